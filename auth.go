@@ -252,15 +252,15 @@ func (s *Service) VerifyMagicLink(ctx context.Context, email, code string, usern
 
 		// not login but update email.
 		if userID.Valid {
-			query := "UPDATE users SET email = $1 WHERE id = $2 RETURNING id, username, avatar"
+			query := "UPDATE users SET email = $1 WHERE id = $2 RETURNING id, username, avatar, avatar_blurhash"
 			row = tx.QueryRowContext(ctx, query, email, userID.String)
 		} else {
-			query := "SELECT id, username, avatar FROM users WHERE email = $1"
+			query := "SELECT id, username, avatar, avatar_blurhash FROM users WHERE email = $1"
 			row = tx.QueryRowContext(ctx, query, email)
 		}
 
-		var avatar sql.NullString
-		err = row.Scan(&auth.User.ID, &auth.User.Username, &avatar)
+		var avatar, avatarBlurHash sql.NullString
+		err = row.Scan(&auth.User.ID, &auth.User.Username, &avatar, &avatarBlurHash)
 		if err == sql.ErrNoRows {
 			if username == nil {
 				return ErrUserNotFound
@@ -293,6 +293,7 @@ func (s *Service) VerifyMagicLink(ctx context.Context, email, code string, usern
 		}
 
 		auth.User.AvatarURL = s.avatarURL(avatar)
+		auth.User.Avatar = s.avatarImage(avatar, avatarBlurHash)
 
 		return nil
 	})
@@ -338,9 +339,9 @@ func (s *Service) DevLogin(ctx context.Context, email string) (AuthOutput, error
 		return out, ErrInvalidEmail
 	}
 
-	var avatar sql.NullString
-	query := "SELECT id, username, avatar FROM users WHERE email = $1"
-	err := s.DB.QueryRowContext(ctx, query, email).Scan(&out.User.ID, &out.User.Username, &avatar)
+	var avatar, avatarBlurHash sql.NullString
+	query := "SELECT id, username, avatar, avatar_blurhash FROM users WHERE email = $1"
+	err := s.DB.QueryRowContext(ctx, query, email).Scan(&out.User.ID, &out.User.Username, &avatar, &avatarBlurHash)
 
 	if err == sql.ErrNoRows {
 		return out, ErrUserNotFound
@@ -351,6 +352,7 @@ func (s *Service) DevLogin(ctx context.Context, email string) (AuthOutput, error
 	}
 
 	out.User.AvatarURL = s.avatarURL(avatar)
+	out.User.Avatar = s.avatarImage(avatar, avatarBlurHash)
 
 	out.Token, err = s.codec().EncodeToString(out.User.ID)
 	if err != nil {
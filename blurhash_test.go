@@ -0,0 +1,31 @@
+package nakama
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_blurHash(t *testing.T) {
+	black := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	draw.Draw(black, black.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	testutil.WantEq(t, "L00000"+strings.Repeat("fQ", 11), blurHash(black), "black")
+
+	red := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	draw.Draw(red, red.Bounds(), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+	got := blurHash(red)
+	testutil.WantEq(t, 28, len(got), "len")
+	testutil.WantEq(t, encodeBase83(0xff0000, 4), got[2:6], "average color")
+
+	gradient := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 50; y++ {
+			gradient.Set(x, y, color.Gray{Y: uint8(x * 255 / 99)})
+		}
+	}
+	testutil.WantEq(t, false, strings.HasSuffix(blurHash(gradient), strings.Repeat("fQ", 11)), "gradient has detail")
+}
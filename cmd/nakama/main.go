@@ -11,11 +11,11 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/go-kit/log"
@@ -29,10 +29,10 @@ import (
 
 	"github.com/nakamauwu/nakama"
 	"github.com/nakamauwu/nakama/mailing"
+	"github.com/nakamauwu/nakama/pubsub"
+	memorypubsub "github.com/nakamauwu/nakama/pubsub/memory"
 	natspubsub "github.com/nakamauwu/nakama/pubsub/nats"
-	"github.com/nakamauwu/nakama/storage"
-	fsstorage "github.com/nakamauwu/nakama/storage/fs"
-	s3storage "github.com/nakamauwu/nakama/storage/s3"
+	pgpubsub "github.com/nakamauwu/nakama/pubsub/postgres"
 	"github.com/nakamauwu/nakama/transport"
 	httptransport "github.com/nakamauwu/nakama/transport/http"
 )
@@ -46,7 +46,15 @@ func main() {
 	logger := log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
 	logger = log.With(logger, "ts", log.DefaultTimestampUTC)
 
-	if err := run(ctx, logger, os.Args[1:]); err != nil {
+	var err error
+	if len(os.Args) > 1 && os.Args[1] == "vapid" {
+		err = runVAPID(ctx, logger, os.Args[2:])
+	} else if len(os.Args) > 1 && os.Args[1] == "storage" {
+		err = runStorage(ctx, logger, os.Args[2:])
+	} else {
+		err = run(ctx, logger, os.Args[1:])
+	}
+	if err != nil {
 		_ = logger.Log("error", err)
 		os.Exit(1)
 	}
@@ -59,18 +67,17 @@ func run(ctx context.Context, logger log.Logger, args []string) error {
 		dbURL               = env("DATABASE_URL", "postgresql://root@127.0.0.1:26257/nakama?sslmode=disable")
 		execSchema, _       = strconv.ParseBool(env("EXEC_SCHEMA", "false"))
 		tokenKey            = env("TOKEN_KEY", "supersecretkeyyoushouldnotcommit")
+		pubsubImpl          = env("PUBSUB", "nats")
 		natsURL             = env("NATS_URL", nats.DefaultURL)
+		natsJetStream, _    = strconv.ParseBool(env("NATS_JETSTREAM", "false"))
+		natsRetention, _    = time.ParseDuration(env("NATS_RETENTION", "24h"))
+		pubsubDBURL         = os.Getenv("PUBSUB_DATABASE_URL")
 		sendgridAPIKey      = os.Getenv("SENDGRID_API_KEY")
 		smtpHost            = env("SMTP_HOST", "smtp.mailtrap.io")
 		smtpPort, _         = strconv.Atoi(env("SMTP_PORT", "25"))
 		smtpUsername        = os.Getenv("SMTP_USERNAME")
 		smtpPassword        = os.Getenv("SMTP_PASSWORD")
 		embedStaticFiles, _ = strconv.ParseBool(env("EMBED_STATIC", "false"))
-		s3Secure, _         = strconv.ParseBool(env("S3_SECURE", "true"))
-		s3Endpoint          = os.Getenv("S3_ENDPOINT")
-		s3Region            = os.Getenv("S3_REGION")
-		s3AccessKey         = os.Getenv("S3_ACCESS_KEY")
-		s3SecretKey         = os.Getenv("S3_SECRET_KEY")
 		avatarURLPrefix     = env("AVATAR_URL_PREFIX", originStr+"/img/avatars/")
 		coverURLPrefix      = env("COVER_URL_PREFIX", originStr+"/img/covers/")
 		mediaURLPrefix      = env("MEDIA_URL_PREFIX", originStr+"/img/media/")
@@ -84,18 +91,31 @@ func run(ctx context.Context, logger log.Logger, args []string) error {
 		allowedOrigins      = os.Getenv("ALLOWED_ORIGINS")
 		vapidPrivateKey     = os.Getenv("VAPID_PRIVATE_KEY")
 		vapidPublicKey      = os.Getenv("VAPID_PUBLIC_KEY")
+		notifRetention, _   = time.ParseDuration(env("NOTIFICATIONS_RETENTION", "2160h"))
+		streamBufSize, _    = strconv.Atoi(env("STREAM_BUFFER_SIZE", "64"))
+		slowConsumer        = env("STREAM_SLOW_CONSUMER", "drop")
+		admins              = os.Getenv("ADMINS")
+		storageQuota, _     = strconv.ParseInt(env("STORAGE_QUOTA", "1073741824"), 10, 64)
+		uploadsPerHour, _   = strconv.Atoi(env("UPLOADS_PER_HOUR", "100"))
+		uploadBytesPerHr, _ = strconv.ParseInt(env("UPLOAD_BYTES_PER_HOUR", "268435456"), 10, 64)
 	)
 
 	fs := flag.NewFlagSet("nakama", flag.ExitOnError)
 	fs.Usage = func() {
 		fs.PrintDefaults()
+		fmt.Println("\nRun \"nakama vapid\" to generate and validate VAPID keys for web push notifications.")
+		fmt.Println("Run \"nakama storage\" to manage stored files.")
 		fmt.Println("\nDon't forget to set TOKEN_KEY, and SENDGRID_API_KEY or SMTP_USERNAME and SMTP_PASSWORD for real usage.")
 	}
 	fs.IntVar(&port, "port", port, "Port in which this server will run")
 	fs.StringVar(&originStr, "origin", originStr, "URL origin for this service")
 	fs.StringVar(&dbURL, "db", dbURL, "Database URL")
 	fs.BoolVar(&execSchema, "exec-schema", execSchema, "Execute database schema")
+	fs.StringVar(&pubsubImpl, "pubsub", pubsubImpl, `Pubsub implementation. Either "nats", "postgres" or "memory" for a single node`)
 	fs.StringVar(&natsURL, "nats", natsURL, "NATS URL")
+	fs.BoolVar(&natsJetStream, "nats-jetstream", natsJetStream, "Persist timeline, notification and comment messages with NATS JetStream")
+	fs.DurationVar(&natsRetention, "nats-retention", natsRetention, "How long NATS JetStream keeps messages")
+	fs.StringVar(&pubsubDBURL, "pubsub-db", pubsubDBURL, "PostgreSQL URL for the postgres pubsub. Required with -pubsub=postgres")
 	fs.StringVar(&smtpHost, "smtp-host", smtpHost, "SMTP server host")
 	fs.IntVar(&smtpPort, "smtp-port", smtpPort, "SMTP server port")
 	fs.BoolVar(&embedStaticFiles, "embed-static", embedStaticFiles, "Embed static files")
@@ -108,6 +128,13 @@ func run(ctx context.Context, logger log.Logger, args []string) error {
 	fs.StringVar(&googleClientID, "google-client-id", googleClientID, "Google client ID")
 	fs.BoolVar(&disabledDevLogin, "disable-dev-login", disabledDevLogin, "Disable development login endpoint")
 	fs.StringVar(&allowedOrigins, "allowed-origins", allowedOrigins, "Comma separated list of allowed origins")
+	fs.DurationVar(&notifRetention, "notifications-retention", notifRetention, "How long to keep read notifications. Zero disables pruning")
+	fs.IntVar(&streamBufSize, "stream-buffer-size", streamBufSize, "Number of events buffered per realtime stream subscriber")
+	fs.StringVar(&slowConsumer, "stream-slow-consumer", slowConsumer, `What to do with slow realtime stream subscribers. Either "drop" events or "disconnect"`)
+	fs.StringVar(&admins, "admins", admins, "Comma separated list of admin user IDs. Admins can manage instance webhooks")
+	fs.Int64Var(&storageQuota, "storage-quota", storageQuota, "Max bytes each user can store across avatars, covers and media. Zero disables it")
+	fs.IntVar(&uploadsPerHour, "uploads-per-hour", uploadsPerHour, "Max media items each user can upload per hour. Zero disables it")
+	fs.Int64Var(&uploadBytesPerHr, "upload-bytes-per-hour", uploadBytesPerHr, "Max media bytes each user can upload per hour. Zero disables it")
 	if err := fs.Parse(args); err != nil {
 		return fmt.Errorf("could not parse flags: %w", err)
 	}
@@ -127,6 +154,17 @@ func run(ctx context.Context, logger log.Logger, args []string) error {
 		port = i
 	}
 
+	slowConsumerPolicy := nakama.SlowConsumerPolicy(slowConsumer)
+	if slowConsumerPolicy != nakama.SlowConsumerDrop && slowConsumerPolicy != nakama.SlowConsumerDisconnect {
+		return fmt.Errorf("invalid stream slow consumer policy %q", slowConsumer)
+	}
+
+	if vapidPrivateKey == "" && vapidPublicKey == "" {
+		_ = logger.Log("message", "web push notifications disabled: VAPID_PRIVATE_KEY and VAPID_PUBLIC_KEY not set")
+	} else if err := nakama.ValidateVAPIDKeys(vapidPublicKey, vapidPrivateKey); err != nil {
+		return fmt.Errorf("could not validate VAPID keys: %w", err)
+	}
+
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		return fmt.Errorf("could not open db connection: %w", err)
@@ -145,12 +183,59 @@ func run(ctx context.Context, logger log.Logger, args []string) error {
 		}
 	}
 
-	natsConn, err := nats.Connect(natsURL)
-	if err != nil {
-		return fmt.Errorf("could not connect to NATS server: %w", err)
-	}
+	var ps pubsub.PubSub
+	switch pubsubImpl {
+	case "nats":
+		_ = logger.Log("pubsub_implementation", "nats")
+		natsConn, err := nats.Connect(natsURL)
+		if err != nil {
+			return fmt.Errorf("could not connect to NATS server: %w", err)
+		}
+
+		defer natsConn.Close()
+
+		nps := &natspubsub.PubSub{
+			Conn:      natsConn,
+			JetStream: natsJetStream,
+			Retention: natsRetention,
+		}
+		if err := nps.Setup(ctx); err != nil {
+			return fmt.Errorf("could not setup NATS pubsub: %w", err)
+		}
+
+		ps = nps
+	case "postgres":
+		_ = logger.Log("pubsub_implementation", "postgres")
+		// the main database is usually CockroachDB which has no LISTEN/NOTIFY.
+		if pubsubDBURL == "" {
+			return errors.New("postgres pubsub requires PUBSUB_DATABASE_URL")
+		}
+
+		pubsubDB, err := sql.Open("postgres", pubsubDBURL)
+		if err != nil {
+			return fmt.Errorf("could not open pubsub db connection: %w", err)
+		}
+
+		defer pubsubDB.Close()
 
-	pubsub := &natspubsub.PubSub{Conn: natsConn}
+		pg := &pgpubsub.PubSub{
+			DB:             pubsubDB,
+			DataSourceName: pubsubDBURL,
+			Logger:         log.With(logger, "component", "pubsub"),
+		}
+		if err := pg.Setup(ctx); err != nil {
+			return fmt.Errorf("could not setup postgres pubsub: %w", err)
+		}
+
+		defer pg.Close()
+
+		ps = pg
+	case "memory":
+		_ = logger.Log("pubsub_implementation", "memory")
+		ps = &memorypubsub.PubSub{}
+	default:
+		return fmt.Errorf("unknown pubsub implementation %q", pubsubImpl)
+	}
 
 	var sender mailing.Sender
 	sendFrom := "no-reply@" + origin.Hostname()
@@ -174,50 +259,39 @@ func run(ctx context.Context, logger log.Logger, args []string) error {
 		)
 	}
 
-	var store storage.Store
-	s3Enabled := s3Endpoint != "" && s3AccessKey != "" && s3SecretKey != ""
-	if s3Enabled {
-		_ = logger.Log("storage_implementation", "s3")
-		s3 := &s3storage.Store{
-			Secure:     s3Secure,
-			Endpoint:   s3Endpoint,
-			Region:     s3Region,
-			AccessKey:  s3AccessKey,
-			SecretKey:  s3SecretKey,
-			BucketList: []string{nakama.AvatarsBucket, nakama.CoversBucket, nakama.MediaBucket},
-		}
-		if err := s3.Setup(ctx); err != nil {
-			return fmt.Errorf("could not setup S3 storage: %w", err)
-		}
-
-		store = s3
-	} else {
-		_ = logger.Log("storage_implementation", "os file system")
-		wd, err := os.Getwd()
-		if err != nil {
-			return fmt.Errorf("could not get current working directory: %w", err)
-		}
-
-		store = &fsstorage.Store{Root: filepath.Join(wd, "web", "static", "img")}
+	store, err := newStore(ctx, logger)
+	if err != nil {
+		return err
 	}
 
-	var svc transport.Service = &nakama.Service{
-		Logger:           logger,
-		DB:               db,
-		Sender:           sender,
-		Origin:           origin,
-		TokenKey:         tokenKey,
-		PubSub:           pubsub,
-		Store:            store,
-		AvatarURLPrefix:  avatarURLPrefix,
-		CoverURLPrefix:   coverURLPrefix,
-		MediaURLPrefix:   mediaURLPrefix,
-		DisabledDevLogin: disabledDevLogin,
-		AllowedOrigins:   strings.Split(allowedOrigins, ","),
-		VAPIDPrivateKey:  vapidPrivateKey,
-		VAPIDPublicKey:   vapidPublicKey,
+	service := &nakama.Service{
+		Logger:                 logger,
+		DB:                     db,
+		Sender:                 sender,
+		Origin:                 origin,
+		TokenKey:               tokenKey,
+		PubSub:                 ps,
+		Store:                  store,
+		AvatarURLPrefix:        avatarURLPrefix,
+		CoverURLPrefix:         coverURLPrefix,
+		MediaURLPrefix:         mediaURLPrefix,
+		DisabledDevLogin:       disabledDevLogin,
+		AllowedOrigins:         strings.Split(allowedOrigins, ","),
+		VAPIDPrivateKey:        vapidPrivateKey,
+		VAPIDPublicKey:         vapidPublicKey,
+		NotificationsRetention: notifRetention,
+		StreamBufferSize:       streamBufSize,
+		SlowConsumerPolicy:     slowConsumerPolicy,
+		Admins:                 strings.Split(admins, ","),
+		StorageQuota:           storageQuota,
+		UploadsPerHour:         uploadsPerHour,
+		UploadBytesPerHour:     uploadBytesPerHr,
 	}
 
+	service.RunBackgroundJobs(ctx)
+
+	var svc transport.Service = service
+
 	var promHandler http.Handler
 	{
 		promHandler = promhttp.Handler()
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama"
+	"github.com/nakamauwu/nakama/storage"
+	fsstorage "github.com/nakamauwu/nakama/storage/fs"
+	s3storage "github.com/nakamauwu/nakama/storage/s3"
+)
+
+const storageUsage = `Usage: nakama storage <command> [flags]
+
+Commands:
+  gc    Delete stored files no longer referenced. Use -dry-run to only list them`
+
+func runStorage(ctx context.Context, logger log.Logger, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, storageUsage)
+		return errors.New("missing storage command")
+	}
+
+	switch cmd, args := args[0], args[1:]; cmd {
+	case "gc":
+		return runStorageGC(ctx, logger, args)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprintln(os.Stderr, storageUsage)
+		return nil
+	default:
+		fmt.Fprintln(os.Stderr, storageUsage)
+		return fmt.Errorf("unknown storage command %q", cmd)
+	}
+}
+
+func runStorageGC(ctx context.Context, logger log.Logger, args []string) error {
+	var (
+		dbURL  = env("DATABASE_URL", "postgresql://root@127.0.0.1:26257/nakama?sslmode=disable")
+		dryRun bool
+	)
+
+	fs := flag.NewFlagSet("nakama storage gc", flag.ExitOnError)
+	fs.StringVar(&dbURL, "db", dbURL, "Database URL")
+	fs.BoolVar(&dryRun, "dry-run", dryRun, "Only list the unreferenced files")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("could not parse flags: %w", err)
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return fmt.Errorf("could not open db connection: %w", err)
+	}
+
+	defer db.Close()
+
+	if err = db.PingContext(ctx); err != nil {
+		return fmt.Errorf("could not ping to db: %w", err)
+	}
+
+	store, err := newStore(ctx, logger)
+	if err != nil {
+		return err
+	}
+
+	service := &nakama.Service{
+		Logger: logger,
+		DB:     db,
+		Store:  store,
+	}
+
+	res, err := service.CollectStorageGarbage(ctx, dryRun)
+	for _, orphan := range res.Orphans {
+		fmt.Println(orphan)
+	}
+	fmt.Printf("scanned: %d, unreferenced: %d, deleted: %d\n", res.Scanned, len(res.Orphans), res.Deleted)
+	return err
+}
+
+// newStore uses S3 when S3_ENDPOINT, S3_ACCESS_KEY and S3_SECRET_KEY are set,
+// and the file system otherwise.
+func newStore(ctx context.Context, logger log.Logger) (storage.Store, error) {
+	var (
+		s3Secure, _ = strconv.ParseBool(env("S3_SECURE", "true"))
+		s3Endpoint  = os.Getenv("S3_ENDPOINT")
+		s3Region    = os.Getenv("S3_REGION")
+		s3AccessKey = os.Getenv("S3_ACCESS_KEY")
+		s3SecretKey = os.Getenv("S3_SECRET_KEY")
+	)
+
+	s3Enabled := s3Endpoint != "" && s3AccessKey != "" && s3SecretKey != ""
+	if s3Enabled {
+		_ = logger.Log("storage_implementation", "s3")
+		s3 := &s3storage.Store{
+			Secure:     s3Secure,
+			Endpoint:   s3Endpoint,
+			Region:     s3Region,
+			AccessKey:  s3AccessKey,
+			SecretKey:  s3SecretKey,
+			BucketList: []string{nakama.AvatarsBucket, nakama.CoversBucket, nakama.MediaBucket, nakama.UploadsBucket},
+		}
+		if err := s3.Setup(ctx); err != nil {
+			return nil, fmt.Errorf("could not setup S3 storage: %w", err)
+		}
+
+		return s3, nil
+	}
+
+	_ = logger.Log("storage_implementation", "os file system")
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("could not get current working directory: %w", err)
+	}
+
+	return &fsstorage.Store{Root: filepath.Join(wd, "web", "static", "img")}, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/SherClockHolmes/webpush-go"
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama"
+)
+
+const vapidUsage = `Usage: nakama vapid <command> [flags]
+
+Commands:
+  generate    Generate a new VAPID key pair
+  validate    Validate VAPID_PUBLIC_KEY and VAPID_PRIVATE_KEY
+  test-push   Send a test web push notification to a user's subscriptions`
+
+func runVAPID(ctx context.Context, logger log.Logger, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, vapidUsage)
+		return errors.New("missing vapid command")
+	}
+
+	switch cmd, args := args[0], args[1:]; cmd {
+	case "generate":
+		return runVAPIDGenerate(args)
+	case "validate":
+		return runVAPIDValidate(args)
+	case "test-push":
+		return runVAPIDTestPush(ctx, logger, args)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprintln(os.Stderr, vapidUsage)
+		return nil
+	default:
+		fmt.Fprintln(os.Stderr, vapidUsage)
+		return fmt.Errorf("unknown vapid command %q", cmd)
+	}
+}
+
+func runVAPIDGenerate(args []string) error {
+	fs := flag.NewFlagSet("nakama vapid generate", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("could not parse flags: %w", err)
+	}
+
+	privateKey, publicKey, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		return fmt.Errorf("could not generate VAPID keys: %w", err)
+	}
+
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\n", publicKey)
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n", privateKey)
+	return nil
+}
+
+func runVAPIDValidate(args []string) error {
+	var (
+		vapidPrivateKey = os.Getenv("VAPID_PRIVATE_KEY")
+		vapidPublicKey  = os.Getenv("VAPID_PUBLIC_KEY")
+	)
+
+	fs := flag.NewFlagSet("nakama vapid validate", flag.ExitOnError)
+	fs.StringVar(&vapidPublicKey, "public-key", vapidPublicKey, "VAPID public key")
+	fs.StringVar(&vapidPrivateKey, "private-key", vapidPrivateKey, "VAPID private key")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("could not parse flags: %w", err)
+	}
+
+	if vapidPublicKey == "" || vapidPrivateKey == "" {
+		return errors.New("VAPID_PUBLIC_KEY and VAPID_PRIVATE_KEY must be set")
+	}
+
+	if err := nakama.ValidateVAPIDKeys(vapidPublicKey, vapidPrivateKey); err != nil {
+		return err
+	}
+
+	fmt.Println("VAPID keys are valid")
+	return nil
+}
+
+func runVAPIDTestPush(ctx context.Context, logger log.Logger, args []string) error {
+	var (
+		dbURL           = env("DATABASE_URL", "postgresql://root@127.0.0.1:26257/nakama?sslmode=disable")
+		vapidPrivateKey = os.Getenv("VAPID_PRIVATE_KEY")
+		vapidPublicKey  = os.Getenv("VAPID_PUBLIC_KEY")
+		userID          string
+	)
+
+	fs := flag.NewFlagSet("nakama vapid test-push", flag.ExitOnError)
+	fs.StringVar(&dbURL, "db", dbURL, "Database URL")
+	fs.StringVar(&userID, "user", userID, "ID of the user to send the test notification to")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("could not parse flags: %w", err)
+	}
+
+	if userID == "" {
+		return errors.New("missing -user flag")
+	}
+
+	if err := nakama.ValidateVAPIDKeys(vapidPublicKey, vapidPrivateKey); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return fmt.Errorf("could not open db connection: %w", err)
+	}
+
+	defer db.Close()
+
+	if err = db.PingContext(ctx); err != nil {
+		return fmt.Errorf("could not ping to db: %w", err)
+	}
+
+	service := &nakama.Service{
+		Logger:          logger,
+		DB:              db,
+		VAPIDPrivateKey: vapidPrivateKey,
+		VAPIDPublicKey:  vapidPublicKey,
+	}
+
+	res, err := service.SendTestWebPush(ctx, userID)
+	fmt.Printf("sent: %d, pruned: %d, failed: %d\n", res.Sent, res.Pruned, res.Failed)
+	return err
+}
@@ -1,13 +1,11 @@
 package nakama
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -134,6 +132,7 @@ func (s *Service) commentCreated(c Comment) {
 	go s.notifyComment(c)
 	go s.notifyCommentMention(c)
 	go s.broadcastComment(c)
+	go s.commentWebhook(c)
 }
 
 type Comments []Comment
@@ -173,6 +172,7 @@ func (s *Service) Comments(ctx context.Context, postID string, last uint64, befo
 		, comments.created_at
 		, users.username
 		, users.avatar
+		, users.avatar_blurhash
 		{{if .auth}}
 		, comments.user_id = @uid AS comment_mine
 		, reactions.user_reactions
@@ -222,8 +222,8 @@ func (s *Service) Comments(ctx context.Context, postID string, last uint64, befo
 		var rawReactions []byte
 		var rawUserReactions []byte
 		var u User
-		var avatar sql.NullString
-		dest := []interface{}{&c.ID, &c.Content, &rawReactions, &c.CreatedAt, &u.Username, &avatar}
+		var avatar, avatarBlurHash sql.NullString
+		dest := []interface{}{&c.ID, &c.Content, &rawReactions, &c.CreatedAt, &u.Username, &avatar, &avatarBlurHash}
 		if auth {
 			dest = append(dest, &c.Mine, &rawUserReactions)
 		}
@@ -258,6 +258,7 @@ func (s *Service) Comments(ctx context.Context, postID string, last uint64, befo
 		}
 
 		u.AvatarURL = s.avatarURL(avatar)
+		u.Avatar = s.avatarImage(avatar, avatarBlurHash)
 		c.User = &u
 		cc = append(cc, c)
 	}
@@ -270,42 +271,31 @@ func (s *Service) Comments(ctx context.Context, postID string, last uint64, befo
 }
 
 // CommentStream to receive comments in realtime.
-func (s *Service) CommentStream(ctx context.Context, postID string) (<-chan Comment, error) {
+// Pass the ID of the last received event to resume right after it.
+func (s *Service) CommentStream(ctx context.Context, postID, lastEventID string) (<-chan StreamEvent[Comment], error) {
 	if !reUUID.MatchString(postID) {
 		return nil, ErrInvalidPostID
 	}
 
-	cc := make(chan Comment)
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
-	unsub, err := s.PubSub.Sub(commentTopic(postID), func(data []byte) {
-		go func(r io.Reader) {
-			var c Comment
-			err := gob.NewDecoder(r).Decode(&c)
-			if err != nil {
-				_ = s.Logger.Log("error", fmt.Errorf("could not gob decode comment: %w", err))
-				return
-			}
+	cc, err := subscribeStream(ctx, s, commentTopic(postID), lastEventID, func(data []byte) (Comment, bool) {
+		c, err := unmarshalComment(data)
+		if errors.Is(err, errUnexpectedWireType) {
+			// skip other wire types, like ones from newer nodes.
+			return c, false
+		}
 
-			if auth && uid == c.UserID {
-				return
-			}
+		if err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not decode comment: %w", err))
+			return c, false
+		}
 
-			cc <- c
-		}(bytes.NewReader(data))
+		return c, !auth || uid != c.UserID
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could not subscribe to comments: %w", err)
 	}
 
-	go func() {
-		<-ctx.Done()
-		if err := unsub(); err != nil {
-			_ = s.Logger.Log("error", fmt.Errorf("could not unsubcribe from comments: %w", err))
-			// don't return
-		}
-		close(cc)
-	}()
-
 	return cc, nil
 }
 
@@ -588,14 +578,13 @@ func (s *Service) ToggleCommentReaction(ctx context.Context, commentID string, i
 }
 
 func (s *Service) broadcastComment(c Comment) {
-	var b bytes.Buffer
-	err := gob.NewEncoder(&b).Encode(c)
+	b, err := marshalComment(c)
 	if err != nil {
-		_ = s.Logger.Log("error", fmt.Errorf("could not gob encode comment: %w", err))
+		_ = s.Logger.Log("error", fmt.Errorf("could not encode comment: %w", err))
 		return
 	}
 
-	err = s.PubSub.Pub(commentTopic(c.PostID), b.Bytes())
+	err = s.PubSub.Pub(commentTopic(c.PostID), b)
 	if err != nil {
 		_ = s.Logger.Log("error", fmt.Errorf("could not publish comment: %w", err))
 		return
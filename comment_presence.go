@@ -0,0 +1,326 @@
+package nakama
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// commentTypingTTL is how long a typing signal lasts.
+	// Clients should keep sending them while the user types.
+	commentTypingTTL = time.Second * 6
+	// commentViewerTTL is how long a viewer is considered online
+	// without a new heartbeat from its stream.
+	commentViewerTTL       = time.Second * 45
+	commentViewerHeartbeat = commentViewerTTL / 3
+	commentPresenceSweep   = time.Second
+)
+
+const (
+	commentPresenceView   = "view"
+	commentPresenceTyping = "typing"
+	commentPresenceLeave  = "leave"
+)
+
+// CommentPresence of the users viewing the comments of a post
+// and the ones currently typing a comment.
+type CommentPresence struct {
+	PostID  string `json:"postID"`
+	Viewers []User `json:"viewers"`
+	Typing  []User `json:"typing"`
+}
+
+// commentPresenceSignal is the ephemeral message broadcasted
+// over the post comment presence topic.
+type commentPresenceSignal struct {
+	PostID string `json:"postID"`
+	Kind   string `json:"kind"`
+	User   User   `json:"user"`
+	// TTL in milliseconds.
+	TTL int64 `json:"ttl"`
+}
+
+// SendCommentTyping signals the viewers of a post comments
+// that the auth user is typing a comment.
+// Nothing gets persisted; the signal expires after a few seconds.
+func (s *Service) SendCommentTyping(ctx context.Context, postID string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(postID) {
+		return ErrInvalidPostID
+	}
+
+	u, err := s.presenceUser(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	return s.broadcastCommentPresence(commentPresenceSignal{
+		PostID: postID,
+		Kind:   commentPresenceTyping,
+		User:   u,
+		TTL:    commentTypingTTL.Milliseconds(),
+	})
+}
+
+// CommentPresenceStream to receive who is viewing and typing on a post comments in realtime.
+// Authenticated users announce themselves as viewers while the stream is open.
+// Every event is a full snapshot.
+func (s *Service) CommentPresenceStream(ctx context.Context, postID string) (<-chan CommentPresence, error) {
+	if !reUUID.MatchString(postID) {
+		return nil, ErrInvalidPostID
+	}
+
+	var viewer *User
+	if uid, ok := ctx.Value(KeyAuthUserID).(string); ok {
+		u, err := s.presenceUser(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+
+		viewer = &u
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	signals, err := subscribeStream(ctx, s, commentPresenceTopic(postID), "", func(data []byte) (commentPresenceSignal, bool) {
+		return s.decodeCommentPresence(data)
+	})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("could not subscribe to comment presence: %w", err)
+	}
+
+	// a new comment means its author is done typing.
+	stops, err := subscribeStream(ctx, s, commentTopic(postID), "", decodeCommentTypingStop)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("could not subscribe to comments: %w", err)
+	}
+
+	out := make(chan CommentPresence)
+	go func() {
+		defer cancel()
+		s.runCommentPresence(ctx, postID, viewer, signals, stops, out)
+	}()
+
+	return out, nil
+}
+
+func (s *Service) runCommentPresence(ctx context.Context, postID string, viewer *User, signals, stops <-chan StreamEvent[commentPresenceSignal], out chan<- CommentPresence) {
+	defer close(out)
+
+	view := func(kind string) {
+		if viewer == nil {
+			return
+		}
+
+		err := s.broadcastCommentPresence(commentPresenceSignal{
+			PostID: postID,
+			Kind:   kind,
+			User:   *viewer,
+			TTL:    commentViewerTTL.Milliseconds(),
+		})
+		if err != nil {
+			_ = s.Logger.Log("error", err)
+		}
+	}
+
+	heartbeat := time.NewTicker(commentViewerHeartbeat)
+	defer heartbeat.Stop()
+
+	sweep := time.NewTicker(commentPresenceSweep)
+	defer sweep.Stop()
+
+	view(commentPresenceView)
+	defer view(commentPresenceLeave)
+
+	tracker := newCommentPresenceTracker(postID)
+	for {
+		var changed bool
+		select {
+		case ev, ok := <-signals:
+			if !ok {
+				return
+			}
+
+			changed = tracker.apply(ev.Item, time.Now())
+		case ev, ok := <-stops:
+			if !ok {
+				return
+			}
+
+			changed = tracker.apply(ev.Item, time.Now())
+		case <-heartbeat.C:
+			view(commentPresenceView)
+		case now := <-sweep.C:
+			changed = tracker.expire(now)
+		case <-ctx.Done():
+			return
+		}
+
+		if !changed {
+			continue
+		}
+
+		select {
+		case out <- tracker.snapshot():
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Service) decodeCommentPresence(data []byte) (commentPresenceSignal, bool) {
+	var sig commentPresenceSignal
+	if err := unmarshalWire(data, wireTypeCommentPresence, &sig); err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not decode comment presence: %w", err))
+		return sig, false
+	}
+
+	return sig, true
+}
+
+// decodeCommentTypingStop turns a new comment
+// into a signal that stops its author typing.
+func decodeCommentTypingStop(data []byte) (commentPresenceSignal, bool) {
+	c, err := unmarshalComment(data)
+	if err != nil {
+		return commentPresenceSignal{}, false
+	}
+
+	return commentPresenceSignal{
+		PostID: c.PostID,
+		Kind:   commentPresenceTyping,
+		User:   User{ID: c.UserID},
+	}, true
+}
+
+func (s *Service) broadcastCommentPresence(sig commentPresenceSignal) error {
+	b, err := marshalEphemeralWire(wireTypeCommentPresence, sig)
+	if err != nil {
+		return fmt.Errorf("could not encode comment presence: %w", err)
+	}
+
+	if err := s.PubSub.Pub(commentPresenceTopic(sig.PostID), b); err != nil {
+		return fmt.Errorf("could not publish comment presence: %w", err)
+	}
+
+	return nil
+}
+
+// commentPresenceTopic is separate from commentTopic
+// so comment subscribers do not wake up for every presence heartbeat.
+func commentPresenceTopic(postID string) string { return "comment_presence_" + postID }
+
+func (s *Service) presenceUser(ctx context.Context, userID string) (User, error) {
+	u := User{ID: userID}
+	var avatar, avatarBlurHash sql.NullString
+	query := "SELECT username, avatar, avatar_blurhash FROM users WHERE id = $1"
+	err := s.DB.QueryRowContext(ctx, query, userID).Scan(&u.Username, &avatar, &avatarBlurHash)
+	if err == sql.ErrNoRows {
+		return u, ErrUserNotFound
+	}
+
+	if err != nil {
+		return u, fmt.Errorf("could not sql query presence user: %w", err)
+	}
+
+	u.AvatarURL = s.avatarURL(avatar)
+	u.Avatar = s.avatarImage(avatar, avatarBlurHash)
+	return u, nil
+}
+
+type commentPresenceEntry struct {
+	user      User
+	expiresAt time.Time
+}
+
+// commentPresenceTracker keeps the presence state of a single subscriber.
+// Entries expire on their own, so crashed nodes do not leave ghosts behind.
+type commentPresenceTracker struct {
+	postID  string
+	viewers map[string]commentPresenceEntry
+	typing  map[string]commentPresenceEntry
+}
+
+func newCommentPresenceTracker(postID string) *commentPresenceTracker {
+	return &commentPresenceTracker{
+		postID:  postID,
+		viewers: map[string]commentPresenceEntry{},
+		typing:  map[string]commentPresenceEntry{},
+	}
+}
+
+// apply a signal and report whether the snapshot changed.
+// A typing signal without TTL stops typing.
+func (t *commentPresenceTracker) apply(sig commentPresenceSignal, now time.Time) bool {
+	if sig.PostID != t.postID || sig.User.ID == "" {
+		return false
+	}
+
+	entries := t.viewers
+	if sig.Kind == commentPresenceTyping {
+		entries = t.typing
+	}
+
+	_, existed := entries[sig.User.ID]
+	if sig.Kind == commentPresenceLeave || sig.TTL <= 0 {
+		delete(entries, sig.User.ID)
+		if sig.Kind == commentPresenceLeave {
+			delete(t.typing, sig.User.ID)
+		}
+		return existed
+	}
+
+	switch sig.Kind {
+	case commentPresenceView, commentPresenceTyping:
+	default:
+		return false
+	}
+
+	entries[sig.User.ID] = commentPresenceEntry{
+		user:      sig.User,
+		expiresAt: now.Add(time.Duration(sig.TTL) * time.Millisecond),
+	}
+	return !existed
+}
+
+// expire removes the entries past their TTL and reports whether any was removed.
+func (t *commentPresenceTracker) expire(now time.Time) bool {
+	var changed bool
+	for _, entries := range []map[string]commentPresenceEntry{t.viewers, t.typing} {
+		for id, e := range entries {
+			if !now.Before(e.expiresAt) {
+				delete(entries, id)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func (t *commentPresenceTracker) snapshot() CommentPresence {
+	return CommentPresence{
+		PostID:  t.postID,
+		Viewers: presenceUsers(t.viewers),
+		Typing:  presenceUsers(t.typing),
+	}
+}
+
+func presenceUsers(entries map[string]commentPresenceEntry) []User {
+	uu := make([]User, 0, len(entries))
+	for _, e := range entries {
+		uu = append(uu, e.user)
+	}
+	sort.Slice(uu, func(i, j int) bool {
+		return uu[i].Username < uu[j].Username
+	})
+	return uu
+}
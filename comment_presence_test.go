@@ -0,0 +1,89 @@
+package nakama
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama/pubsub/memory"
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_commentPresenceTracker(t *testing.T) {
+	const postID = "post"
+	now := time.Now()
+	alice := User{ID: "1", Username: "alice"}
+	bob := User{ID: "2", Username: "bob"}
+
+	tr := newCommentPresenceTracker(postID)
+	testutil.WantEq(t, true, tr.apply(commentPresenceSignal{PostID: postID, Kind: commentPresenceView, User: bob, TTL: 1000}, now), "bob views")
+	testutil.WantEq(t, true, tr.apply(commentPresenceSignal{PostID: postID, Kind: commentPresenceView, User: alice, TTL: 2000}, now), "alice views")
+	testutil.WantEq(t, false, tr.apply(commentPresenceSignal{PostID: postID, Kind: commentPresenceView, User: alice, TTL: 2000}, now), "alice heartbeat")
+	testutil.WantEq(t, false, tr.apply(commentPresenceSignal{PostID: "other", Kind: commentPresenceView, User: alice, TTL: 2000}, now), "other post")
+	testutil.WantEq(t, true, tr.apply(commentPresenceSignal{PostID: postID, Kind: commentPresenceTyping, User: alice, TTL: 500}, now), "alice types")
+	testutil.WantEq(t, CommentPresence{PostID: postID, Viewers: []User{alice, bob}, Typing: []User{alice}}, tr.snapshot(), "snapshot")
+
+	testutil.WantEq(t, false, tr.expire(now.Add(time.Millisecond*100)), "nothing expired")
+	testutil.WantEq(t, true, tr.expire(now.Add(time.Millisecond*1000)), "typing and bob expired")
+	testutil.WantEq(t, CommentPresence{PostID: postID, Viewers: []User{alice}, Typing: []User{}}, tr.snapshot(), "after expiry")
+
+	tr.apply(commentPresenceSignal{PostID: postID, Kind: commentPresenceTyping, User: alice, TTL: 500}, now)
+	testutil.WantEq(t, true, tr.apply(commentPresenceSignal{PostID: postID, Kind: commentPresenceLeave, User: alice}, now), "alice leaves")
+	testutil.WantEq(t, CommentPresence{PostID: postID, Viewers: []User{}, Typing: []User{}}, tr.snapshot(), "after leave")
+}
+
+func TestService_CommentPresenceStream(t *testing.T) {
+	ps := &memory.PubSub{}
+	svc := &Service{Logger: log.NewNopLogger(), PubSub: ps}
+	postID := "00000000-0000-0000-0000-000000000001"
+	alice := User{ID: "00000000-0000-0000-0000-000000000002", Username: "alice"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pp, err := svc.CommentPresenceStream(ctx, postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = svc.broadcastCommentPresence(commentPresenceSignal{
+		PostID: postID,
+		Kind:   commentPresenceTyping,
+		User:   alice,
+		TTL:    commentTypingTTL.Milliseconds(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case p := <-pp:
+		testutil.WantEq(t, []User{alice}, p.Typing, "typing")
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for presence")
+	}
+
+	// a new comment stops its author typing.
+	svc.broadcastComment(Comment{ID: "c", UserID: alice.ID, PostID: postID})
+
+	select {
+	case p := <-pp:
+		testutil.WantEq(t, []User{}, p.Typing, "typing after comment")
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for presence")
+	}
+
+	t.Run("not_replayed", func(t *testing.T) {
+		replay := svc.streamReplays.topics[commentTopic(postID)]
+		replay.mu.Lock()
+		testutil.WantEq(t, 1, len(replay.entries), "retained comments")
+		replay.mu.Unlock()
+
+		replay = svc.streamReplays.topics[commentPresenceTopic(postID)]
+		replay.mu.Lock()
+		defer replay.mu.Unlock()
+		testutil.WantEq(t, 0, len(replay.entries), "retained presence")
+	})
+}
@@ -0,0 +1,458 @@
+package nakama
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/v2/crdb"
+	"github.com/lib/pq"
+)
+
+// conversationMaxParticipants including the creator.
+const conversationMaxParticipants = 8
+
+var (
+	// ErrInvalidConversationID denotes an invalid conversation ID; that is not uuid.
+	ErrInvalidConversationID = InvalidArgumentError("invalid conversation ID")
+	// ErrInvalidConversationParticipants denotes an empty list of participants,
+	// one including the authenticated user, or one exceeding the max allowed participants (7).
+	ErrInvalidConversationParticipants = InvalidArgumentError("invalid conversation participants")
+	// ErrConversationNotFound denotes a not found conversation,
+	// or one the authenticated user does not participate in.
+	ErrConversationNotFound = NotFoundError("conversation not found")
+	// ErrDirectMessagesNotAllowed denotes that a user does not accept
+	// direct messages from the authenticated user. Either because of a block,
+	// or because they only accept them from the users they follow.
+	ErrDirectMessagesNotAllowed = PermissionDeniedError("direct messages not allowed")
+)
+
+// Conversation model.
+// Participants does not include the authenticated user.
+type Conversation struct {
+	ID            string                    `json:"id"`
+	Group         bool                      `json:"group"`
+	Participants  []ConversationParticipant `json:"participants"`
+	LastMessage   *Message                  `json:"lastMessage"`
+	UnreadCount   uint64                    `json:"unreadCount"`
+	LastMessageAt time.Time                 `json:"lastMessageAt"`
+	CreatedAt     time.Time                 `json:"createdAt"`
+}
+
+// ConversationParticipant along with when they last read the conversation;
+// messages sent before that have been seen.
+type ConversationParticipant struct {
+	User
+	LastReadAt *time.Time `json:"lastReadAt"`
+}
+
+type Conversations []Conversation
+
+func (cc Conversations) EndCursor() *string {
+	if len(cc) == 0 {
+		return nil
+	}
+
+	last := cc[len(cc)-1]
+	return ptrString(encodeCursor(last.ID, last.LastMessageAt))
+}
+
+// DMSettings of the authenticated user.
+type DMSettings struct {
+	// FromFolloweesOnly only accepts direct messages
+	// from the users the authenticated user follows.
+	FromFolloweesOnly bool `json:"fromFolloweesOnly"`
+}
+
+// CreateConversation between the authenticated user and the given users.
+// A single user starts a direct conversation; if one already exists it is returned instead.
+// More users start a group conversation.
+func (s *Service) CreateConversation(ctx context.Context, usernames []string) (Conversation, error) {
+	var out Conversation
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	usernames, ok = normalizeUsernames(usernames)
+	if !ok {
+		return out, ErrInvalidUsername
+	}
+
+	if len(usernames) == 0 || len(usernames) >= conversationMaxParticipants {
+		return out, ErrInvalidConversationParticipants
+	}
+
+	var conversationID string
+	err := crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT users.id
+			, users.dms_from_followees_only
+			, follows.follower_id IS NOT NULL AS follows_me
+			, user_blocks.blocker_id IS NOT NULL AS blocked
+			FROM users
+			LEFT JOIN follows
+				ON follows.follower_id = users.id AND follows.followee_id = $1
+			LEFT JOIN user_blocks
+				ON (user_blocks.blocker_id = users.id AND user_blocks.blocked_id = $1)
+					OR (user_blocks.blocker_id = $1 AND user_blocks.blocked_id = users.id)
+			WHERE users.username = ANY($2)`, uid, pq.Array(usernames))
+		if err != nil {
+			return fmt.Errorf("could not sql query select conversation participants: %w", err)
+		}
+
+		defer rows.Close()
+
+		participants := map[string]struct{}{}
+		for rows.Next() {
+			var id string
+			var fromFolloweesOnly, followsMe, blocked bool
+			if err := rows.Scan(&id, &fromFolloweesOnly, &followsMe, &blocked); err != nil {
+				return fmt.Errorf("could not sql scan conversation participant: %w", err)
+			}
+
+			if id == uid {
+				return ErrInvalidConversationParticipants
+			}
+
+			if blocked || (fromFolloweesOnly && !followsMe) {
+				return ErrDirectMessagesNotAllowed
+			}
+
+			participants[id] = struct{}{}
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("could not iterate over conversation participant rows: %w", err)
+		}
+
+		if len(participants) != len(usernames) {
+			return ErrUserNotFound
+		}
+
+		var directKey *string
+		if len(participants) == 1 {
+			for id := range participants {
+				directKey = ptrString(directConversationKey(uid, id))
+			}
+		}
+
+		query := `
+			INSERT INTO conversations (direct_key) VALUES ($1)
+			ON CONFLICT (direct_key) DO NOTHING
+			RETURNING id`
+		err = tx.QueryRowContext(ctx, query, directKey).Scan(&conversationID)
+		if err == sql.ErrNoRows && directKey != nil {
+			query = "SELECT id FROM conversations WHERE direct_key = $1"
+			if err := tx.QueryRowContext(ctx, query, *directKey).Scan(&conversationID); err != nil {
+				return fmt.Errorf("could not sql query select direct conversation: %w", err)
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not sql insert conversation: %w", err)
+		}
+
+		participantIDs := []string{uid}
+		for id := range participants {
+			participantIDs = append(participantIDs, id)
+		}
+
+		query = `
+			INSERT INTO conversation_participants (conversation_id, user_id)
+			SELECT $1, unnest($2::UUID[])`
+		if _, err := tx.ExecContext(ctx, query, conversationID, pq.Array(participantIDs)); err != nil {
+			return fmt.Errorf("could not sql insert conversation participants: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return out, err
+	}
+
+	return s.Conversation(ctx, conversationID)
+}
+
+// Conversations of the authenticated user with the most recent activity first,
+// and backward pagination.
+func (s *Service) Conversations(ctx context.Context, last uint64, before *string) (Conversations, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	var beforeConversationID string
+	var beforeLastMessageAt time.Time
+
+	if before != nil {
+		var err error
+		beforeConversationID, beforeLastMessageAt, err = decodeCursor(*before)
+		if err != nil || !reUUID.MatchString(beforeConversationID) {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return s.conversations(ctx, uid, "", normalizePageSize(last), beforeConversationID, beforeLastMessageAt)
+}
+
+// Conversation of the authenticated user.
+func (s *Service) Conversation(ctx context.Context, conversationID string) (Conversation, error) {
+	var out Conversation
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(conversationID) {
+		return out, ErrInvalidConversationID
+	}
+
+	cc, err := s.conversations(ctx, uid, conversationID, 1, "", time.Time{})
+	if err != nil {
+		return out, err
+	}
+
+	if len(cc) == 0 {
+		return out, ErrConversationNotFound
+	}
+
+	return cc[0], nil
+}
+
+func (s *Service) conversations(ctx context.Context, uid, conversationID string, last uint64, beforeConversationID string, beforeLastMessageAt time.Time) (Conversations, error) {
+	query, args, err := buildQuery(`
+		SELECT conversations.id
+		, conversations.direct_key IS NULL
+		, conversation_participants.unread_count
+		, conversation_participants.last_message_at
+		, conversations.created_at
+		, messages.id
+		, messages.user_id
+		, messages.content
+		, messages.media
+		, messages.created_at
+		, users.username
+		, users.avatar
+		, users.avatar_blurhash
+		FROM conversation_participants
+		INNER JOIN conversations ON conversations.id = conversation_participants.conversation_id
+		LEFT JOIN messages ON messages.id = conversations.last_message_id
+		LEFT JOIN users ON users.id = messages.user_id
+		WHERE conversation_participants.user_id = @uid
+		{{ if .conversationID }}
+			AND conversation_participants.conversation_id = @conversationID
+		{{ end }}
+		{{ if and .beforeConversationID .beforeLastMessageAt }}
+			AND conversation_participants.last_message_at <= @beforeLastMessageAt
+			AND (
+				conversation_participants.conversation_id < @beforeConversationID
+					OR conversation_participants.last_message_at < @beforeLastMessageAt
+			)
+		{{ end }}
+		ORDER BY conversation_participants.last_message_at DESC, conversation_participants.conversation_id DESC
+		LIMIT @last`, map[string]interface{}{
+		"uid":                  uid,
+		"conversationID":       conversationID,
+		"last":                 last,
+		"beforeConversationID": beforeConversationID,
+		"beforeLastMessageAt":  beforeLastMessageAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build conversations sql query: %w", err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select conversations: %w", err)
+	}
+
+	defer rows.Close()
+
+	var cc Conversations
+	var ids []string
+	for rows.Next() {
+		var c Conversation
+		var messageID, messageUserID, messageContent, messageUsername sql.NullString
+		var messageAvatar, messageAvatarBlurHash sql.NullString
+		var messageCreatedAt sql.NullTime
+		var messageMedia []string
+		err := rows.Scan(
+			&c.ID,
+			&c.Group,
+			&c.UnreadCount,
+			&c.LastMessageAt,
+			&c.CreatedAt,
+			&messageID,
+			&messageUserID,
+			&messageContent,
+			pq.Array(&messageMedia),
+			&messageCreatedAt,
+			&messageUsername,
+			&messageAvatar,
+			&messageAvatarBlurHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not sql scan conversation: %w", err)
+		}
+
+		if messageID.Valid {
+			m := Message{
+				ID:             messageID.String,
+				ConversationID: c.ID,
+				UserID:         messageUserID.String,
+				Content:        messageContent.String,
+				MediaURLs:      s.mediaURLs(messageMedia),
+				Media:          s.mediaImages(messageMedia),
+				CreatedAt:      messageCreatedAt.Time,
+				Mine:           messageUserID.String == uid,
+			}
+			if messageUsername.Valid {
+				m.User = &User{
+					Username:  messageUsername.String,
+					AvatarURL: s.avatarURL(messageAvatar),
+					Avatar:    s.avatarImage(messageAvatar, messageAvatarBlurHash),
+				}
+			}
+			c.LastMessage = &m
+		}
+
+		cc = append(cc, c)
+		ids = append(ids, c.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate over conversation rows: %w", err)
+	}
+
+	if len(cc) == 0 {
+		return cc, nil
+	}
+
+	participants, err := s.conversationParticipants(ctx, uid, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cc {
+		cc[i].Participants = participants[cc[i].ID]
+		if cc[i].Participants == nil {
+			// everybody else deleted their account.
+			cc[i].Participants = []ConversationParticipant{}
+		}
+	}
+
+	return cc, nil
+}
+
+// conversationParticipants other than the given user, by conversation ID.
+func (s *Service) conversationParticipants(ctx context.Context, uid string, conversationIDs []string) (map[string][]ConversationParticipant, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT conversation_participants.conversation_id
+		, users.username
+		, users.avatar
+		, users.avatar_blurhash
+		, conversation_participants.last_read_at
+		FROM conversation_participants
+		INNER JOIN users ON users.id = conversation_participants.user_id
+		WHERE conversation_participants.conversation_id = ANY($1)
+			AND conversation_participants.user_id != $2
+		ORDER BY users.username`, pq.Array(conversationIDs), uid)
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select conversation participants: %w", err)
+	}
+
+	defer rows.Close()
+
+	out := map[string][]ConversationParticipant{}
+	for rows.Next() {
+		var conversationID string
+		var p ConversationParticipant
+		var avatar, avatarBlurHash sql.NullString
+		if err := rows.Scan(&conversationID, &p.Username, &avatar, &avatarBlurHash, &p.LastReadAt); err != nil {
+			return nil, fmt.Errorf("could not sql scan conversation participant: %w", err)
+		}
+
+		p.AvatarURL = s.avatarURL(avatar)
+		p.Avatar = s.avatarImage(avatar, avatarBlurHash)
+		out[conversationID] = append(out[conversationID], p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate over conversation participant rows: %w", err)
+	}
+
+	return out, nil
+}
+
+// DMSettings of the authenticated user.
+func (s *Service) DMSettings(ctx context.Context) (DMSettings, error) {
+	var out DMSettings
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	query := "SELECT dms_from_followees_only FROM users WHERE id = $1"
+	err := s.DB.QueryRowContext(ctx, query, uid).Scan(&out.FromFolloweesOnly)
+	if err == sql.ErrNoRows {
+		return out, ErrUserNotFound
+	}
+
+	if err != nil {
+		return out, fmt.Errorf("could not sql query select dm settings: %w", err)
+	}
+
+	return out, nil
+}
+
+// UpdateDMSettings of the authenticated user.
+func (s *Service) UpdateDMSettings(ctx context.Context, in DMSettings) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	query := "UPDATE users SET dms_from_followees_only = $1 WHERE id = $2"
+	if _, err := s.DB.ExecContext(ctx, query, in.FromFolloweesOnly, uid); err != nil {
+		return fmt.Errorf("could not sql update dm settings: %w", err)
+	}
+
+	return nil
+}
+
+// directConversationKey identifies the direct conversation between two users
+// regardless of who started it.
+func directConversationKey(userID, otherUserID string) string {
+	ids := []string{userID, otherUserID}
+	sort.Strings(ids)
+	return strings.Join(ids, ":")
+}
+
+// normalizeUsernames trims and deduplicates the given usernames
+// and reports false if any of them is invalid.
+func normalizeUsernames(usernames []string) ([]string, bool) {
+	seen := map[string]struct{}{}
+	var out []string
+	for _, username := range usernames {
+		username = strings.TrimSpace(username)
+		if !ValidUsername(username) {
+			return nil, false
+		}
+
+		if _, ok := seen[username]; ok {
+			continue
+		}
+
+		seen[username] = struct{}{}
+		out = append(out, username)
+	}
+	return out, true
+}
+
+func conversationTopic(conversationID string) string { return "conversation_" + conversationID }
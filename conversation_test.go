@@ -0,0 +1,103 @@
+package nakama
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama/pubsub/memory"
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_directConversationKey(t *testing.T) {
+	a := "00000001-0000-0000-0000-000000000000"
+	b := "00000002-0000-0000-0000-000000000000"
+	testutil.WantEq(t, a+":"+b, directConversationKey(a, b), "key")
+	testutil.WantEq(t, directConversationKey(a, b), directConversationKey(b, a), "symmetric key")
+}
+
+func Test_normalizeUsernames(t *testing.T) {
+	got, ok := normalizeUsernames([]string{" shinji ", "rei", "shinji"})
+	testutil.WantEq(t, true, ok, "ok")
+	testutil.WantEq(t, []string{"shinji", "rei"}, got, "usernames")
+
+	_, ok = normalizeUsernames([]string{"shinji", "not valid"})
+	testutil.WantEq(t, false, ok, "invalid ok")
+}
+
+func TestService_CreateConversation(t *testing.T) {
+	svc := &Service{Logger: log.NewNopLogger(), PubSub: &memory.PubSub{}}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		_, err := svc.CreateConversation(context.Background(), []string{"rei"})
+		testutil.WantEq(t, ErrUnauthenticated, err, "error")
+	})
+
+	ctx := context.WithValue(context.Background(), KeyAuthUserID, "00000001-0000-0000-0000-000000000000")
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := svc.CreateConversation(ctx, nil)
+		testutil.WantEq(t, ErrInvalidConversationParticipants, err, "error")
+	})
+
+	t.Run("too_many", func(t *testing.T) {
+		_, err := svc.CreateConversation(ctx, []string{"a", "b", "c", "d", "e", "f", "g", "h"})
+		testutil.WantEq(t, ErrInvalidConversationParticipants, err, "error")
+	})
+
+	t.Run("invalid_username", func(t *testing.T) {
+		_, err := svc.CreateConversation(ctx, []string{"not valid"})
+		testutil.WantEq(t, ErrInvalidUsername, err, "error")
+	})
+}
+
+func TestService_SendMessage(t *testing.T) {
+	svc := &Service{Logger: log.NewNopLogger(), PubSub: &memory.PubSub{}}
+	ctx := context.WithValue(context.Background(), KeyAuthUserID, "00000001-0000-0000-0000-000000000000")
+
+	_, err := svc.SendMessage(ctx, "nope", "hi", nil)
+	testutil.WantEq(t, ErrInvalidConversationID, err, "invalid conversation id")
+
+	_, err = svc.SendMessage(ctx, "00000002-0000-0000-0000-000000000000", "  ", nil)
+	testutil.WantEq(t, ErrInvalidContent, err, "empty content")
+}
+
+func TestService_canSendMessage(t *testing.T) {
+	if testDB == nil {
+		t.Skip("integration test")
+	}
+
+	ctx := context.Background()
+	svc := &Service{Logger: log.NewNopLogger(), DB: testDB}
+
+	insertUser := func() string {
+		t.Helper()
+
+		var id string
+		err := testDB.QueryRowContext(ctx, "INSERT INTO users (email, username) VALUES ($1, $2) RETURNING id",
+			testutil.RandStr(t, 10)+"@example.org", testutil.RandStr(t, 10)).Scan(&id)
+		testutil.WantEq(t, nil, err, "insert user")
+		return id
+	}
+
+	alice, bob, carol := insertUser(), insertUser(), insertUser()
+
+	var conversationID string
+	err := testDB.QueryRowContext(ctx, "INSERT INTO conversations DEFAULT VALUES RETURNING id").Scan(&conversationID)
+	testutil.WantEq(t, nil, err, "insert group conversation")
+
+	for _, uid := range []string{alice, bob, carol} {
+		_, err := testDB.ExecContext(ctx, "INSERT INTO conversation_participants (conversation_id, user_id) VALUES ($1, $2)", conversationID, uid)
+		testutil.WantEq(t, nil, err, "insert participant")
+	}
+
+	testutil.WantEq(t, nil, svc.canSendMessage(ctx, bob, conversationID), "before block")
+
+	_, err = testDB.ExecContext(ctx, "INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2)", alice, bob)
+	testutil.WantEq(t, nil, err, "insert block")
+
+	testutil.WantEq(t, ErrDirectMessagesNotAllowed, svc.canSendMessage(ctx, bob, conversationID), "blocked by participant")
+	testutil.WantEq(t, ErrDirectMessagesNotAllowed, svc.canSendMessage(ctx, alice, conversationID), "blocking participant")
+	testutil.WantEq(t, ErrConversationNotFound, svc.canSendMessage(ctx, insertUser(), conversationID), "not participant")
+}
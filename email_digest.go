@@ -0,0 +1,485 @@
+package nakama
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nakamauwu/nakama/web"
+)
+
+const (
+	// emailDigestHour is the local hour of the day at which digests are sent.
+	emailDigestHour          = 8
+	emailDigestCheckInterval = time.Hour
+	emailDigestBatchSize     = 100
+	emailDigestMaxItems      = 5
+
+	emailDigestDaily  = "daily"
+	emailDigestWeekly = "weekly"
+)
+
+var (
+	// ErrInvalidEmailDigestFrequency denotes an invalid email digest frequency; that is not "daily" nor "weekly".
+	ErrInvalidEmailDigestFrequency = InvalidArgumentError("invalid email digest frequency")
+	// ErrInvalidTimezone denotes an invalid IANA timezone name.
+	ErrInvalidTimezone = InvalidArgumentError("invalid timezone")
+	// ErrInvalidUnsubscribeToken denotes an invalid or tampered unsubscribe token.
+	ErrInvalidUnsubscribeToken = InvalidArgumentError("invalid unsubscribe token")
+)
+
+// EmailDigestSettings of a user.
+// A nil frequency means the user opted out of email digests.
+type EmailDigestSettings struct {
+	Frequency *string `json:"frequency"`
+	Timezone  string  `json:"timezone"`
+}
+
+// EmailDigestSettings of the authenticated user.
+func (s *Service) EmailDigestSettings(ctx context.Context) (EmailDigestSettings, error) {
+	var out EmailDigestSettings
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	query := "SELECT email_digest, timezone FROM users WHERE id = $1"
+	err := s.DB.QueryRowContext(ctx, query, uid).Scan(&out.Frequency, &out.Timezone)
+	if err == sql.ErrNoRows {
+		return out, ErrUserNotFound
+	}
+
+	if err != nil {
+		return out, fmt.Errorf("could not sql query select email digest settings: %w", err)
+	}
+
+	return out, nil
+}
+
+// UpdateEmailDigestSettings of the authenticated user.
+// Set frequency to nil to opt-out.
+func (s *Service) UpdateEmailDigestSettings(ctx context.Context, in EmailDigestSettings) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if in.Frequency != nil {
+		*in.Frequency = strings.TrimSpace(strings.ToLower(*in.Frequency))
+		if *in.Frequency != emailDigestDaily && *in.Frequency != emailDigestWeekly {
+			return ErrInvalidEmailDigestFrequency
+		}
+	}
+
+	in.Timezone = strings.TrimSpace(in.Timezone)
+	if in.Timezone == "" {
+		in.Timezone = "UTC"
+	}
+
+	if _, err := time.LoadLocation(in.Timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+
+	query := "UPDATE users SET email_digest = $1, timezone = $2 WHERE id = $3"
+	_, err := s.DB.ExecContext(ctx, query, in.Frequency, in.Timezone, uid)
+	if err != nil {
+		return fmt.Errorf("could not sql update email digest settings: %w", err)
+	}
+
+	return nil
+}
+
+// UnsubscribeEmailDigest opts-out the given user from email digests.
+// The token comes from the unsubscribe link sent within each digest
+// so no authenticated user is required.
+func (s *Service) UnsubscribeEmailDigest(ctx context.Context, userID, token string) error {
+	if !reUUID.MatchString(userID) {
+		return ErrInvalidUserID
+	}
+
+	if !hmac.Equal([]byte(token), []byte(s.emailDigestUnsubscribeToken(userID))) {
+		return ErrInvalidUnsubscribeToken
+	}
+
+	query := "UPDATE users SET email_digest = NULL WHERE id = $1"
+	_, err := s.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("could not sql update email digest unsubscription: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) emailDigestUnsubscribeToken(userID string) string {
+	h := hmac.New(sha256.New, []byte(s.TokenKey))
+	h.Write([]byte("email_digest_unsubscribe:" + userID))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (s *Service) emailDigestJob(ctx context.Context) {
+	ticker := time.NewTicker(emailDigestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.sendEmailDigests(ctx, now); err != nil {
+				_ = s.Logger.Log("error", fmt.Errorf("could not send email digests: %w", err))
+			}
+		}
+	}
+}
+
+// sendEmailDigests to every user whose local time, given its timezone,
+// matches the digest hour.
+func (s *Service) sendEmailDigests(ctx context.Context, now time.Time) error {
+	rows, err := s.DB.QueryContext(ctx, "SELECT DISTINCT timezone FROM users WHERE email_digest IS NOT NULL")
+	if err != nil {
+		return fmt.Errorf("could not sql query select email digest timezones: %w", err)
+	}
+
+	defer rows.Close()
+
+	var timezones []string
+	for rows.Next() {
+		var tz string
+		if err := rows.Scan(&tz); err != nil {
+			return fmt.Errorf("could not sql scan email digest timezone: %w", err)
+		}
+
+		timezones = append(timezones, tz)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not sql iterate over email digest timezones: %w", err)
+	}
+
+	for _, tz := range timezones {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not load email digest timezone %q: %w", tz, err))
+			continue
+		}
+
+		local := now.In(loc)
+		if local.Hour() != emailDigestHour {
+			continue
+		}
+
+		weekly := local.Weekday() == time.Monday
+		if err := s.sendEmailDigestBatches(ctx, tz, weekly, now); err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not send email digests for timezone %q: %w", tz, err))
+			continue
+		}
+	}
+
+	return nil
+}
+
+type emailDigestRecipient struct {
+	ID        string
+	Email     string
+	Username  string
+	Frequency string
+	SentAt    *time.Time
+}
+
+func (s *Service) sendEmailDigestBatches(ctx context.Context, timezone string, weekly bool, now time.Time) error {
+	var afterID string
+	for {
+		query, args, err := buildQuery(`
+			SELECT id, email, username, email_digest, email_digest_sent_at
+			FROM users
+			WHERE timezone = @timezone
+				AND (
+					(email_digest = 'daily' AND (email_digest_sent_at IS NULL OR email_digest_sent_at < @dailyThreshold))
+					{{ if .weekly }}
+					OR (email_digest = 'weekly' AND (email_digest_sent_at IS NULL OR email_digest_sent_at < @weeklyThreshold))
+					{{ end }}
+				)
+				{{ if .afterID }}AND id > @afterID{{ end }}
+			ORDER BY id ASC
+			LIMIT @batchSize`, map[string]interface{}{
+			"timezone":        timezone,
+			"weekly":          weekly,
+			"dailyThreshold":  now.Add(-time.Hour * 20),
+			"weeklyThreshold": now.Add(-time.Hour * 24 * 6),
+			"afterID":         afterID,
+			"batchSize":       emailDigestBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("could not build email digest recipients sql query: %w", err)
+		}
+
+		recipients, err := s.emailDigestRecipients(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		for _, rcpt := range recipients {
+			if err := s.sendEmailDigest(ctx, rcpt, now); err != nil {
+				_ = s.Logger.Log("error", fmt.Errorf("could not send email digest to user %q: %w", rcpt.ID, err))
+			}
+		}
+
+		if len(recipients) < emailDigestBatchSize {
+			return nil
+		}
+
+		afterID = recipients[len(recipients)-1].ID
+	}
+}
+
+func (s *Service) emailDigestRecipients(ctx context.Context, query string, args ...interface{}) ([]emailDigestRecipient, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select email digest recipients: %w", err)
+	}
+
+	defer rows.Close()
+
+	var out []emailDigestRecipient
+	for rows.Next() {
+		var rcpt emailDigestRecipient
+		if err := rows.Scan(&rcpt.ID, &rcpt.Email, &rcpt.Username, &rcpt.Frequency, &rcpt.SentAt); err != nil {
+			return nil, fmt.Errorf("could not sql scan email digest recipient: %w", err)
+		}
+
+		out = append(out, rcpt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not sql iterate over email digest recipients: %w", err)
+	}
+
+	return out, nil
+}
+
+type emailDigestPost struct {
+	ID            string
+	Content       string
+	CommentsCount int
+	Username      string
+}
+
+func (s *Service) sendEmailDigest(ctx context.Context, rcpt emailDigestRecipient, now time.Time) error {
+	since := now.Add(-time.Hour * 24)
+	if rcpt.Frequency == emailDigestWeekly {
+		since = now.Add(-time.Hour * 24 * 7)
+	}
+	if rcpt.SentAt != nil && rcpt.SentAt.After(since) {
+		since = *rcpt.SentAt
+	}
+
+	var unreadCount int
+	query := "SELECT count(*) FROM notifications WHERE user_id = $1 AND (read_at IS NULL OR read_at = '0001-01-01 00:00:00')"
+	err := s.DB.QueryRowContext(ctx, query, rcpt.ID).Scan(&unreadCount)
+	if err != nil {
+		return fmt.Errorf("could not sql query select unread notifications count: %w", err)
+	}
+
+	nn, err := s.emailDigestNotifications(ctx, rcpt.ID)
+	if err != nil {
+		return err
+	}
+
+	pp, err := s.emailDigestPosts(ctx, rcpt.ID, since)
+	if err != nil {
+		return err
+	}
+
+	if len(nn) != 0 || len(pp) != 0 {
+		if err := s.mailEmailDigest(rcpt, unreadCount, nn, pp); err != nil {
+			return err
+		}
+	}
+
+	query = "UPDATE users SET email_digest_sent_at = $1 WHERE id = $2"
+	if _, err := s.DB.ExecContext(ctx, query, now, rcpt.ID); err != nil {
+		return fmt.Errorf("could not sql update email digest sent at: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) emailDigestNotifications(ctx context.Context, userID string) ([]Notification, error) {
+	query := `
+		SELECT id, actors, type, post_id, issued_at
+		FROM notifications
+		WHERE user_id = $1 AND (read_at IS NULL OR read_at = '0001-01-01 00:00:00')
+		ORDER BY issued_at DESC
+		LIMIT $2`
+	rows, err := s.DB.QueryContext(ctx, query, userID, emailDigestMaxItems)
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select email digest notifications: %w", err)
+	}
+
+	defer rows.Close()
+
+	var nn []Notification
+	for rows.Next() {
+		var n Notification
+		if err := rows.Scan(&n.ID, pq.Array(&n.Actors), &n.Type, &n.PostID, &n.IssuedAt); err != nil {
+			return nil, fmt.Errorf("could not sql scan email digest notification: %w", err)
+		}
+
+		n.UserID = userID
+		nn = append(nn, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not sql iterate over email digest notifications: %w", err)
+	}
+
+	return nn, nil
+}
+
+// emailDigestPosts are the most commented posts from the user followees since the given time.
+func (s *Service) emailDigestPosts(ctx context.Context, userID string, since time.Time) ([]emailDigestPost, error) {
+	query := `
+		SELECT posts.id, posts.content, posts.comments_count, users.username
+		FROM posts
+		INNER JOIN follows ON follows.followee_id = posts.user_id AND follows.follower_id = $1
+		INNER JOIN users ON posts.user_id = users.id
+		WHERE posts.created_at >= $2
+		ORDER BY posts.comments_count DESC, posts.created_at DESC
+		LIMIT $3`
+	rows, err := s.DB.QueryContext(ctx, query, userID, since, emailDigestMaxItems)
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select email digest posts: %w", err)
+	}
+
+	defer rows.Close()
+
+	var pp []emailDigestPost
+	for rows.Next() {
+		var p emailDigestPost
+		if err := rows.Scan(&p.ID, &p.Content, &p.CommentsCount, &p.Username); err != nil {
+			return nil, fmt.Errorf("could not sql scan email digest post: %w", err)
+		}
+
+		pp = append(pp, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not sql iterate over email digest posts: %w", err)
+	}
+
+	return pp, nil
+}
+
+func (s *Service) mailEmailDigest(rcpt emailDigestRecipient, unreadCount int, nn []Notification, pp []emailDigestPost) error {
+	var err error
+	s.emailDigestTmplOncer.Do(func() {
+		var text []byte
+		text, err = web.TemplateFiles.ReadFile("template/mail/digest.html.tmpl")
+		if err != nil {
+			err = fmt.Errorf("could not read email digest template file: %w", err)
+			return
+		}
+
+		s.emailDigestTmpl, err = template.
+			New("mail/digest.html").
+			Funcs(template.FuncMap{
+				"notification_text": notificationText,
+				"post_url":          s.postURL,
+				"excerpt": func(content string) string {
+					return excerpt(content, 140)
+				},
+			}).
+			Parse(string(text))
+		if err != nil {
+			err = fmt.Errorf("could not parse email digest mail template: %w", err)
+			return
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	unsubscribeLink := cloneURL(s.Origin)
+	unsubscribeLink.Path = "/api/email_digest/unsubscribe"
+	q := unsubscribeLink.Query()
+	q.Set("user_id", rcpt.ID)
+	q.Set("token", s.emailDigestUnsubscribeToken(rcpt.ID))
+	unsubscribeLink.RawQuery = q.Encode()
+
+	var b bytes.Buffer
+	err = s.emailDigestTmpl.Execute(&b, map[string]interface{}{
+		"Origin":          s.Origin,
+		"Username":        rcpt.Username,
+		"Weekly":          rcpt.Frequency == emailDigestWeekly,
+		"UnreadCount":     unreadCount,
+		"Notifications":   nn,
+		"Posts":           pp,
+		"UnsubscribeLink": unsubscribeLink,
+	})
+	if err != nil {
+		return fmt.Errorf("could not execute email digest mail template: %w", err)
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "You have %d unread notifications.\n\n", unreadCount)
+	for _, n := range nn {
+		fmt.Fprintf(&text, "- %s\n", notificationText(n))
+	}
+	if len(pp) != 0 {
+		text.WriteString("\nTop posts from people you follow:\n\n")
+		for _, p := range pp {
+			fmt.Fprintf(&text, "- @%s: %s\n  %s\n", p.Username, excerpt(p.Content, 140), s.postURL(p.ID))
+		}
+	}
+	fmt.Fprintf(&text, "\nUnsubscribe: %s\n", unsubscribeLink)
+
+	subject := "Your daily digest at Nakama"
+	if rcpt.Frequency == emailDigestWeekly {
+		subject = "Your weekly digest at Nakama"
+	}
+
+	err = s.Sender.Send(rcpt.Email, subject, b.String(), text.String())
+	if err != nil {
+		return fmt.Errorf("could not send email digest: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) postURL(postID string) string {
+	u := cloneURL(s.Origin)
+	u.Path = "/posts/" + postID
+	return u.String()
+}
+
+func notificationText(n Notification) string {
+	actors := strings.Join(n.Actors, ", ")
+	switch n.Type {
+	case "follow":
+		return actors + " followed you"
+	case "comment":
+		return actors + " commented on a post"
+	case "post_mention":
+		return actors + " mentioned you on a post"
+	case "comment_mention":
+		return actors + " mentioned you on a comment"
+	}
+	return actors + " interacted with you"
+}
+
+func excerpt(s string, max int) string {
+	rr := []rune(s)
+	if len(rr) <= max {
+		return s
+	}
+	return string(rr[:max]) + "…"
+}
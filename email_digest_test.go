@@ -0,0 +1,37 @@
+package nakama
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestService_UnsubscribeEmailDigest(t *testing.T) {
+	ctx := context.Background()
+	userID := "24ca6ce6-b3e9-4276-a99a-45c77115cc9f"
+
+	t.Run("invalid_user_id", func(t *testing.T) {
+		svc := &Service{TokenKey: "secret"}
+		err := svc.UnsubscribeEmailDigest(ctx, "nope", svc.emailDigestUnsubscribeToken("nope"))
+		testutil.WantEq(t, ErrInvalidUserID, err, "error")
+	})
+
+	t.Run("invalid_token", func(t *testing.T) {
+		svc := &Service{TokenKey: "secret"}
+		err := svc.UnsubscribeEmailDigest(ctx, userID, "nope")
+		testutil.WantEq(t, ErrInvalidUnsubscribeToken, err, "error")
+	})
+
+	t.Run("token_from_another_key", func(t *testing.T) {
+		svc := &Service{TokenKey: "secret"}
+		other := &Service{TokenKey: "other"}
+		err := svc.UnsubscribeEmailDigest(ctx, userID, other.emailDigestUnsubscribeToken(userID))
+		testutil.WantEq(t, ErrInvalidUnsubscribeToken, err, "error")
+	})
+}
+
+func Test_excerpt(t *testing.T) {
+	testutil.WantEq(t, "short", excerpt("short", 10), "short excerpt")
+	testutil.WantEq(t, "世界…", excerpt("世界世界", 2), "long excerpt")
+}
@@ -98,3 +98,31 @@ func (e GoneError) Error() string {
 func (e GoneError) Unwrap() error {
 	return ErrGone
 }
+
+// -----------------------------------------------------------------------------
+
+var ErrResourceExhausted = errors.New("resource exhausted")
+
+type ResourceExhaustedError string
+
+func (e ResourceExhaustedError) Error() string {
+	return string(e)
+}
+
+func (e ResourceExhaustedError) Unwrap() error {
+	return ErrResourceExhausted
+}
+
+// -----------------------------------------------------------------------------
+
+var ErrUnavailable = errors.New("unavailable")
+
+type UnavailableError string
+
+func (e UnavailableError) Error() string {
+	return string(e)
+}
+
+func (e UnavailableError) Unwrap() error {
+	return ErrUnavailable
+}
@@ -9,7 +9,9 @@ require (
 	github.com/disintegration/imaging v1.6.2
 	github.com/go-kit/log v0.2.1
 	github.com/go-mail/mail v2.3.1+incompatible
+	github.com/google/uuid v1.6.0
 	github.com/gorilla/securecookie v1.1.2
+	github.com/gorilla/websocket v1.5.3
 	github.com/hako/branca v0.0.0-20200807062402-6052ac720505
 	github.com/hako/durafmt v0.0.0-20210608085754-5c1018a4e16b
 	github.com/hybridtheory/samesite-cookie-support v0.4.0
@@ -24,6 +26,7 @@ require (
 	github.com/ory/dockertest/v3 v3.11.0
 	github.com/prometheus/client_golang v1.20.4
 	github.com/sendgrid/sendgrid-go v3.16.0+incompatible
+	golang.org/x/image v0.20.0
 	golang.org/x/oauth2 v0.23.0
 	golang.org/x/sync v0.8.0
 )
@@ -51,7 +54,6 @@ require (
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/golang-jwt/jwt v3.2.2+incompatible // indirect
 	github.com/google/shlex v0.0.0-20191202100458-e7afc7fbc510 // indirect
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/klauspost/compress v1.17.10 // indirect
 	github.com/klauspost/cpuid/v2 v2.2.8 // indirect
 	github.com/kr/text v0.2.0 // indirect
@@ -78,7 +80,6 @@ require (
 	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
 	github.com/xeipuuv/gojsonschema v1.2.0 // indirect
 	golang.org/x/crypto v0.27.0 // indirect
-	golang.org/x/image v0.20.0 // indirect
 	golang.org/x/mod v0.20.0 // indirect
 	golang.org/x/net v0.29.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
@@ -0,0 +1,317 @@
+package nakama
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // register the WebP decoder.
+)
+
+const (
+	// MaxImageDimension is the max width or height of an image.
+	MaxImageDimension = 8192
+	// MaxAnimationFrames is the max number of frames of an animated GIF.
+	MaxAnimationFrames = 300
+	// MaxAnimationPixels is the max width*height*frames of an animated GIF.
+	MaxAnimationPixels = 100_000_000
+)
+
+var (
+	// ErrImageTooLarge denotes an image exceeding the max dimensions.
+	ErrImageTooLarge = InvalidArgumentError("image dimensions too large")
+	// ErrTooManyAnimationFrames denotes an animated GIF exceeding the max frames.
+	ErrTooManyAnimationFrames = InvalidArgumentError("too many animation frames")
+)
+
+// decodedImage is an image ready to be re-encoded as ContentType.
+// Anim is set for animated GIFs only, with Image being its first frame.
+type decodedImage struct {
+	Image       image.Image
+	Anim        *gif.GIF
+	ContentType string
+}
+
+// supportedImageType reports whether images of the given content type can be decoded.
+// AVIF is not supported since there is no decoder for it without cgo.
+func supportedImageType(ct string) bool {
+	switch ct {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// decodeImage decodes at most maxBytes from r checking the dimensions beforehand.
+// With animated, GIFs with multiple frames keep them.
+// WebP and still GIFs get transcoded to PNG, or JPEG when opaque.
+// Use supportedImageType to check ct first.
+func decodeImage(r io.ReadSeeker, ct string, maxBytes int64, animated bool) (decodedImage, error) {
+	var out decodedImage
+	cfg, _, err := image.DecodeConfig(io.LimitReader(r, maxBytes))
+	if err == image.ErrFormat {
+		return out, ErrUnsupportedMediaItemFormat
+	}
+
+	if err != nil {
+		return out, fmt.Errorf("could not decode image config: %w", err)
+	}
+
+	if cfg.Width > MaxImageDimension || cfg.Height > MaxImageDimension {
+		return out, ErrImageTooLarge
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return out, fmt.Errorf("could not seek image to start: %w", err)
+	}
+
+	if ct == "image/gif" && animated {
+		frames, err := countGIFFrames(io.LimitReader(r, maxBytes))
+		if err != nil {
+			return out, err
+		}
+
+		if frames > MaxAnimationFrames {
+			return out, ErrTooManyAnimationFrames
+		}
+
+		if frames*cfg.Width*cfg.Height > MaxAnimationPixels {
+			return out, ErrImageTooLarge
+		}
+
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return out, fmt.Errorf("could not seek image to start: %w", err)
+		}
+
+		if frames > 1 {
+			g, err := gif.DecodeAll(io.LimitReader(r, maxBytes))
+			if err != nil {
+				return out, fmt.Errorf("could not decode animated gif: %w", err)
+			}
+
+			out.Anim = g
+			out.Image = gifFirstFrame(g)
+			out.ContentType = "image/gif"
+			return out, nil
+		}
+	}
+
+	img, err := imaging.Decode(io.LimitReader(r, maxBytes), imaging.AutoOrientation(true))
+	if err == image.ErrFormat {
+		return out, ErrUnsupportedMediaItemFormat
+	}
+
+	if err != nil {
+		return out, fmt.Errorf("could not decode image: %w", err)
+	}
+
+	out.Image = img
+	out.ContentType = ct
+	if ct == "image/gif" || ct == "image/webp" {
+		out.ContentType = "image/png"
+		if imageOpaque(img) {
+			out.ContentType = "image/jpeg"
+		}
+	}
+
+	return out, nil
+}
+
+// encodeImage encodes img as ct, or anim when animated.
+func encodeImage(w io.Writer, img image.Image, anim *gif.GIF, ct string) error {
+	switch {
+	case anim != nil:
+		return gif.EncodeAll(w, anim)
+	case ct == "image/png":
+		return png.Encode(w, img)
+	case ct == "image/gif":
+		return gif.Encode(w, img, nil)
+	default:
+		return jpeg.Encode(w, img, nil)
+	}
+}
+
+// imageExt returns the file extension of the given content type.
+func imageExt(ct string) string {
+	switch ct {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ".jpg"
+	}
+}
+
+func imageOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// gifFirstFrame composes the first frame over the GIF canvas.
+func gifFirstFrame(g *gif.GIF) image.Image {
+	canvas := image.NewNRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	frame := g.Image[0]
+	draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+	return canvas
+}
+
+// resizeGIF downscales every frame of g to the given width.
+// Frames are composed over the canvas honoring their disposal
+// so the output frames are full frames cleared after displayed.
+func resizeGIF(g *gif.GIF, width int) *gif.GIF {
+	height := max(1, g.Config.Height*width/g.Config.Width)
+	out := &gif.GIF{
+		Image:           make([]*image.Paletted, len(g.Image)),
+		Delay:           make([]int, len(g.Image)),
+		Disposal:        make([]byte, len(g.Image)),
+		LoopCount:       g.LoopCount,
+		BackgroundIndex: g.BackgroundIndex,
+	}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	for i, frame := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		var previous *image.NRGBA
+		if disposal == gif.DisposalPrevious {
+			previous = imaging.Clone(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		resized := imaging.Resize(canvas, width, height, imaging.Linear)
+		paletted := image.NewPaletted(resized.Bounds(), gifPalette(frame.Palette))
+		draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), resized, image.Point{})
+
+		out.Image[i] = paletted
+		out.Disposal[i] = gif.DisposalBackground
+		if i < len(g.Delay) {
+			out.Delay[i] = g.Delay[i]
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return out
+}
+
+// gifPalette makes sure p has a transparent color
+// so the transparent areas of the canvas survive the quantization.
+func gifPalette(p color.Palette) color.Palette {
+	for _, c := range p {
+		if _, _, _, a := c.RGBA(); a == 0 {
+			return p
+		}
+	}
+
+	if len(p) < 256 {
+		return append(append(color.Palette{}, p...), color.Transparent)
+	}
+
+	out := append(color.Palette{}, p...)
+	out[len(out)-1] = color.Transparent
+	return out
+}
+
+// countGIFFrames counts the image descriptors of a GIF
+// without decoding them. It stops counting past MaxAnimationFrames.
+func countGIFFrames(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	errMalformed := errors.New("malformed gif")
+
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return 0, fmt.Errorf("could not read gif header: %w", err)
+	}
+
+	if !bytes.HasPrefix(header, []byte("GIF8")) {
+		return 0, errMalformed
+	}
+
+	// global color table.
+	if header[10]&0x80 != 0 {
+		if _, err := br.Discard(3 << (header[10]&0x07 + 1)); err != nil {
+			return 0, fmt.Errorf("could not skip gif color table: %w", err)
+		}
+	}
+
+	var frames int
+	for frames <= MaxAnimationFrames {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("could not read gif block: %w", err)
+		}
+
+		switch b {
+		case 0x3b: // trailer.
+			return frames, nil
+		case 0x21: // extension.
+			if _, err := br.ReadByte(); err != nil {
+				return 0, fmt.Errorf("could not read gif extension: %w", err)
+			}
+		case 0x2c: // image descriptor.
+			desc := make([]byte, 9)
+			if _, err := io.ReadFull(br, desc); err != nil {
+				return 0, fmt.Errorf("could not read gif image descriptor: %w", err)
+			}
+
+			if desc[8]&0x80 != 0 {
+				if _, err := br.Discard(3 << (desc[8]&0x07 + 1)); err != nil {
+					return 0, fmt.Errorf("could not skip gif color table: %w", err)
+				}
+			}
+
+			// LZW minimum code size.
+			if _, err := br.ReadByte(); err != nil {
+				return 0, fmt.Errorf("could not read gif image data: %w", err)
+			}
+
+			frames++
+		default:
+			return 0, errMalformed
+		}
+
+		if err := skipGIFSubBlocks(br); err != nil {
+			return 0, err
+		}
+	}
+
+	return frames, nil
+}
+
+func skipGIFSubBlocks(br *bufio.Reader) error {
+	for {
+		n, err := br.ReadByte()
+		if err != nil {
+			return fmt.Errorf("could not read gif sub-block: %w", err)
+		}
+
+		if n == 0 {
+			return nil
+		}
+
+		if _, err := br.Discard(int(n)); err != nil {
+			return fmt.Errorf("could not skip gif sub-block: %w", err)
+		}
+	}
+}
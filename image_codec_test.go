@@ -0,0 +1,82 @@
+package nakama
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"testing"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_countGIFFrames(t *testing.T) {
+	b := testGIF(t, 640, 320, 3)
+	got, err := countGIFFrames(bytes.NewReader(b))
+	testutil.WantEq(t, nil, err, "error")
+	testutil.WantEq(t, 3, got, "frames")
+
+	_, err = countGIFFrames(bytes.NewReader([]byte("nope nope nope")))
+	testutil.WantEq(t, true, err != nil, "malformed error")
+}
+
+func Test_decodeImage(t *testing.T) {
+	t.Run("animated", func(t *testing.T) {
+		b := testGIF(t, 640, 320, 3)
+		img, err := decodeImage(bytes.NewReader(b), "image/gif", int64(len(b)), true)
+		testutil.WantEq(t, nil, err, "error")
+		testutil.WantEq(t, "image/gif", img.ContentType, "content type")
+		testutil.WantEq(t, 3, len(img.Anim.Image), "frames")
+		testutil.WantEq(t, 640, img.Image.Bounds().Dx(), "width")
+
+		files, err := imageVariantFiles(img, "abc.gif", mediaVariants)
+		testutil.WantEq(t, nil, err, "variants error")
+
+		small, err := gif.DecodeAll(bytes.NewReader(files[1].Content))
+		testutil.WantEq(t, nil, err, "decode small error")
+		testutil.WantEq(t, 3, len(small.Image), "small frames")
+		testutil.WantEq(t, 480, small.Config.Width, "small width")
+		testutil.WantEq(t, 240, small.Config.Height, "small height")
+	})
+
+	t.Run("still", func(t *testing.T) {
+		b := testGIF(t, 64, 64, 3)
+		img, err := decodeImage(bytes.NewReader(b), "image/gif", int64(len(b)), false)
+		testutil.WantEq(t, nil, err, "error")
+		testutil.WantEq(t, true, img.Anim == nil, "still")
+		testutil.WantEq(t, "image/jpeg", img.ContentType, "transcoded content type")
+	})
+
+	t.Run("too_many_frames", func(t *testing.T) {
+		b := testGIF(t, 2, 2, MaxAnimationFrames+1)
+		_, err := decodeImage(bytes.NewReader(b), "image/gif", int64(len(b)), true)
+		testutil.WantEq(t, ErrTooManyAnimationFrames, err, "error")
+	})
+
+	t.Run("too_large", func(t *testing.T) {
+		b := testGIF(t, MaxImageDimension+1, 1, 1)
+		_, err := decodeImage(bytes.NewReader(b), "image/gif", int64(len(b)), true)
+		testutil.WantEq(t, ErrImageTooLarge, err, "error")
+	})
+}
+
+func testGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+		for x := 0; x < width; x++ {
+			frame.Set(x, (x+i)%height, color.White)
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+
+	buf := &bytes.Buffer{}
+	err := gif.EncodeAll(buf, g)
+	testutil.WantEq(t, nil, err, "encode gif")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,250 @@
+package nakama
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/nakamauwu/nakama/storage"
+)
+
+// ImageVariant names one of the sizes an image gets stored at.
+type ImageVariant string
+
+const (
+	ImageVariantThumb    ImageVariant = "thumb"
+	ImageVariantSmall    ImageVariant = "small"
+	ImageVariantMedium   ImageVariant = "medium"
+	ImageVariantOriginal ImageVariant = "original"
+)
+
+// imageVariantSpec is a variant downscaled to fit the given width.
+type imageVariantSpec struct {
+	Variant ImageVariant
+	Width   int
+}
+
+var (
+	avatarVariants = []imageVariantSpec{
+		{Variant: ImageVariantThumb, Width: 160},
+	}
+	coverVariants = []imageVariantSpec{
+		{Variant: ImageVariantSmall, Width: 480},
+		{Variant: ImageVariantMedium, Width: 1080},
+	}
+	mediaVariants = []imageVariantSpec{
+		{Variant: ImageVariantThumb, Width: 160},
+		{Variant: ImageVariantSmall, Width: 480},
+		{Variant: ImageVariantMedium, Width: 1080},
+	}
+)
+
+// Image with the URLs of its stored variants.
+// URL points to the original.
+// Width, Height and BlurHash are null for images stored before they were computed.
+type Image struct {
+	URL      string        `json:"url"`
+	Srcset   []ImageSource `json:"srcset"`
+	Width    *int          `json:"width"`
+	Height   *int          `json:"height"`
+	BlurHash *string       `json:"blurHash"`
+}
+
+// imageMeta are the nullable columns of a stored image.
+type imageMeta struct {
+	BlurHash sql.NullString
+	Width    sql.NullInt64
+	Height   sql.NullInt64
+}
+
+func (m imageMeta) apply(img *Image) {
+	if m.BlurHash.Valid {
+		img.BlurHash = &m.BlurHash.String
+	}
+
+	if m.Width.Valid && m.Height.Valid {
+		img.Width = ptrInt(int(m.Width.Int64))
+		img.Height = ptrInt(int(m.Height.Int64))
+	}
+}
+
+// ImageSource is a srcset candidate.
+// Width is the max width of the variant; zero for the original.
+type ImageSource struct {
+	Variant ImageVariant `json:"variant"`
+	URL     string       `json:"url"`
+	Width   int          `json:"width,omitempty"`
+}
+
+// ValidImageVariant reports whether v is a known variant.
+func ValidImageVariant(v ImageVariant) bool {
+	switch v {
+	case ImageVariantThumb, ImageVariantSmall, ImageVariantMedium, ImageVariantOriginal:
+		return true
+	}
+	return false
+}
+
+// ImageVariantName returns the object name the given variant
+// of the named image is stored at. That is "<base>_<variant><ext>".
+func ImageVariantName(name string, v ImageVariant) string {
+	if v == ImageVariantOriginal || v == "" {
+		return name
+	}
+
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "_" + string(v) + ext
+}
+
+// imageVariantBase returns the name of the original image
+// the given variant object belongs to.
+func imageVariantBase(name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	i := strings.LastIndexByte(base, '_')
+	if i == -1 {
+		return name
+	}
+
+	if v := ImageVariant(base[i+1:]); v == ImageVariantOriginal || !ValidImageVariant(v) {
+		return name
+	}
+
+	return base[:i] + ext
+}
+
+// imageVariantNames returns the names of the original and its variants.
+func imageVariantNames(name string, specs []imageVariantSpec) []string {
+	names := make([]string, 0, len(specs)+1)
+	names = append(names, name)
+	for _, spec := range specs {
+		names = append(names, ImageVariantName(name, spec.Variant))
+	}
+	return names
+}
+
+// imageVariantFiles encodes the given variants of img.
+// Images smaller than a variant are not upscaled.
+func imageVariantFiles(img decodedImage, name string, specs []imageVariantSpec) ([]mediaFile, error) {
+	files := make([]mediaFile, len(specs))
+	for i, spec := range specs {
+		variant, anim := img.Image, img.Anim
+		if img.Image.Bounds().Dx() > spec.Width {
+			if anim != nil {
+				anim = resizeGIF(anim, spec.Width)
+			} else {
+				variant = imaging.Resize(img.Image, spec.Width, 0, imaging.Lanczos)
+			}
+		}
+
+		buf := &bytes.Buffer{}
+		if err := encodeImage(buf, variant, anim, img.ContentType); err != nil {
+			return nil, fmt.Errorf("could not encode %s image variant: %w", spec.Variant, err)
+		}
+
+		files[i] = mediaFile{
+			Name:        ImageVariantName(name, spec.Variant),
+			ContentType: img.ContentType,
+			Content:     buf.Bytes(),
+		}
+	}
+	return files, nil
+}
+
+// storeImageFiles stores the given files along with their variants.
+func (s *Service) storeImageFiles(ctx context.Context, bucket string, files []mediaFile) error {
+	g, gctx := errgroup.WithContext(ctx)
+	for _, file := range files {
+		for _, file := range append([]mediaFile{file}, file.Variants...) {
+			file := file
+			g.Go(func() error {
+				err := s.Store.Put(gctx, bucket, file.Name, bytes.NewReader(file.Content), int64(len(file.Content)), storage.StoreWithContentType(file.ContentType))
+				if err != nil {
+					return fmt.Errorf("could not store %s image: %w", bucket, err)
+				}
+				return nil
+			})
+		}
+	}
+	return g.Wait()
+}
+
+// deleteImageFiles deletes the named images along with their variants.
+func (s *Service) deleteImageFiles(ctx context.Context, bucket string, fileNames []string, specs []imageVariantSpec) error {
+	g, gctx := errgroup.WithContext(ctx)
+	for _, fileName := range fileNames {
+		for _, name := range imageVariantNames(fileName, specs) {
+			name := name
+			g.Go(func() error {
+				err := s.Store.Delete(gctx, bucket, name)
+				if err != nil {
+					return fmt.Errorf("could not delete %s image: %w", bucket, err)
+				}
+				return nil
+			})
+		}
+	}
+	return g.Wait()
+}
+
+// newImage builds the srcset of the named image.
+// Variant URLs are served through the "variant" query parameter.
+func newImage(prefix, name string, specs []imageVariantSpec) *Image {
+	out := &Image{URL: prefix + name}
+	for _, spec := range specs {
+		out.Srcset = append(out.Srcset, ImageSource{
+			Variant: spec.Variant,
+			URL:     prefix + name + "?variant=" + string(spec.Variant),
+			Width:   spec.Width,
+		})
+	}
+	out.Srcset = append(out.Srcset, ImageSource{
+		Variant: ImageVariantOriginal,
+		URL:     out.URL,
+	})
+	return out
+}
+
+// avatarImage builds the avatar image.
+// Avatars are always avatarSize squares.
+func (s *Service) avatarImage(avatar, blurHash sql.NullString) *Image {
+	if !avatar.Valid {
+		return nil
+	}
+
+	img := newImage(s.AvatarURLPrefix, avatar.String, avatarVariants)
+	imageMeta{
+		BlurHash: blurHash,
+		Width:    sql.NullInt64{Int64: avatarSize, Valid: true},
+		Height:   sql.NullInt64{Int64: avatarSize, Valid: true},
+	}.apply(img)
+	return img
+}
+
+func (s *Service) coverImage(cover sql.NullString, meta imageMeta) *Image {
+	if !cover.Valid {
+		return nil
+	}
+
+	img := newImage(s.CoverURLPrefix, cover.String, coverVariants)
+	meta.apply(img)
+	return img
+}
+
+func (s *Service) mediaImages(media []string) []Image {
+	if media == nil {
+		return nil
+	}
+
+	out := make([]Image, len(media))
+	for i, item := range media {
+		out[i] = *newImage(s.MediaURLPrefix, item, mediaVariants)
+	}
+	return out
+}
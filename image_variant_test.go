@@ -0,0 +1,68 @@
+package nakama
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestImageVariantName(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant ImageVariant
+		want    string
+	}{
+		{name: "abc.jpg", variant: ImageVariantThumb, want: "abc_thumb.jpg"},
+		{name: "abc.png", variant: ImageVariantMedium, want: "abc_medium.png"},
+		{name: "abc.jpg", variant: ImageVariantOriginal, want: "abc.jpg"},
+		{name: "abc.jpg", variant: "", want: "abc.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := ImageVariantName(tt.name, tt.variant)
+			testutil.WantEq(t, tt.want, got, "name")
+			testutil.WantEq(t, tt.name, imageVariantBase(got), "base")
+		})
+	}
+
+	testutil.WantEq(t, "a_b.jpg", imageVariantBase("a_b.jpg"), "unknown variant")
+	testutil.WantEq(t, "a_original.jpg", imageVariantBase("a_original.jpg"), "original variant")
+}
+
+func Test_imageVariantFiles(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	files, err := imageVariantFiles(decodedImage{Image: img, ContentType: "image/png"}, "abc.png", mediaVariants)
+	testutil.WantEq(t, nil, err, "error")
+	testutil.WantEq(t, len(mediaVariants), len(files), "files")
+
+	wantWidths := map[string]int{
+		"abc_thumb.png":  160,
+		"abc_small.png":  480,
+		"abc_medium.png": 800, // not upscaled
+	}
+	for _, f := range files {
+		testutil.WantEq(t, "image/png", f.ContentType, "content type")
+
+		cfg, err := png.DecodeConfig(bytes.NewReader(f.Content))
+		testutil.WantEq(t, nil, err, "decode error")
+		testutil.WantEq(t, wantWidths[f.Name], cfg.Width, f.Name+" width")
+		testutil.WantEq(t, wantWidths[f.Name]/2, cfg.Height, f.Name+" height")
+	}
+}
+
+func TestService_mediaImages(t *testing.T) {
+	svc := &Service{MediaURLPrefix: "https://example.org/img/media/"}
+	media := []string{"abc.jpg"}
+	got := svc.mediaImages(media)
+	testutil.WantEq(t, 1, len(got), "images")
+	testutil.WantEq(t, "https://example.org/img/media/abc.jpg", got[0].URL, "url")
+	testutil.WantEq(t, len(mediaVariants)+1, len(got[0].Srcset), "srcset")
+	testutil.WantEq(t, "https://example.org/img/media/abc.jpg?variant=thumb", got[0].Srcset[0].URL, "thumb url")
+	testutil.WantEq(t, 160, got[0].Srcset[0].Width, "thumb width")
+	testutil.WantEq(t, ImageVariantOriginal, got[0].Srcset[len(got[0].Srcset)-1].Variant, "last variant")
+	testutil.WantEq(t, "abc.jpg", media[0], "media left untouched")
+	testutil.WantEq(t, []Image(nil), svc.mediaImages(nil), "nil media")
+}
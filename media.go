@@ -0,0 +1,146 @@
+package nakama
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+// mediaFile is a decoded and re-encoded media item ready to be stored.
+type mediaFile struct {
+	Name        string
+	ContentType string
+	Content     []byte
+	Width       int
+	Height      int
+	BlurHash    string
+	// Variants are the downscaled versions of the media item.
+	Variants []mediaFile
+}
+
+// processMedia decodes and re-encodes the given media items in parallel,
+// stripping metadata and checking the size limits.
+func processMedia(media []io.ReadSeeker) ([]mediaFile, error) {
+	if len(media) == 0 {
+		return nil, nil
+	}
+
+	files := make([]mediaFile, len(media))
+	g := errgroup.Group{}
+	for i, mediaItem := range media {
+		i := i
+		mediaItem := mediaItem
+
+		g.Go(func() error {
+			f, err := processMediaItem(mediaItem, MaxMediaItemBytes)
+			if err != nil {
+				return err
+			}
+
+			files[i] = f
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var mediaItemsBytes int64
+	for _, f := range files {
+		mediaItemsBytes += int64(len(f.Content))
+	}
+
+	if mediaItemsBytes > MaxMediaBytes {
+		return nil, ErrMediaTooLarge
+	}
+
+	return files, nil
+}
+
+func processMediaItem(mediaItem io.ReadSeeker, maxBytes int64) (mediaFile, error) {
+	var f mediaFile
+	ct, err := detectContentType(mediaItem)
+	if err != nil {
+		return f, fmt.Errorf("detect media content type: %w", err)
+	}
+
+	if !supportedImageType(ct) {
+		return f, ErrUnsupportedMediaItemFormat
+	}
+
+	img, err := decodeImage(mediaItem, ct, maxBytes, true)
+	if err != nil {
+		return f, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := encodeImage(buf, img.Image, img.Anim, img.ContentType); err != nil {
+		return f, fmt.Errorf("could not encode media item: %w", err)
+	}
+
+	fileName := mediaFileName(buf.Bytes(), img.ContentType)
+
+	f.Variants, err = imageVariantFiles(img, fileName, mediaVariants)
+	if err != nil {
+		return f, err
+	}
+
+	f.Name = fileName
+	f.ContentType = img.ContentType
+	f.Width = img.Image.Bounds().Dx()
+	f.Height = img.Image.Bounds().Dy()
+	f.BlurHash = blurHash(img.Image)
+	f.Content = buf.Bytes()
+	return f, nil
+}
+
+func (s *Service) storeMediaFiles(ctx context.Context, files []mediaFile) error {
+	return s.storeImageFiles(ctx, MediaBucket, files)
+}
+
+func (s *Service) deleteMediaFiles(ctx context.Context, fileNames []string) error {
+	return s.deleteImageFiles(ctx, MediaBucket, fileNames, mediaVariants)
+}
+
+// mediaFileName addresses media by the hash of its processed content
+// so identical media gets stored once.
+// Variants are derived from the same content so they share it too.
+func mediaFileName(content []byte, contentType string) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]) + imageExt(contentType)
+}
+
+// privateMediaFiles renames the given media files and their variants at random
+// so nobody holding a copy of the media can derive their names.
+// Use it for media only some users should reach, like message attachments.
+func privateMediaFiles(files []mediaFile) error {
+	for i := range files {
+		id, err := gonanoid.Generate("0123456789abcdefghijklmnopqrstuvwxyz", 32)
+		if err != nil {
+			return fmt.Errorf("could not generate private media file name: %w", err)
+		}
+
+		f := &files[i]
+		f.Name = id + path.Ext(f.Name)
+		for j := range f.Variants {
+			f.Variants[j].Name = ImageVariantName(f.Name, mediaVariants[j].Variant)
+		}
+	}
+	return nil
+}
+
+func mediaFileNames(files []mediaFile) []string {
+	var fileNames []string
+	for _, f := range files {
+		fileNames = append(fileNames, f.Name)
+	}
+	return fileNames
+}
@@ -0,0 +1,276 @@
+package nakama
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+const mediaAltTextMaxLength = 1500
+
+var (
+	// ErrInvalidMediaAltText denotes an invalid media alt text; that is too long,
+	// or more alt texts than media items.
+	ErrInvalidMediaAltText = InvalidArgumentError("invalid media alt text")
+	// ErrInvalidMediaItemName denotes an invalid media item name.
+	ErrInvalidMediaItemName = InvalidArgumentError("invalid media item name")
+	// ErrMediaItemNotFound denotes a not found media item.
+	ErrMediaItemNotFound = NotFoundError("media item not found")
+)
+
+// MediaItem model.
+// Metadata fields are null for media stored before media items existed.
+type MediaItem struct {
+	Image
+	Name        string  `json:"name"`
+	ContentType *string `json:"contentType"`
+	AltText     *string `json:"altText"`
+}
+
+// UpdatePostMediaAltText of a post media item.
+// A nil alt text clears it.
+// Unlike the rest of the post, alt text can be edited anytime.
+func (s *Service) UpdatePostMediaAltText(ctx context.Context, postID, name string, altText *string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(postID) {
+		return ErrInvalidPostID
+	}
+
+	if name == "" || imageVariantBase(name) != name {
+		return ErrInvalidMediaItemName
+	}
+
+	altText, err := normalizeMediaAltText(altText)
+	if err != nil {
+		return err
+	}
+
+	var authorID string
+	var found bool
+	query := "SELECT user_id, $2 = ANY(media) IS TRUE FROM posts WHERE id = $1"
+	err = s.DB.QueryRowContext(ctx, query, postID, name).Scan(&authorID, &found)
+	if err == sql.ErrNoRows {
+		return ErrPostNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not sql query select post media: %w", err)
+	}
+
+	if authorID != uid {
+		return ErrUpdatePostDenied
+	}
+
+	if !found {
+		return ErrMediaItemNotFound
+	}
+
+	query = `
+		INSERT INTO post_media_alt_texts (post_id, name, alt_text) VALUES ($1, $2, $3)
+		ON CONFLICT (post_id, name) DO UPDATE SET alt_text = excluded.alt_text`
+	_, err = s.DB.ExecContext(ctx, query, postID, name, altText)
+	if err != nil {
+		return fmt.Errorf("could not sql upsert post media alt text: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeMediaAltTexts validates the given alt texts against the count of media items.
+// The output has one entry per media item, nil for the ones without.
+func normalizeMediaAltTexts(altTexts []string, mediaCount int) ([]*string, error) {
+	if len(altTexts) > mediaCount {
+		return nil, ErrInvalidMediaAltText
+	}
+
+	out := make([]*string, mediaCount)
+	for i, altText := range altTexts {
+		altText := altText
+		v, err := normalizeMediaAltText(&altText)
+		if err != nil {
+			return nil, err
+		}
+
+		out[i] = v
+	}
+	return out, nil
+}
+
+func normalizeMediaAltText(altText *string) (*string, error) {
+	if altText == nil {
+		return nil, nil
+	}
+
+	v := smartTrim(*altText)
+	if v == "" {
+		return nil, nil
+	}
+
+	if utf8.RuneCountInString(v) > mediaAltTextMaxLength {
+		return nil, ErrInvalidMediaAltText
+	}
+
+	return &v, nil
+}
+
+// insertMediaItems inserts the metadata of the given stored media files.
+// Identical media shares the metadata of the first one stored.
+func insertMediaItems(ctx context.Context, tx *sql.Tx, uid string, files []mediaFile) error {
+	for _, f := range files {
+		query := `
+			INSERT INTO media_items (name, user_id, content_type, width, height, blurhash)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (name) DO NOTHING`
+		_, err := tx.ExecContext(ctx, query, f.Name, uid, f.ContentType, f.Width, f.Height, f.BlurHash)
+		if err != nil {
+			return fmt.Errorf("could not sql insert media item: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// insertPostMediaAltTexts sets the alt texts of the post media.
+// Alt texts belong to the post since the same media can be shared by many.
+func insertPostMediaAltTexts(ctx context.Context, tx *sql.Tx, postID string, media []string, altTexts []*string) error {
+	for i, name := range media {
+		if altTexts[i] == nil {
+			continue
+		}
+
+		query := `
+			INSERT INTO post_media_alt_texts (post_id, name, alt_text) VALUES ($1, $2, $3)
+			ON CONFLICT (post_id, name) DO NOTHING`
+		if _, err := tx.ExecContext(ctx, query, postID, name, altTexts[i]); err != nil {
+			return fmt.Errorf("could not sql insert post media alt text: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// mediaItems builds the media items of the given media names without metadata.
+// Use loadMediaItems to fill it.
+func (s *Service) mediaItems(media []string) []MediaItem {
+	if media == nil {
+		return nil
+	}
+
+	out := make([]MediaItem, len(media))
+	for i, name := range media {
+		out[i] = MediaItem{
+			Image: *newImage(s.MediaURLPrefix, name, mediaVariants),
+			Name:  name,
+		}
+	}
+	return out
+}
+
+// loadMediaItems fills the metadata of the media items of the given posts
+// with a single query.
+func (s *Service) loadMediaItems(ctx context.Context, pp ...*Post) error {
+	var names []string
+	for _, p := range pp {
+		for _, item := range p.Media {
+			names = append(names, item.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT name, content_type, width, height, blurhash, alt_text
+		FROM media_items WHERE name = ANY($1)`
+	rows, err := s.DB.QueryContext(ctx, query, pq.Array(names))
+	if err != nil {
+		return fmt.Errorf("could not sql query select media items: %w", err)
+	}
+
+	defer rows.Close()
+
+	byName := map[string]MediaItem{}
+	for rows.Next() {
+		var item MediaItem
+		var meta imageMeta
+		err := rows.Scan(&item.Name, &item.ContentType, &meta.Width, &meta.Height, &meta.BlurHash, &item.AltText)
+		if err != nil {
+			return fmt.Errorf("could not sql scan media item: %w", err)
+		}
+
+		meta.apply(&item.Image)
+
+		byName[item.Name] = item
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate over media item rows: %w", err)
+	}
+
+	altTexts, err := s.postMediaAltTexts(ctx, pp...)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pp {
+		for i, item := range p.Media {
+			meta, ok := byName[item.Name]
+			if !ok {
+				continue
+			}
+
+			meta.URL, meta.Srcset = item.URL, item.Srcset
+			// media items stored before content addressing keep their own alt text.
+			if altText, ok := altTexts[[2]string{p.ID, item.Name}]; ok {
+				meta.AltText = altText
+			}
+			p.Media[i] = meta
+		}
+	}
+
+	return nil
+}
+
+// postMediaAltTexts loads the media alt texts of the given posts
+// keyed by post ID and media item name.
+func (s *Service) postMediaAltTexts(ctx context.Context, pp ...*Post) (map[[2]string]*string, error) {
+	var postIDs []string
+	for _, p := range pp {
+		if len(p.Media) != 0 {
+			postIDs = append(postIDs, p.ID)
+		}
+	}
+
+	query := "SELECT post_id, name, alt_text FROM post_media_alt_texts WHERE post_id = ANY($1)"
+	rows, err := s.DB.QueryContext(ctx, query, pq.Array(postIDs))
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select post media alt texts: %w", err)
+	}
+
+	defer rows.Close()
+
+	out := map[[2]string]*string{}
+	for rows.Next() {
+		var postID, name string
+		var altText *string
+		if err := rows.Scan(&postID, &name, &altText); err != nil {
+			return nil, fmt.Errorf("could not sql scan post media alt text: %w", err)
+		}
+
+		out[[2]string{postID, name}] = altText
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate over post media alt text rows: %w", err)
+	}
+
+	return out, nil
+}
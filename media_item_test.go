@@ -0,0 +1,78 @@
+package nakama
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_normalizeMediaAltTexts(t *testing.T) {
+	got, err := normalizeMediaAltTexts([]string{" a cat ", ""}, 3)
+	testutil.WantEq(t, nil, err, "error")
+	testutil.WantEq(t, 3, len(got), "len")
+	testutil.WantEq(t, "a cat", *got[0], "first")
+	testutil.WantEq(t, true, got[1] == nil, "empty is nil")
+	testutil.WantEq(t, true, got[2] == nil, "missing is nil")
+
+	_, err = normalizeMediaAltTexts([]string{"a", "b"}, 1)
+	testutil.WantEq(t, ErrInvalidMediaAltText, err, "more alt texts than media")
+
+	_, err = normalizeMediaAltTexts([]string{strings.Repeat("a", mediaAltTextMaxLength+1)}, 1)
+	testutil.WantEq(t, ErrInvalidMediaAltText, err, "too long")
+}
+
+func TestService_UpdatePostMediaAltText(t *testing.T) {
+	svc := &Service{Logger: log.NewNopLogger()}
+	ctx := context.WithValue(context.Background(), KeyAuthUserID, "00000001-0000-0000-0000-000000000000")
+	postID := "00000002-0000-0000-0000-000000000000"
+	long := strings.Repeat("a", mediaAltTextMaxLength+1)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		postID  string
+		item    string
+		altText *string
+		want    error
+	}{
+		{
+			name:   "unauthenticated",
+			ctx:    context.Background(),
+			postID: postID,
+			item:   "abc.jpg",
+			want:   ErrUnauthenticated,
+		},
+		{
+			name:   "invalid_post_id",
+			ctx:    ctx,
+			postID: "nope",
+			item:   "abc.jpg",
+			want:   ErrInvalidPostID,
+		},
+		{
+			name:   "variant_name",
+			ctx:    ctx,
+			postID: postID,
+			item:   "abc_thumb.jpg",
+			want:   ErrInvalidMediaItemName,
+		},
+		{
+			name:    "too_long",
+			ctx:     ctx,
+			postID:  postID,
+			item:    "abc.jpg",
+			altText: &long,
+			want:    ErrInvalidMediaAltText,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.UpdatePostMediaAltText(tt.ctx, tt.postID, tt.item, tt.altText)
+			testutil.WantEq(t, tt.want, err, "error")
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package nakama
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nakamauwu/nakama/storage"
+)
+
+// ErrMediaDeleting denotes media identical to the one being stored
+// getting deleted at the same time.
+var ErrMediaDeleting = UnavailableError("identical media is being deleted; try again")
+
+// acquireMedia counts a new reference to each of the given media files
+// and charges their size to the user storage usage.
+// References are held by posts, messages and finalized post uploads.
+// It fails with ErrMediaDeleting while a deletion of the same media is claimed,
+// and restores the files of a first reference
+// in case a deletion completed after they got stored.
+func (s *Service) acquireMedia(ctx context.Context, tx *sql.Tx, uid string, files []mediaFile) error {
+	staleClaim := time.Now().Add(-storageGCGracePeriod)
+	for _, f := range files {
+		var refCount int
+		query := `
+			INSERT INTO media_objects (name, ref_count, size) VALUES ($1, 1, $2)
+			ON CONFLICT (name) DO UPDATE SET
+				ref_count = media_objects.ref_count + 1
+				, deleting_at = NULL
+			WHERE media_objects.deleting_at IS NULL OR media_objects.deleting_at < $3
+			RETURNING ref_count`
+		err := tx.QueryRowContext(ctx, query, f.Name, imageFilesSize([]mediaFile{f}), staleClaim).Scan(&refCount)
+		if err == sql.ErrNoRows {
+			return ErrMediaDeleting
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not sql increment media object references: %w", err)
+		}
+
+		if refCount == 1 {
+			if err := s.restoreMissingMedia(ctx, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.chargeStorage(ctx, tx, uid, imageFilesSize(files))
+}
+
+// restoreMissingMedia stores the given media file again
+// if it or any of its variants is missing.
+func (s *Service) restoreMissingMedia(ctx context.Context, file mediaFile) error {
+	for _, f := range append([]mediaFile{file}, file.Variants...) {
+		obj, err := s.Store.Open(ctx, MediaBucket, f.Name)
+		if errors.Is(err, storage.ErrNotFound) {
+			return s.storeMediaFiles(ctx, []mediaFile{file})
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not open media object: %w", err)
+		}
+
+		_ = obj.Close()
+	}
+
+	return nil
+}
+
+// releaseMedia drops a reference to each of the given media objects,
+// frees their size from the user storage usage,
+// and returns the ones no longer referenced.
+// Media stored before reference counting has no count
+// and is left to the storage garbage collector.
+func (s *Service) releaseMedia(ctx context.Context, tx *sql.Tx, uid string, names []string) ([]string, error) {
+	var unreferenced []string
+	var freed int64
+	for _, name := range names {
+		var refCount int
+		var size int64
+		query := `
+			UPDATE media_objects SET ref_count = ref_count - 1
+			WHERE name = $1 AND ref_count > 0
+			RETURNING ref_count, size`
+		err := tx.QueryRowContext(ctx, query, name).Scan(&refCount, &size)
+		if err == sql.ErrNoRows {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not sql decrement media object references: %w", err)
+		}
+
+		freed += size
+		if refCount == 0 {
+			unreferenced = append(unreferenced, name)
+		}
+	}
+
+	if err := s.chargeStorage(ctx, tx, uid, -freed); err != nil && err != ErrUserGone {
+		return nil, err
+	}
+
+	return unreferenced, nil
+}
+
+// deleteUnreferencedMedia deletes the given media objects
+// along with their variants and metadata if they are still unreferenced.
+// Objects stored within the storage garbage collection grace period
+// are skipped since identical media might be about to get referenced again;
+// the garbage collector takes care of them later.
+func (s *Service) deleteUnreferencedMedia(ctx context.Context, names []string) error {
+	for _, name := range names {
+		query := "UPDATE media_objects SET deleting_at = now() WHERE name = $1 AND ref_count = 0 AND deleting_at IS NULL"
+		res, err := s.DB.ExecContext(ctx, query, name)
+		if err != nil {
+			return fmt.Errorf("could not sql update claim media object deletion: %w", err)
+		}
+
+		// referenced again meanwhile.
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			continue
+		}
+
+		if _, err := s.deleteClaimedMedia(ctx, name, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteClaimedMedia deletes the named media object
+// once its base media deletion got claimed on media_objects,
+// so acquireMedia does not reference it meanwhile.
+// The claim is released if the base media was stored recently or the deletion fails,
+// and dropped along with the base media object row once its files are gone.
+// It reports whether the object got deleted.
+func (s *Service) deleteClaimedMedia(ctx context.Context, base, name string) (bool, error) {
+	unclaim := func() {
+		query := "UPDATE media_objects SET deleting_at = NULL WHERE name = $1"
+		if _, err := s.DB.ExecContext(ctx, query, base); err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not sql update release media object deletion: %w", err))
+		}
+	}
+
+	recent, err := s.recentlyStoredMedia(ctx, base)
+	if err != nil {
+		unclaim()
+		return false, err
+	}
+
+	if recent {
+		unclaim()
+		return false, nil
+	}
+
+	if name == base {
+		query := "DELETE FROM media_items WHERE name = $1"
+		if _, err := s.DB.ExecContext(ctx, query, name); err != nil {
+			unclaim()
+			return false, fmt.Errorf("could not sql delete media item: %w", err)
+		}
+
+		err = s.deleteMediaFiles(ctx, []string{name})
+	} else {
+		err = s.Store.Delete(ctx, MediaBucket, name)
+	}
+	if err != nil {
+		unclaim()
+		return false, err
+	}
+
+	query := "DELETE FROM media_objects WHERE name = $1 AND deleting_at IS NOT NULL"
+	if _, err := s.DB.ExecContext(ctx, query, base); err != nil {
+		return true, fmt.Errorf("could not sql delete media object: %w", err)
+	}
+
+	return true, nil
+}
+
+// recentlyStoredMedia tells whether the named media object
+// was stored within the storage garbage collection grace period.
+// Storing identical media again refreshes it.
+func (s *Service) recentlyStoredMedia(ctx context.Context, name string) (bool, error) {
+	f, err := s.Store.Open(ctx, MediaBucket, name)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not open media object: %w", err)
+	}
+
+	defer f.Close()
+
+	return time.Since(f.LastModified) < storageGCGracePeriod, nil
+}
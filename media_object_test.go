@@ -0,0 +1,126 @@
+package nakama
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	fsstorage "github.com/nakamauwu/nakama/storage/fs"
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestService_releaseMedia(t *testing.T) {
+	if testDB == nil {
+		t.Skip("integration test")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := &Service{
+		Logger: log.NewNopLogger(),
+		DB:     testDB,
+		Store:  &fsstorage.Store{Root: root},
+	}
+
+	var uid string
+	err := testDB.QueryRowContext(ctx, "INSERT INTO users (email, username) VALUES ($1, $2) RETURNING id",
+		testutil.RandStr(t, 10)+"@example.org", testutil.RandStr(t, 10)).Scan(&uid)
+	testutil.WantEq(t, nil, err, "insert user")
+
+	name := testutil.RandStr(t, 10) + ".jpg"
+	err = svc.Store.Store(ctx, MediaBucket, name, []byte(name))
+	testutil.WantEq(t, nil, err, "store")
+
+	old := time.Now().Add(-storageGCGracePeriod * 2)
+	err = os.Chtimes(filepath.Join(root, MediaBucket, name), old, old)
+	testutil.WantEq(t, nil, err, "chtimes")
+
+	tx := func(fn func(tx *sql.Tx) error) {
+		t.Helper()
+
+		tx, err := testDB.BeginTx(ctx, nil)
+		testutil.WantEq(t, nil, err, "begin")
+		testutil.WantEq(t, nil, fn(tx), "tx error")
+		testutil.WantEq(t, nil, tx.Commit(), "commit")
+	}
+
+	tx(func(tx *sql.Tx) error {
+		f := mediaFile{Name: name, Content: []byte(name)}
+		return svc.acquireMedia(ctx, tx, uid, []mediaFile{f, f})
+	})
+
+	var unreferenced []string
+	tx(func(tx *sql.Tx) (err error) {
+		unreferenced, err = svc.releaseMedia(ctx, tx, uid, []string{name})
+		return err
+	})
+	testutil.WantEq(t, 0, len(unreferenced), "still referenced")
+
+	tx(func(tx *sql.Tx) (err error) {
+		unreferenced, err = svc.releaseMedia(ctx, tx, uid, []string{name})
+		return err
+	})
+	testutil.WantEq(t, []string{name}, unreferenced, "unreferenced")
+
+	var storageBytes int64
+	err = testDB.QueryRowContext(ctx, "SELECT storage_bytes FROM users WHERE id = $1", uid).Scan(&storageBytes)
+	testutil.WantEq(t, nil, err, "select storage bytes")
+	testutil.WantEq(t, int64(0), storageBytes, "freed storage bytes")
+
+	err = svc.deleteUnreferencedMedia(ctx, unreferenced)
+	testutil.WantEq(t, nil, err, "delete error")
+
+	_, err = os.Stat(filepath.Join(root, MediaBucket, name))
+	testutil.WantEq(t, true, os.IsNotExist(err), "deleted")
+}
+
+func TestService_acquireMedia_deleting(t *testing.T) {
+	if testDB == nil {
+		t.Skip("integration test")
+	}
+
+	ctx := context.Background()
+	svc := &Service{
+		Logger: log.NewNopLogger(),
+		DB:     testDB,
+		Store:  &fsstorage.Store{Root: t.TempDir()},
+	}
+
+	var uid string
+	err := testDB.QueryRowContext(ctx, "INSERT INTO users (email, username) VALUES ($1, $2) RETURNING id",
+		testutil.RandStr(t, 10)+"@example.org", testutil.RandStr(t, 10)).Scan(&uid)
+	testutil.WantEq(t, nil, err, "insert user")
+
+	f := mediaFile{Name: testutil.RandStr(t, 10) + ".jpg", Content: []byte("media")}
+	acquire := func() error {
+		t.Helper()
+
+		tx, err := testDB.BeginTx(ctx, nil)
+		testutil.WantEq(t, nil, err, "begin")
+		defer tx.Rollback()
+
+		if err := svc.acquireMedia(ctx, tx, uid, []mediaFile{f}); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	_, err = testDB.ExecContext(ctx, "INSERT INTO media_objects (name, deleting_at) VALUES ($1, now())", f.Name)
+	testutil.WantEq(t, nil, err, "insert claimed media object")
+	testutil.WantEq(t, ErrMediaDeleting, acquire(), "claimed")
+
+	// the deletion completed after the media got stored.
+	_, err = testDB.ExecContext(ctx, "DELETE FROM media_objects WHERE name = $1", f.Name)
+	testutil.WantEq(t, nil, err, "delete media object")
+	testutil.WantEq(t, nil, acquire(), "first reference")
+
+	obj, err := svc.Store.Open(ctx, MediaBucket, f.Name)
+	testutil.WantEq(t, nil, err, "restored")
+	obj.Close()
+}
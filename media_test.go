@@ -0,0 +1,40 @@
+package nakama
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_processMediaItem(t *testing.T) {
+	b := testGIF(t, 64, 32, 2)
+	a, err := processMediaItem(bytes.NewReader(b), int64(len(b)))
+	testutil.WantEq(t, nil, err, "error")
+	testutil.WantEq(t, true, regexp.MustCompile(`^[0-9a-f]{64}\.gif$`).MatchString(a.Name), "content addressed name")
+
+	again, err := processMediaItem(bytes.NewReader(b), int64(len(b)))
+	testutil.WantEq(t, nil, err, "again error")
+	testutil.WantEq(t, a.Name, again.Name, "identical media same name")
+	testutil.WantEq(t, ImageVariantName(a.Name, ImageVariantThumb), again.Variants[0].Name, "variant name")
+
+	b = testGIF(t, 64, 32, 3)
+	other, err := processMediaItem(bytes.NewReader(b), int64(len(b)))
+	testutil.WantEq(t, nil, err, "other error")
+	testutil.WantEq(t, false, a.Name == other.Name, "different media different name")
+}
+
+func Test_privateMediaFiles(t *testing.T) {
+	b := testGIF(t, 64, 32, 2)
+	f, err := processMediaItem(bytes.NewReader(b), int64(len(b)))
+	testutil.WantEq(t, nil, err, "error")
+
+	files := []mediaFile{f, f}
+	files[1].Variants = append([]mediaFile(nil), f.Variants...)
+	testutil.WantEq(t, nil, privateMediaFiles(files), "private error")
+	testutil.WantEq(t, true, regexp.MustCompile(`^[0-9a-z]{32}\.gif$`).MatchString(files[0].Name), "random name")
+	testutil.WantEq(t, false, files[0].Name == files[1].Name, "identical media different names")
+	testutil.WantEq(t, ImageVariantName(files[0].Name, ImageVariantThumb), files[0].Variants[0].Name, "variant name")
+	testutil.WantEq(t, imageVariantBase(files[0].Variants[0].Name), files[0].Name, "variant base")
+}
@@ -0,0 +1,573 @@
+package nakama
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SherClockHolmes/webpush-go"
+	"github.com/cockroachdb/cockroach-go/v2/crdb"
+	"github.com/lib/pq"
+)
+
+const messageContentMaxLength = 2048
+
+// Message model.
+type Message struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversationID"`
+	UserID         string    `json:"-"`
+	Content        string    `json:"content"`
+	MediaURLs      []string  `json:"mediaURLs"` // Deprecated: use Media.
+	Media          []Image   `json:"media"`
+	CreatedAt      time.Time `json:"createdAt"`
+	User           *User     `json:"user,omitempty"`
+	Mine           bool      `json:"mine"`
+}
+
+type Messages []Message
+
+func (mm Messages) EndCursor() *string {
+	if len(mm) == 0 {
+		return nil
+	}
+
+	last := mm[len(mm)-1]
+	return ptrString(encodeCursor(last.ID, last.CreatedAt))
+}
+
+// ReadReceipt is sent to the conversation
+// when a participant reads it.
+type ReadReceipt struct {
+	ConversationID string    `json:"conversationID"`
+	UserID         string    `json:"-"`
+	User           User      `json:"user"`
+	ReadAt         time.Time `json:"readAt"`
+}
+
+// ConversationEvent delivered by the conversation stream.
+// Either a new message or a read receipt.
+type ConversationEvent struct {
+	Message *Message     `json:"message,omitempty"`
+	Read    *ReadReceipt `json:"read,omitempty"`
+}
+
+// SendMessage to a conversation the authenticated user participates in.
+func (s *Service) SendMessage(ctx context.Context, conversationID, content string, media []io.ReadSeeker) (Message, error) {
+	var m Message
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return m, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(conversationID) {
+		return m, ErrInvalidConversationID
+	}
+
+	content = smartTrim(content)
+	if len(media) == 0 && content == "" || utf8.RuneCountInString(content) > messageContentMaxLength {
+		return m, ErrInvalidContent
+	}
+
+	if err := s.canSendMessage(ctx, uid, conversationID); err != nil {
+		return m, err
+	}
+
+	files, err := processMedia(media)
+	if err != nil {
+		return m, err
+	}
+
+	// attachments are not content addressed like post media
+	// since they are only meant for the conversation participants.
+	if err := privateMediaFiles(files); err != nil {
+		return m, err
+	}
+
+	if err := s.limitMedia(ctx, uid, files); err != nil {
+		return m, err
+	}
+
+	fileNames := mediaFileNames(files)
+	if len(files) != 0 {
+		if err := s.storeMediaFiles(ctx, files); err != nil {
+			return m, fmt.Errorf("could not store message media: %w", err)
+		}
+	}
+
+	err = crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO messages (conversation_id, user_id, content, media) VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at`
+		row := tx.QueryRowContext(ctx, query, conversationID, uid, content, pq.Array(fileNames))
+		if err := row.Scan(&m.ID, &m.CreatedAt); err != nil {
+			if isForeignKeyViolation(err) {
+				return ErrConversationNotFound
+			}
+
+			return fmt.Errorf("could not sql insert message: %w", err)
+		}
+
+		if err := s.recordUploads(ctx, tx, uid, mediaFileSizes(files)...); err != nil {
+			return err
+		}
+
+		if err := s.acquireMedia(ctx, tx, uid, files); err != nil {
+			return err
+		}
+
+		query = "UPDATE conversations SET last_message_id = $1, last_message_at = $2 WHERE id = $3"
+		if _, err := tx.ExecContext(ctx, query, m.ID, m.CreatedAt, conversationID); err != nil {
+			return fmt.Errorf("could not sql update conversation last message: %w", err)
+		}
+
+		query = `
+			UPDATE conversation_participants SET
+				unread_count = unread_count + 1
+				, last_message_at = $1
+			WHERE conversation_id = $2 AND user_id != $3`
+		if _, err := tx.ExecContext(ctx, query, m.CreatedAt, conversationID, uid); err != nil {
+			return fmt.Errorf("could not sql update conversation participants unread count: %w", err)
+		}
+
+		query = `
+			UPDATE conversation_participants SET
+				unread_count = 0
+				, last_read_at = $1
+				, last_message_at = $1
+			WHERE conversation_id = $2 AND user_id = $3`
+		if _, err := tx.ExecContext(ctx, query, m.CreatedAt, conversationID, uid); err != nil {
+			return fmt.Errorf("could not sql update sender conversation participant: %w", err)
+		}
+
+		return nil
+	})
+	// stored media is left to the storage garbage collector on error
+	// since identical media might be getting referenced concurrently.
+	if err != nil {
+		return m, err
+	}
+
+	m.ConversationID = conversationID
+	m.UserID = uid
+	m.Content = content
+	m.Media = s.mediaImages(fileNames)
+	m.MediaURLs = s.mediaURLs(fileNames)
+	m.Mine = true
+
+	go s.messageSent(m)
+
+	return m, nil
+}
+
+// canSendMessage checks the user participates in the conversation
+// and that no other participant blocked the user, nor was blocked.
+// On direct conversations it also checks the other participant
+// still accepts direct messages from them.
+func (s *Service) canSendMessage(ctx context.Context, uid, conversationID string) error {
+	var direct bool
+	query := `
+		SELECT conversations.direct_key IS NOT NULL
+		FROM conversation_participants
+		INNER JOIN conversations ON conversations.id = conversation_participants.conversation_id
+		WHERE conversation_participants.conversation_id = $1
+			AND conversation_participants.user_id = $2`
+	err := s.DB.QueryRowContext(ctx, query, conversationID, uid).Scan(&direct)
+	if err == sql.ErrNoRows {
+		return ErrConversationNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not sql query select conversation participation: %w", err)
+	}
+
+	var blocked bool
+	query = `
+		SELECT EXISTS (
+			SELECT 1 FROM conversation_participants
+			INNER JOIN user_blocks
+				ON (user_blocks.blocker_id = conversation_participants.user_id AND user_blocks.blocked_id = $2)
+					OR (user_blocks.blocker_id = $2 AND user_blocks.blocked_id = conversation_participants.user_id)
+			WHERE conversation_participants.conversation_id = $1
+				AND conversation_participants.user_id != $2
+		)`
+	err = s.DB.QueryRowContext(ctx, query, conversationID, uid).Scan(&blocked)
+	if err != nil {
+		return fmt.Errorf("could not sql query select conversation participant block existence: %w", err)
+	}
+
+	if blocked {
+		return ErrDirectMessagesNotAllowed
+	}
+
+	if !direct {
+		return nil
+	}
+
+	var fromFolloweesOnly, followsMe bool
+	query = `
+		SELECT users.dms_from_followees_only
+		, follows.follower_id IS NOT NULL
+		FROM conversation_participants
+		INNER JOIN users ON users.id = conversation_participants.user_id
+		LEFT JOIN follows
+			ON follows.follower_id = users.id AND follows.followee_id = $2
+		WHERE conversation_participants.conversation_id = $1
+			AND conversation_participants.user_id != $2`
+	err = s.DB.QueryRowContext(ctx, query, conversationID, uid).Scan(&fromFolloweesOnly, &followsMe)
+	if err == sql.ErrNoRows {
+		// the other participant deleted their account.
+		return ErrDirectMessagesNotAllowed
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not sql query select direct conversation participant: %w", err)
+	}
+
+	if fromFolloweesOnly && !followsMe {
+		return ErrDirectMessagesNotAllowed
+	}
+
+	return nil
+}
+
+func (s *Service) messageSent(m Message) {
+	u, err := s.userByID(context.Background(), m.UserID)
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not fetch message user: %w", err))
+		return
+	}
+
+	m.User = &u
+	m.Mine = false
+
+	go s.broadcastConversationEvent(m.ConversationID, ConversationEvent{Message: &m})
+	go s.pushMessage(m)
+}
+
+// pushMessage sends a web push notification to the other participants
+// skipping the ones blocked by or blocking the sender.
+func (s *Service) pushMessage(m Message) {
+	ctx := context.Background()
+	query := `
+		SELECT user_id FROM conversation_participants
+		WHERE conversation_id = $1 AND user_id != $2
+			AND NOT EXISTS (
+				SELECT 1 FROM user_blocks
+				WHERE (blocker_id = conversation_participants.user_id AND blocked_id = $2)
+					OR (blocker_id = $2 AND blocked_id = conversation_participants.user_id)
+			)`
+	rows, err := s.DB.QueryContext(ctx, query, m.ConversationID, m.UserID)
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not sql query select message recipients: %w", err))
+		return
+	}
+
+	defer rows.Close()
+
+	var recipients []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not sql scan message recipient: %w", err))
+			return
+		}
+
+		recipients = append(recipients, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not iterate over message recipient rows: %w", err))
+		return
+	}
+
+	if len(recipients) == 0 {
+		return
+	}
+
+	var actors []string
+	if m.User != nil {
+		actors = []string{m.User.Username}
+	}
+
+	message, err := json.Marshal(struct {
+		ID             string    `json:"id"`
+		Type           string    `json:"type"`
+		Actors         []string  `json:"actors"`
+		ConversationID string    `json:"conversationID"`
+		IssuedAt       time.Time `json:"issuedAt"`
+	}{
+		ID:             m.ID,
+		Type:           "message",
+		Actors:         actors,
+		ConversationID: m.ConversationID,
+		IssuedAt:       m.CreatedAt,
+	})
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not json marshal web push message: %w", err))
+		return
+	}
+
+	for _, userID := range recipients {
+		s.sendWebPush(userID, message, webPushTopic(m.ConversationID), webpush.UrgencyHigh)
+	}
+}
+
+// Messages of a conversation the authenticated user participates in,
+// newest first, with backward pagination.
+func (s *Service) Messages(ctx context.Context, conversationID string, last uint64, before *string) (Messages, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(conversationID) {
+		return nil, ErrInvalidConversationID
+	}
+
+	var beforeMessageID string
+	var beforeCreatedAt time.Time
+
+	if before != nil {
+		var err error
+		beforeMessageID, beforeCreatedAt, err = decodeCursor(*before)
+		if err != nil || !reUUID.MatchString(beforeMessageID) {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	if err := s.checkConversationParticipant(ctx, uid, conversationID); err != nil {
+		return nil, err
+	}
+
+	last = normalizePageSize(last)
+	query, args, err := buildQuery(`
+		SELECT messages.id
+		, messages.user_id
+		, messages.content
+		, messages.media
+		, messages.created_at
+		, users.username
+		, users.avatar
+		, users.avatar_blurhash
+		FROM messages
+		INNER JOIN users ON users.id = messages.user_id
+		WHERE messages.conversation_id = @conversationID
+		{{ if and .beforeMessageID .beforeCreatedAt }}
+			AND messages.created_at <= @beforeCreatedAt
+			AND (
+				messages.id < @beforeMessageID
+					OR messages.created_at < @beforeCreatedAt
+			)
+		{{ end }}
+		ORDER BY messages.created_at DESC, messages.id DESC
+		LIMIT @last`, map[string]interface{}{
+		"conversationID":  conversationID,
+		"last":            last,
+		"beforeMessageID": beforeMessageID,
+		"beforeCreatedAt": beforeCreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build messages sql query: %w", err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select messages: %w", err)
+	}
+
+	defer rows.Close()
+
+	var mm Messages
+	for rows.Next() {
+		var m Message
+		var u User
+		var avatar, avatarBlurHash sql.NullString
+		var media []string
+		err := rows.Scan(
+			&m.ID,
+			&m.UserID,
+			&m.Content,
+			pq.Array(&media),
+			&m.CreatedAt,
+			&u.Username,
+			&avatar,
+			&avatarBlurHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not sql scan message: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(avatar)
+		u.Avatar = s.avatarImage(avatar, avatarBlurHash)
+		m.ConversationID = conversationID
+		m.Media = s.mediaImages(media)
+		m.MediaURLs = s.mediaURLs(media)
+		m.User = &u
+		m.Mine = m.UserID == uid
+		mm = append(mm, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate over message rows: %w", err)
+	}
+
+	return mm, nil
+}
+
+// MarkConversationAsRead resets the unread count of the authenticated user
+// and lets the other participants know.
+func (s *Service) MarkConversationAsRead(ctx context.Context, conversationID string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(conversationID) {
+		return ErrInvalidConversationID
+	}
+
+	var readAt time.Time
+	query := `
+		UPDATE conversation_participants SET
+			unread_count = 0
+			, last_read_at = now()
+		WHERE conversation_id = $1 AND user_id = $2
+		RETURNING last_read_at`
+	err := s.DB.QueryRowContext(ctx, query, conversationID, uid).Scan(&readAt)
+	if err == sql.ErrNoRows {
+		return ErrConversationNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not sql update conversation as read: %w", err)
+	}
+
+	go s.conversationRead(ReadReceipt{
+		ConversationID: conversationID,
+		UserID:         uid,
+		ReadAt:         readAt,
+	})
+
+	return nil
+}
+
+func (s *Service) conversationRead(r ReadReceipt) {
+	u, err := s.userByID(context.Background(), r.UserID)
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not fetch read receipt user: %w", err))
+		return
+	}
+
+	r.User = u
+	s.broadcastConversationEvent(r.ConversationID, ConversationEvent{Read: &r})
+}
+
+// UnreadMessagesCount across all the conversations of the authenticated user.
+func (s *Service) UnreadMessagesCount(ctx context.Context) (uint64, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+
+	var unread uint64
+	query := "SELECT COALESCE(SUM(unread_count), 0) FROM conversation_participants WHERE user_id = $1"
+	if err := s.DB.QueryRowContext(ctx, query, uid).Scan(&unread); err != nil {
+		return 0, fmt.Errorf("could not sql query select unread messages count: %w", err)
+	}
+
+	return unread, nil
+}
+
+// ConversationStream delivers the new messages and read receipts
+// of a conversation the authenticated user participates in.
+// Own read receipts are skipped.
+func (s *Service) ConversationStream(ctx context.Context, conversationID, lastEventID string) (<-chan StreamEvent[ConversationEvent], error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(conversationID) {
+		return nil, ErrInvalidConversationID
+	}
+
+	if err := s.checkConversationParticipant(ctx, uid, conversationID); err != nil {
+		return nil, err
+	}
+
+	ee, err := subscribeStream(ctx, s, conversationTopic(conversationID), lastEventID, func(data []byte) (ConversationEvent, bool) {
+		var ev ConversationEvent
+		m, err := unmarshalMessage(data)
+		if err == nil {
+			m.Mine = m.UserID == uid
+			ev.Message = &m
+			return ev, true
+		}
+
+		if !errors.Is(err, errUnexpectedWireType) {
+			_ = s.Logger.Log("error", fmt.Errorf("could not decode message: %w", err))
+			return ev, false
+		}
+
+		r, err := unmarshalReadReceipt(data)
+		if err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not decode read receipt: %w", err))
+			return ev, false
+		}
+
+		ev.Read = &r
+		return ev, r.UserID != uid
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not subscribe to conversation: %w", err)
+	}
+
+	return ee, nil
+}
+
+func (s *Service) checkConversationParticipant(ctx context.Context, uid, conversationID string) error {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM conversation_participants
+			WHERE conversation_id = $1 AND user_id = $2
+		)`
+	if err := s.DB.QueryRowContext(ctx, query, conversationID, uid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not sql query select conversation participation: %w", err)
+	}
+
+	if !exists {
+		return ErrConversationNotFound
+	}
+
+	return nil
+}
+
+func (s *Service) broadcastConversationEvent(conversationID string, ev ConversationEvent) {
+	var b []byte
+	var err error
+	switch {
+	case ev.Message != nil:
+		b, err = marshalMessage(*ev.Message)
+	case ev.Read != nil:
+		b, err = marshalReadReceipt(*ev.Read)
+	default:
+		return
+	}
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not encode conversation event: %w", err))
+		return
+	}
+
+	err = s.PubSub.Pub(conversationTopic(conversationID), b)
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not publish conversation event: %w", err))
+		return
+	}
+}
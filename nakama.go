@@ -1,11 +1,14 @@
 package nakama
 
 import (
+	"context"
 	"database/sql"
 	_ "embed"
 	"html/template"
+	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 
@@ -35,7 +38,49 @@ type Service struct {
 	AllowedOrigins   []string
 	VAPIDPrivateKey  string
 	VAPIDPublicKey   string
+	// NotificationsRetention is how long read notifications are kept.
+	// Zero disables pruning.
+	NotificationsRetention time.Duration
+	// StreamBufferSize is the number of events buffered
+	// per realtime stream subscriber. Defaults to 64.
+	StreamBufferSize int
+	// SlowConsumerPolicy applies to stream subscribers with a full buffer.
+	// Defaults to SlowConsumerDrop.
+	SlowConsumerPolicy SlowConsumerPolicy
+	// Admins are the IDs of the users allowed to manage instance webhooks.
+	Admins []string
+	// StorageQuota is the max bytes each user can store
+	// across avatars, covers and media. Zero disables it.
+	StorageQuota int64
+	// UploadsPerHour is the max media items each user can upload per hour.
+	// Zero disables it.
+	UploadsPerHour int
+	// UploadBytesPerHour is the max media bytes each user can upload per hour.
+	// Zero disables it.
+	UploadBytesPerHour int64
 
 	magicLinkTmplOncer sync.Once
 	magicLinkTmpl      *template.Template
+
+	emailDigestTmplOncer sync.Once
+	emailDigestTmpl      *template.Template
+
+	webPushQueueOncer sync.Once
+	webPushQueue      chan webPushDelivery
+
+	webhookQueueOncer sync.Once
+	webhookQueue      chan webhookDelivery
+	webhookClient     *http.Client
+
+	streamReplays streamReplays
+}
+
+// RunBackgroundJobs until the given context is canceled.
+func (s *Service) RunBackgroundJobs(ctx context.Context) {
+	go s.emailDigestJob(ctx)
+	go s.pruneNotificationsJob(ctx)
+	go s.webPushDeliveryJob(ctx)
+	go s.webhookDeliveryJob(ctx)
+	go s.pruneUploadsJob(ctx)
+	go s.storageGCJob(ctx)
 }
@@ -1,18 +1,20 @@
 package nakama
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/gob"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/cockroachdb/cockroach-go/v2/crdb"
 	"github.com/lib/pq"
 )
 
+const (
+	notificationsPruneInterval  = time.Hour
+	notificationsPruneBatchSize = 1000
+)
+
 // ErrInvalidNotificationID denotes an invalid notification id; that is not uuid.
 var ErrInvalidNotificationID = InvalidArgumentError("invalid notification ID")
 
@@ -111,38 +113,26 @@ func (s *Service) Notifications(ctx context.Context, last uint64, before *string
 }
 
 // NotificationStream to receive notifications in realtime.
-func (s *Service) NotificationStream(ctx context.Context) (<-chan Notification, error) {
+// Pass the ID of the last received event to resume right after it.
+func (s *Service) NotificationStream(ctx context.Context, lastEventID string) (<-chan StreamEvent[Notification], error) {
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
 		return nil, ErrUnauthenticated
 	}
 
-	nn := make(chan Notification)
-	unsub, err := s.PubSub.Sub(notificationTopic(uid), func(data []byte) {
-		go func(r io.Reader) {
-			var n Notification
-			err := gob.NewDecoder(r).Decode(&n)
-			if err != nil {
-				_ = s.Logger.Log("error", fmt.Errorf("could not gob decode notification: %w", err))
-				return
-			}
+	nn, err := subscribeStream(ctx, s, notificationTopic(uid), lastEventID, func(data []byte) (Notification, bool) {
+		n, err := unmarshalNotification(data)
+		if err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not decode notification: %w", err))
+			return n, false
+		}
 
-			nn <- n
-		}(bytes.NewReader(data))
+		return n, true
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could not subcribe to notifications: %w", err)
 	}
 
-	go func() {
-		<-ctx.Done()
-		if err := unsub(); err != nil {
-			_ = s.Logger.Log("error", fmt.Errorf("could not unsubcribe from notifications: %w", err))
-			// don't return
-		}
-		close(nn)
-	}()
-
 	return nn, nil
 }
 
@@ -163,6 +153,23 @@ func (s *Service) HasUnreadNotifications(ctx context.Context) (bool, error) {
 	return unread, nil
 }
 
+// UnreadNotificationsCount of the authenticated user.
+func (s *Service) UnreadNotificationsCount(ctx context.Context) (uint64, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+
+	var count uint64
+	if err := s.DB.QueryRowContext(ctx, `
+		SELECT count(*) FROM notifications
+		WHERE user_id = $1 AND (read_at IS NULL OR read_at = '0001-01-01 00:00:00')`, uid).Scan(&count); err != nil {
+		return 0, fmt.Errorf("could not query select unread notifications count: %w", err)
+	}
+
+	return count, nil
+}
+
 // MarkNotificationAsRead sets a notification from the authenticated user as read.
 func (s *Service) MarkNotificationAsRead(ctx context.Context, notificationID string) error {
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
@@ -200,6 +207,94 @@ func (s *Service) MarkNotificationsAsRead(ctx context.Context) error {
 	return nil
 }
 
+// DeleteNotification from the authenticated user.
+func (s *Service) DeleteNotification(ctx context.Context, notificationID string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(notificationID) {
+		return ErrInvalidNotificationID
+	}
+
+	if _, err := s.DB.ExecContext(ctx, `
+		DELETE FROM notifications WHERE id = $1 AND user_id = $2`, notificationID, uid); err != nil {
+		return fmt.Errorf("could not sql delete notification: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAllNotifications from the authenticated user.
+func (s *Service) DeleteAllNotifications(ctx context.Context) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if _, err := s.DB.ExecContext(ctx, `
+		DELETE FROM notifications WHERE user_id = $1`, uid); err != nil {
+		return fmt.Errorf("could not sql delete all notifications: %w", err)
+	}
+
+	return nil
+}
+
+// pruneNotificationsJob deletes read notifications older than NotificationsRetention.
+// Unread notifications are never pruned.
+func (s *Service) pruneNotificationsJob(ctx context.Context) {
+	if s.NotificationsRetention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(notificationsPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.pruneNotifications(ctx, time.Now().Add(-s.NotificationsRetention))
+			if err != nil {
+				_ = s.Logger.Log("error", fmt.Errorf("could not prune notifications: %w", err))
+				continue
+			}
+
+			if n != 0 {
+				_ = s.Logger.Log("message", "pruned notifications", "count", n)
+			}
+		}
+	}
+}
+
+// pruneNotifications in batches so a big table does not end up in a single huge transaction.
+func (s *Service) pruneNotifications(ctx context.Context, olderThan time.Time) (int64, error) {
+	var total int64
+	for {
+		result, err := s.DB.ExecContext(ctx, `
+			DELETE FROM notifications
+			WHERE read_at IS NOT NULL
+				AND read_at != '0001-01-01 00:00:00'
+				AND issued_at < $1
+			LIMIT $2`, olderThan, notificationsPruneBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("could not sql delete old read notifications: %w", err)
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("could not get pruned notifications count: %w", err)
+		}
+
+		total += n
+		if n < notificationsPruneBatchSize {
+			return total, nil
+		}
+	}
+}
+
 func (s *Service) notifyFollow(followerID, followeeID string) {
 	ctx := context.Background()
 	var n Notification
@@ -345,6 +440,7 @@ func (s *Service) notifyPostMention(p Post) {
 
 	defer rows.Close()
 
+	var mentioned []string
 	for rows.Next() {
 		var n Notification
 		if err = rows.Scan(&n.ID, &n.UserID, &n.IssuedAt); err != nil {
@@ -356,6 +452,7 @@ func (s *Service) notifyPostMention(p Post) {
 		n.Type = "post_mention"
 		n.PostID = &p.ID
 
+		mentioned = append(mentioned, n.UserID)
 		go s.broadcastNotification(n)
 	}
 
@@ -363,6 +460,10 @@ func (s *Service) notifyPostMention(p Post) {
 		_ = s.Logger.Log("error", fmt.Errorf("could not iterate post mention notification rows: %w", err))
 		return
 	}
+
+	if len(mentioned) != 0 {
+		go s.emitWebhookEvent(WebhookEventMention, mentioned, webhookMentionData{PostID: p.ID, Post: &p})
+	}
 }
 
 func (s *Service) notifyCommentMention(c Comment) {
@@ -394,6 +495,7 @@ func (s *Service) notifyCommentMention(c Comment) {
 
 	defer rows.Close()
 
+	var mentioned []string
 	for rows.Next() {
 		var n Notification
 		if err = rows.Scan(&n.ID, &n.UserID, pq.Array(&n.Actors), &n.IssuedAt); err != nil {
@@ -404,6 +506,7 @@ func (s *Service) notifyCommentMention(c Comment) {
 		n.Type = "comment_mention"
 		n.PostID = &c.PostID
 
+		mentioned = append(mentioned, n.UserID)
 		go s.broadcastNotification(n)
 	}
 
@@ -411,17 +514,20 @@ func (s *Service) notifyCommentMention(c Comment) {
 		_ = s.Logger.Log("error", fmt.Errorf("could not iterate comment mention notification rows: %w", err))
 		return
 	}
+
+	if len(mentioned) != 0 {
+		go s.emitWebhookEvent(WebhookEventMention, mentioned, webhookMentionData{PostID: c.PostID, Comment: &c})
+	}
 }
 
 func (s *Service) broadcastNotification(n Notification) {
-	var b bytes.Buffer
-	err := gob.NewEncoder(&b).Encode(n)
+	b, err := marshalNotification(n)
 	if err != nil {
-		_ = s.Logger.Log("error", fmt.Errorf("could not gob encode notification: %w", err))
+		_ = s.Logger.Log("error", fmt.Errorf("could not encode notification: %w", err))
 		return
 	}
 
-	err = s.PubSub.Pub(notificationTopic(n.UserID), b.Bytes())
+	err = s.PubSub.Pub(notificationTopic(n.UserID), b)
 	if err != nil {
 		_ = s.Logger.Log("error", fmt.Errorf("could not publish notification: %w", err))
 		return
@@ -0,0 +1,123 @@
+package nakama
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestService_DeleteNotification(t *testing.T) {
+	svc := &Service{Logger: log.NewNopLogger()}
+
+	err := svc.DeleteNotification(context.Background(), "00000000-0000-0000-0000-000000000001")
+	testutil.WantEq(t, ErrUnauthenticated, err, "unauthenticated")
+
+	ctx := context.WithValue(context.Background(), KeyAuthUserID, "00000000-0000-0000-0000-000000000002")
+	err = svc.DeleteNotification(ctx, "nope")
+	testutil.WantEq(t, ErrInvalidNotificationID, err, "invalid notification id")
+
+	testutil.WantEq(t, ErrUnauthenticated, svc.DeleteAllNotifications(context.Background()), "delete all unauthenticated")
+
+	_, err = svc.UnreadNotificationsCount(context.Background())
+	testutil.WantEq(t, ErrUnauthenticated, err, "count unauthenticated")
+}
+
+func TestService_notifications(t *testing.T) {
+	if testDB == nil {
+		t.Skip("integration test")
+	}
+
+	svc := &Service{Logger: log.NewNopLogger(), DB: testDB}
+
+	insertUser := func() string {
+		t.Helper()
+
+		var id string
+		err := testDB.QueryRow("INSERT INTO users (email, username) VALUES ($1, $2) RETURNING id",
+			testutil.RandStr(t, 10)+"@example.org", testutil.RandStr(t, 10)).Scan(&id)
+		testutil.WantEq(t, nil, err, "insert user")
+		return id
+	}
+
+	insertNotification := func(uid string, readAt *time.Time) string {
+		t.Helper()
+
+		var id string
+		err := testDB.QueryRow("INSERT INTO notifications (user_id, actors, type, read_at) VALUES ($1, ARRAY['actor'], 'follow', $2) RETURNING id",
+			uid, readAt).Scan(&id)
+		testutil.WantEq(t, nil, err, "insert notification")
+		return id
+	}
+
+	notificationExists := func(id string) bool {
+		t.Helper()
+
+		var exists bool
+		err := testDB.QueryRow("SELECT EXISTS (SELECT 1 FROM notifications WHERE id = $1)", id).Scan(&exists)
+		testutil.WantEq(t, nil, err, "select notification existence")
+		return exists
+	}
+
+	alice, bob := insertUser(), insertUser()
+	aliceCtx := context.WithValue(context.Background(), KeyAuthUserID, alice)
+	bobCtx := context.WithValue(context.Background(), KeyAuthUserID, bob)
+
+	t.Run("unread_count", func(t *testing.T) {
+		readAt := time.Now()
+		zero := time.Time{}
+		insertNotification(alice, nil)
+		insertNotification(alice, &zero)
+		insertNotification(alice, &readAt)
+		insertNotification(bob, nil)
+
+		count, err := svc.UnreadNotificationsCount(aliceCtx)
+		testutil.WantEq(t, nil, err, "error")
+		testutil.WantEq(t, uint64(2), count, "count")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		id := insertNotification(alice, nil)
+
+		testutil.WantEq(t, nil, svc.DeleteNotification(bobCtx, id), "delete other user notification")
+		testutil.WantEq(t, true, notificationExists(id), "other user notification kept")
+
+		testutil.WantEq(t, nil, svc.DeleteNotification(aliceCtx, id), "delete own notification")
+		testutil.WantEq(t, false, notificationExists(id), "own notification deleted")
+	})
+
+	t.Run("delete_all", func(t *testing.T) {
+		bobID := insertNotification(bob, nil)
+
+		testutil.WantEq(t, nil, svc.DeleteAllNotifications(aliceCtx), "delete all")
+
+		count, err := svc.UnreadNotificationsCount(aliceCtx)
+		testutil.WantEq(t, nil, err, "count error")
+		testutil.WantEq(t, uint64(0), count, "count after delete all")
+		testutil.WantEq(t, true, notificationExists(bobID), "other user notifications kept")
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		// issued long ago so other tests notifications are left alone.
+		issuedAt := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		const read = notificationsPruneBatchSize*2 + 1
+		_, err := testDB.Exec(`
+			INSERT INTO notifications (user_id, actors, type, read_at, issued_at)
+			SELECT $1, ARRAY['actor'], 'follow', $2::TIMESTAMPTZ + i * INTERVAL '1 second', $2
+			FROM generate_series(1, $3) AS i`, bob, issuedAt, read)
+		testutil.WantEq(t, nil, err, "insert read notifications")
+
+		var unreadID string
+		err = testDB.QueryRow("INSERT INTO notifications (user_id, actors, type, issued_at) VALUES ($1, ARRAY['actor'], 'follow', $2) RETURNING id",
+			bob, issuedAt).Scan(&unreadID)
+		testutil.WantEq(t, nil, err, "insert unread notification")
+
+		n, err := svc.pruneNotifications(context.Background(), issuedAt.Add(time.Hour))
+		testutil.WantEq(t, nil, err, "error")
+		testutil.WantEq(t, int64(read), n, "pruned across batches")
+		testutil.WantEq(t, true, notificationExists(unreadID), "unread notification kept")
+	})
+}
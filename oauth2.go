@@ -82,15 +82,16 @@ func (svc *Service) LoginFromProvider(ctx context.Context, name string, provided
 			}
 		}
 
-		var avatar sql.NullString
-		query = fmt.Sprintf(`SELECT id, username, avatar FROM users WHERE %s_provider_id = $1`, name)
+		var avatar, avatarBlurHash sql.NullString
+		query = fmt.Sprintf(`SELECT id, username, avatar, avatar_blurhash FROM users WHERE %s_provider_id = $1`, name)
 		row = tx.QueryRowContext(ctx, query, providedUser.ID)
-		err = row.Scan(&u.ID, &u.Username, &avatar)
+		err = row.Scan(&u.ID, &u.Username, &avatar, &avatarBlurHash)
 		if err != nil {
 			return fmt.Errorf("could not sql query user by provider id: %w", err)
 		}
 
 		u.AvatarURL = svc.avatarURL(avatar)
+		u.Avatar = svc.avatarImage(avatar, avatarBlurHash)
 
 		return nil
 	})
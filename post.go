@@ -1,13 +1,10 @@
 package nakama
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/gob"
 	"encoding/json"
 	"fmt"
-	"io"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -42,19 +39,20 @@ var (
 
 // Post model.
 type Post struct {
-	ID            string     `json:"id"`
-	UserID        string     `json:"-"`
-	Content       string     `json:"content"`
-	SpoilerOf     *string    `json:"spoilerOf"`
-	NSFW          bool       `json:"nsfw"`
-	Reactions     []Reaction `json:"reactions"`
-	CommentsCount int        `json:"commentsCount"`
-	MediaURLs     []string   `json:"mediaURLs"`
-	CreatedAt     time.Time  `json:"createdAt"`
-	UpdatedAt     time.Time  `json:"updatedAt"`
-	User          *User      `json:"user,omitempty"`
-	Mine          bool       `json:"mine"`
-	Subscribed    bool       `json:"subscribed"`
+	ID            string      `json:"id"`
+	UserID        string      `json:"-"`
+	Content       string      `json:"content"`
+	SpoilerOf     *string     `json:"spoilerOf"`
+	NSFW          bool        `json:"nsfw"`
+	Reactions     []Reaction  `json:"reactions"`
+	CommentsCount int         `json:"commentsCount"`
+	MediaURLs     []string    `json:"mediaURLs"` // Deprecated: use Media.
+	Media         []MediaItem `json:"media"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+	User          *User       `json:"user,omitempty"`
+	Mine          bool        `json:"mine"`
+	Subscribed    bool        `json:"subscribed"`
 }
 
 type Reaction struct {
@@ -152,6 +150,7 @@ func (s *Service) Posts(ctx context.Context, last uint64, before *string, opts .
 		{{ if not .username }}
 		, users.username
 		, users.avatar
+		, users.avatar_blurhash
 		{{ end }}
 		FROM posts
 		{{ if .auth }}
@@ -212,7 +211,7 @@ func (s *Service) Posts(ctx context.Context, last uint64, before *string, opts .
 	for rows.Next() {
 		var p Post
 		var u User
-		var avatar sql.NullString
+		var avatar, avatarBlurHash sql.NullString
 		var rawReactions []byte
 		var rawUserReactions []byte
 		var media []string
@@ -231,7 +230,7 @@ func (s *Service) Posts(ctx context.Context, last uint64, before *string, opts .
 			dest = append(dest, &p.Mine, &rawUserReactions, &p.Subscribed)
 		}
 		if options.Username == nil {
-			dest = append(dest, &u.Username, &avatar)
+			dest = append(dest, &u.Username, &avatar, &avatarBlurHash)
 		}
 
 		if err = rows.Scan(dest...); err != nil {
@@ -266,9 +265,11 @@ func (s *Service) Posts(ctx context.Context, last uint64, before *string, opts .
 
 		if options.Username == nil {
 			u.AvatarURL = s.avatarURL(avatar)
+			u.Avatar = s.avatarImage(avatar, avatarBlurHash)
 			p.User = &u
 		}
 
+		p.Media = s.mediaItems(media)
 		p.MediaURLs = s.mediaURLs(media)
 		pp = append(pp, p)
 	}
@@ -277,36 +278,42 @@ func (s *Service) Posts(ctx context.Context, last uint64, before *string, opts .
 		return nil, fmt.Errorf("could not iterate posts rows: %w", err)
 	}
 
+	posts := make([]*Post, len(pp))
+	for i := range pp {
+		posts[i] = &pp[i]
+	}
+
+	if err := s.loadMediaItems(ctx, posts...); err != nil {
+		return nil, err
+	}
+
 	return pp, nil
 }
 
 // PostStream to receive posts in realtime.
 func (s *Service) PostStream(ctx context.Context) (<-chan Post, error) {
-	pp := make(chan Post)
-	unsub, err := s.PubSub.Sub(postsTopic, func(data []byte) {
-		go func(r io.Reader) {
-			var p Post
-			err := gob.NewDecoder(r).Decode(&p)
-			if err != nil {
-				_ = s.Logger.Log("error", fmt.Errorf("could not gob decode post: %w", err))
-				return
-			}
+	ee, err := subscribeStream(ctx, s, postsTopic, "", func(data []byte) (Post, bool) {
+		p, err := unmarshalPost(data)
+		if err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not decode post: %w", err))
+			return p, false
+		}
 
-			pp <- p
-		}(bytes.NewReader(data))
+		return p, true
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could not subscribe to posts: %w", err)
 	}
 
+	pp := make(chan Post)
 	go func() {
-		<-ctx.Done()
-		if err := unsub(); err != nil {
-			_ = s.Logger.Log("error", fmt.Errorf("could not unsubcribe from posts: %w", err))
-			// don't return
+		defer close(pp)
+		for ev := range ee {
+			select {
+			case pp <- ev.Item:
+			case <-ctx.Done():
+			}
 		}
-
-		close(pp)
 	}()
 
 	return pp, nil
@@ -332,6 +339,7 @@ func (s *Service) Post(ctx context.Context, postID string) (Post, error) {
 			, posts.updated_at
 			, users.username
 			, users.avatar
+			, users.avatar_blurhash
 			{{if .auth}}
 			, posts.user_id = @uid AS mine
 			, reactions.user_reactions
@@ -362,7 +370,7 @@ func (s *Service) Post(ctx context.Context, postID string) (Post, error) {
 	var rawReactions []byte
 	var rawUserReactions []byte
 	var u User
-	var avatar sql.NullString
+	var avatar, avatarBlurHash sql.NullString
 	var media []string
 	dest := []interface{}{
 		&p.ID,
@@ -376,6 +384,7 @@ func (s *Service) Post(ctx context.Context, postID string) (Post, error) {
 		&p.UpdatedAt,
 		&u.Username,
 		&avatar,
+		&avatarBlurHash,
 	}
 	if auth {
 		dest = append(dest, &p.Mine, &rawUserReactions, &p.Subscribed)
@@ -415,10 +424,16 @@ func (s *Service) Post(ctx context.Context, postID string) (Post, error) {
 		}
 	}
 
+	p.Media = s.mediaItems(media)
 	p.MediaURLs = s.mediaURLs(media)
 	u.AvatarURL = s.avatarURL(avatar)
+	u.Avatar = s.avatarImage(avatar, avatarBlurHash)
 	p.User = &u
 
+	if err := s.loadMediaItems(ctx, &p); err != nil {
+		return p, err
+	}
+
 	return p, nil
 }
 
@@ -552,10 +567,33 @@ func (s *Service) DeletePost(ctx context.Context, postID string) error {
 		return ErrInvalidPostID
 	}
 
-	query := "DELETE FROM posts WHERE id = $1 AND user_id = $2"
-	_, err := s.DB.ExecContext(ctx, query, postID, uid)
+	var unreferenced []string
+	err := crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
+		var media []string
+		query := "DELETE FROM posts WHERE id = $1 AND user_id = $2 RETURNING media"
+		err := tx.QueryRowContext(ctx, query, postID, uid).Scan(pq.Array(&media))
+		if err == sql.ErrNoRows {
+			unreferenced = nil
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not sql delete post: %w", err)
+		}
+
+		unreferenced, err = s.releaseMedia(ctx, tx, uid, media)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("could not sql delete post: %w", err)
+		return err
+	}
+
+	if len(unreferenced) != 0 {
+		go func() {
+			if err := s.deleteUnreferencedMedia(context.Background(), unreferenced); err != nil {
+				_ = s.Logger.Log("error", fmt.Errorf("could not delete released post media: %w", err))
+			}
+		}()
 	}
 
 	return nil
@@ -783,14 +821,13 @@ func (s *Service) TogglePostSubscription(ctx context.Context, postID string) (To
 const postsTopic = "posts"
 
 func (s *Service) broadcastPost(p Post) {
-	var b bytes.Buffer
-	err := gob.NewEncoder(&b).Encode(p)
+	b, err := marshalPost(p)
 	if err != nil {
-		_ = s.Logger.Log("error", fmt.Errorf("could not gob encode post: %w", err))
+		_ = s.Logger.Log("error", fmt.Errorf("could not encode post: %w", err))
 		return
 	}
 
-	err = s.PubSub.Pub(postsTopic, b.Bytes())
+	err = s.PubSub.Pub(postsTopic, b)
 	if err != nil {
 		_ = s.Logger.Log("error", fmt.Errorf("could not publish post: %w", err))
 		return
@@ -0,0 +1,61 @@
+// Package memory provides an in-process pubsub.PubSub
+// for single node deployments and tests.
+package memory
+
+import "sync"
+
+// PubSub implementation in memory with topic fan-out.
+// Callbacks are called synchronously from Pub in publishing order,
+// so they must not block.
+// Zero value is ready to use.
+type PubSub struct {
+	mu     sync.RWMutex
+	topics map[string]map[uint64]func(data []byte)
+	nextID uint64
+}
+
+// Pub publishes some data to the given topic.
+func (ps *PubSub) Pub(topic string, data []byte) error {
+	ps.mu.RLock()
+	cbs := make([]func(data []byte), 0, len(ps.topics[topic]))
+	for _, cb := range ps.topics[topic] {
+		cbs = append(cbs, cb)
+	}
+	ps.mu.RUnlock()
+
+	for _, cb := range cbs {
+		// copy so subscribers cannot mess with each other's data.
+		cb(append([]byte(nil), data...))
+	}
+
+	return nil
+}
+
+// Sub subscribes the given callback function to the interested topic.
+func (ps *PubSub) Sub(topic string, cb func(data []byte)) (unsub func() error, err error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.topics == nil {
+		ps.topics = map[string]map[uint64]func(data []byte){}
+	}
+
+	if ps.topics[topic] == nil {
+		ps.topics[topic] = map[uint64]func(data []byte){}
+	}
+
+	ps.nextID++
+	id := ps.nextID
+	ps.topics[topic][id] = cb
+
+	return func() error {
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+
+		delete(ps.topics[topic], id)
+		if len(ps.topics[topic]) == 0 {
+			delete(ps.topics, topic)
+		}
+		return nil
+	}, nil
+}
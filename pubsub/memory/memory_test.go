@@ -0,0 +1,11 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/nakamauwu/nakama/pubsub/tests"
+)
+
+func TestPubSub(t *testing.T) {
+	tests.RunPubSubTests(t, &PubSub{})
+}
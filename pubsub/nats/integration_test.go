@@ -0,0 +1,66 @@
+package nats
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ory/dockertest/v3"
+)
+
+var testConn *nats.Conn
+
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		fmt.Printf("could not create docker pool: %v\n", err)
+		return 1
+	}
+
+	cleanup, err := setupTestNATS(pool)
+	if err != nil {
+		fmt.Printf("could not setup test nats: %v\n", err)
+		return 1
+	}
+
+	defer func() {
+		if err := cleanup(); err != nil {
+			fmt.Printf("could not cleanup nats container: %v\n", err)
+		}
+	}()
+
+	return m.Run()
+}
+
+func setupTestNATS(pool *dockertest.Pool) (func() error, error) {
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "nats",
+		Tag:        "latest",
+		Cmd:        []string{"-js"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create nats resource: %w", err)
+	}
+
+	err = pool.Retry(func() (err error) {
+		testConn, err = nats.Connect("nats://" + resource.GetHostPort("4222/tcp"))
+		if err != nil {
+			return fmt.Errorf("could not connect to nats: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		testConn.Close()
+		return pool.Purge(resource)
+	}, nil
+}
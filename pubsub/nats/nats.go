@@ -1,18 +1,104 @@
 package nats
 
-import "github.com/nats-io/nats.go"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	defaultRetention    = time.Hour * 24
+	jetStreamPubTimeout = time.Second * 5
+	jetStreamOpTimeout  = time.Second * 10
+)
+
+// topicFamilies persisted in JetStream mode.
+// Topic "comment_<post_id>" maps to subject "comment.<post_id>"
+// in stream "COMMENT".
+var topicFamilies = []struct {
+	prefix string
+	stream string
+}{
+	{prefix: "timeline_item_", stream: "TIMELINE_ITEM"},
+	{prefix: "notification_", stream: "NOTIFICATION"},
+	{prefix: "comment_", stream: "COMMENT"},
+}
+
+// ephemeralTopicPrefixes within the persisted families
+// that only carry ephemeral messages and stay on core NATS.
+var ephemeralTopicPrefixes = []string{"comment_presence_"}
 
 // PubSub implementation using NATS server.
 type PubSub struct {
 	Conn *nats.Conn
+	// JetStream persists the timeline_item_*, notification_* and comment_*
+	// topic families in a stream each, so subscribers do not miss
+	// messages published while their NATS connection reconnects.
+	// Ephemeral messages and other topics keep using core NATS.
+	// You must call Setup when enabled.
+	JetStream bool
+	// Retention of JetStream messages. Defaults to 24 hours.
+	Retention time.Duration
+
+	js jetstream.JetStream
+}
+
+// Setup creates or updates the JetStream streams.
+// No-op unless JetStream is enabled.
+func (ps *PubSub) Setup(ctx context.Context) error {
+	if !ps.JetStream {
+		return nil
+	}
+
+	js, err := jetstream.New(ps.Conn)
+	if err != nil {
+		return fmt.Errorf("could not create jetstream context: %w", err)
+	}
+
+	retention := ps.Retention
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+
+	for _, f := range topicFamilies {
+		_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+			Name:     f.stream,
+			Subjects: []string{strings.TrimSuffix(f.prefix, "_") + ".>"},
+			MaxAge:   retention,
+			Storage:  jetstream.FileStorage,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create or update %s stream: %w", f.stream, err)
+		}
+	}
+
+	ps.js = js
+	return nil
 }
 
 // Pub publishes some data to the given topic.
 func (ps *PubSub) Pub(topic string, data []byte) error {
+	if _, subject, ok := ps.persisted(topic); ok && !isEphemeral(data) {
+		ctx, cancel := context.WithTimeout(context.Background(), jetStreamPubTimeout)
+		defer cancel()
+
+		_, err := ps.js.Publish(ctx, subject, data)
+		return err
+	}
+
 	return ps.Conn.Publish(topic, data)
 }
 
 // Sub subscribes the given callback function to the interested topic.
+// Persisted topics deliver new messages only, without gaps
+// across reconnections.
+// Replaying older messages is up to the service, which keeps
+// its own buffer of recent events per stream.
 func (ps *PubSub) Sub(topic string, cb func(data []byte)) (unsub func() error, err error) {
 	s, err := ps.Conn.Subscribe(topic, func(msg *nats.Msg) {
 		cb(msg.Data)
@@ -20,5 +106,73 @@ func (ps *PubSub) Sub(topic string, cb func(data []byte)) (unsub func() error, e
 	if err != nil {
 		return nil, err
 	}
-	return s.Unsubscribe, nil
+
+	stream, subject, ok := ps.persisted(topic)
+	if !ok {
+		return s.Unsubscribe, nil
+	}
+
+	// the core subscription above gets the ephemeral messages.
+	ctx, cancel := context.WithTimeout(context.Background(), jetStreamOpTimeout)
+	defer cancel()
+
+	c, err := ps.js.OrderedConsumer(ctx, stream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{subject},
+		DeliverPolicy:  jetstream.DeliverNewPolicy,
+	})
+	if err != nil {
+		_ = s.Unsubscribe()
+		return nil, err
+	}
+
+	cc, err := c.Consume(func(msg jetstream.Msg) {
+		cb(msg.Data())
+	})
+	if err != nil {
+		_ = s.Unsubscribe()
+		return nil, err
+	}
+
+	return func() error {
+		cc.Stop()
+		return s.Unsubscribe()
+	}, nil
+}
+
+// isEphemeral reports whether data is a wire envelope flagged as ephemeral;
+// like comment presence, which is never worth persisting.
+func isEphemeral(data []byte) bool {
+	var msg struct {
+		Ephemeral bool `json:"ephemeral"`
+	}
+	return json.Unmarshal(data, &msg) == nil && msg.Ephemeral
+}
+
+// persisted returns the stream and subject of the given topic
+// when JetStream is enabled and the topic belongs to a family.
+func (ps *PubSub) persisted(topic string) (stream, subject string, ok bool) {
+	if ps.js == nil {
+		return "", "", false
+	}
+
+	return jetStreamSubject(topic)
+}
+
+func jetStreamSubject(topic string) (stream, subject string, ok bool) {
+	for _, prefix := range ephemeralTopicPrefixes {
+		if strings.HasPrefix(topic, prefix) {
+			return "", "", false
+		}
+	}
+
+	for _, f := range topicFamilies {
+		rest, found := strings.CutPrefix(topic, f.prefix)
+		if !found || rest == "" || strings.ContainsAny(rest, ". >") || (rest != "*" && strings.Contains(rest, "*")) {
+			continue
+		}
+
+		return f.stream, strings.TrimSuffix(f.prefix, "_") + "." + rest, true
+	}
+
+	return "", "", false
 }
@@ -0,0 +1,84 @@
+package nats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nakamauwu/nakama/pubsub/tests"
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestPubSub(t *testing.T) {
+	tests.RunPubSubTests(t, &PubSub{Conn: testConn})
+}
+
+func TestPubSub_JetStream(t *testing.T) {
+	ps := &PubSub{Conn: testConn, JetStream: true, Retention: time.Hour}
+	if err := ps.Setup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests.RunPubSubTests(t, ps)
+
+	t.Run("ephemeral_not_persisted", func(t *testing.T) {
+		topic := "comment_ephemeral_not_persisted"
+		ch := make(chan string, 10)
+		unsub, err := ps.Sub(topic, func(data []byte) {
+			ch <- string(data)
+		})
+		testutil.WantEq(t, nil, err, "sub error")
+
+		t.Cleanup(func() { _ = unsub() })
+
+		stream, err := ps.js.Stream(context.Background(), "COMMENT")
+		testutil.WantEq(t, nil, err, "stream error")
+
+		before, err := stream.Info(context.Background())
+		testutil.WantEq(t, nil, err, "stream info error")
+
+		data := `{"type":"comment_presence","ephemeral":true}`
+		err = ps.Pub(topic, []byte(data))
+		testutil.WantEq(t, nil, err, "pub error")
+		testutil.WantEq(t, data, receive(t, ch), "data")
+
+		after, err := stream.Info(context.Background())
+		testutil.WantEq(t, nil, err, "stream info error")
+		testutil.WantEq(t, before.State.Msgs, after.State.Msgs, "persisted messages")
+	})
+}
+
+func Test_jetStreamSubject(t *testing.T) {
+	tt := []struct {
+		topic   string
+		stream  string
+		subject string
+		ok      bool
+	}{
+		{topic: "timeline_item_24ca6ce6-b3e9-4276-a99a-45c77115cc9f", stream: "TIMELINE_ITEM", subject: "timeline_item.24ca6ce6-b3e9-4276-a99a-45c77115cc9f", ok: true},
+		{topic: "notification_24ca6ce6-b3e9-4276-a99a-45c77115cc9f", stream: "NOTIFICATION", subject: "notification.24ca6ce6-b3e9-4276-a99a-45c77115cc9f", ok: true},
+		{topic: "comment_*", stream: "COMMENT", subject: "comment.*", ok: true},
+		{topic: "comment_", ok: false},
+		{topic: "comment_presence_24ca6ce6-b3e9-4276-a99a-45c77115cc9f", ok: false},
+		{topic: "comment_a.b", ok: false},
+		{topic: "posts", ok: false},
+	}
+	for _, tc := range tt {
+		stream, subject, ok := jetStreamSubject(tc.topic)
+		testutil.WantEq(t, tc.stream, stream, tc.topic+" stream")
+		testutil.WantEq(t, tc.subject, subject, tc.topic+" subject")
+		testutil.WantEq(t, tc.ok, ok, tc.topic+" ok")
+	}
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for message")
+		return ""
+	}
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/ory/dockertest/v3"
+)
+
+var (
+	testDB  *sql.DB
+	testDSN string
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		fmt.Printf("could not create docker pool: %v\n", err)
+		return 1
+	}
+
+	cleanup, err := setupTestPostgres(pool)
+	if err != nil {
+		fmt.Printf("could not setup test postgres: %v\n", err)
+		return 1
+	}
+
+	defer func() {
+		if err := cleanup(); err != nil {
+			fmt.Printf("could not cleanup postgres container: %v\n", err)
+		}
+	}()
+
+	return m.Run()
+}
+
+func setupTestPostgres(pool *dockertest.Pool) (func() error, error) {
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "postgres",
+		Tag:        "latest",
+		Env:        []string{"POSTGRES_PASSWORD=postgres"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create postgres resource: %w", err)
+	}
+
+	err = pool.Retry(func() (err error) {
+		testDSN = "postgresql://postgres:postgres@" + resource.GetHostPort("5432/tcp") + "/postgres?sslmode=disable"
+		testDB, err = sql.Open("postgres", testDSN)
+		if err != nil {
+			return fmt.Errorf("could not open db: %w", err)
+		}
+
+		if err = testDB.Ping(); err != nil {
+			return fmt.Errorf("could not ping db: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return pool.Purge(resource)
+	}, nil
+}
@@ -0,0 +1,235 @@
+// Package postgres provides a pubsub.PubSub using PostgreSQL LISTEN/NOTIFY.
+// CockroachDB does not support LISTEN/NOTIFY; point it to a PostgreSQL database.
+package postgres
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/lib/pq"
+)
+
+const (
+	// maxPayloadSize is the NOTIFY payload limit (8000 bytes) minus some room.
+	// Bigger messages are stored in the spillover table and only its ID is notified.
+	maxPayloadSize = 7900
+	// spilloverTTL is how long spilled payloads are kept for subscribers to read.
+	spilloverTTL = time.Minute * 5
+
+	maxChannelLength     = 63
+	minReconnectInterval = time.Second * 10
+	maxReconnectInterval = time.Minute
+
+	inlinePrefix    = "d:"
+	spilloverPrefix = "s:"
+)
+
+// Schema creates the table used for payloads above the NOTIFY limit.
+const Schema = `
+CREATE TABLE IF NOT EXISTS pubsub_spillover (
+	id BIGSERIAL NOT NULL PRIMARY KEY,
+	data BYTEA NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS pubsub_spillover_created_at ON pubsub_spillover (created_at);
+`
+
+// PubSub implementation using PostgreSQL LISTEN/NOTIFY.
+// You must call Setup before using it.
+type PubSub struct {
+	DB *sql.DB
+	// DataSourceName of the same database.
+	// Listening needs a dedicated connection outside of DB pool.
+	DataSourceName string
+	// Logger for messages that could not be dispatched
+	// and failed spillover cleanups. Optional.
+	Logger log.Logger
+
+	listener *pq.Listener
+	// listenMu serializes Listen and Unlisten calls.
+	// dispatch never takes it: pq reads their replies from the same connection
+	// that fills listener.Notify, so dispatch must keep draining meanwhile.
+	listenMu sync.Mutex
+	mu       sync.RWMutex
+	channels map[string]map[uint64]func(data []byte)
+	nextID   uint64
+}
+
+// Setup creates the spillover table and starts listening.
+func (ps *PubSub) Setup(ctx context.Context) error {
+	if _, err := ps.DB.ExecContext(ctx, Schema); err != nil {
+		return fmt.Errorf("could not create pubsub spillover table: %w", err)
+	}
+
+	ps.channels = map[string]map[uint64]func(data []byte){}
+	ps.listener = pq.NewListener(ps.DataSourceName, minReconnectInterval, maxReconnectInterval, nil)
+	if err := ps.listener.Ping(); err != nil {
+		_ = ps.listener.Close()
+		return fmt.Errorf("could not ping pubsub listener: %w", err)
+	}
+
+	go ps.dispatch()
+
+	return nil
+}
+
+// Close stops listening.
+func (ps *PubSub) Close() error {
+	return ps.listener.Close()
+}
+
+// Pub publishes some data to the given topic.
+func (ps *PubSub) Pub(topic string, data []byte) error {
+	ctx := context.Background()
+	payload := inlinePrefix + base64.StdEncoding.EncodeToString(data)
+	if len(payload) > maxPayloadSize {
+		var id int64
+		query := "INSERT INTO pubsub_spillover (data) VALUES ($1) RETURNING id"
+		if err := ps.DB.QueryRowContext(ctx, query, data).Scan(&id); err != nil {
+			return fmt.Errorf("could not insert pubsub spillover: %w", err)
+		}
+
+		payload = fmt.Sprintf("%s%d", spilloverPrefix, id)
+
+		query = "DELETE FROM pubsub_spillover WHERE created_at < $1"
+		// pruning is best effort; it must not keep the message from going out.
+		if _, err := ps.DB.ExecContext(ctx, query, time.Now().Add(-spilloverTTL)); err != nil && ps.Logger != nil {
+			_ = ps.Logger.Log("error", fmt.Errorf("could not delete expired pubsub spillover: %w", err))
+		}
+	}
+
+	_, err := ps.DB.ExecContext(ctx, "SELECT pg_notify($1, $2)", channelName(topic), payload)
+	if err != nil {
+		return fmt.Errorf("could not notify: %w", err)
+	}
+
+	return nil
+}
+
+// Sub subscribes the given callback function to the interested topic.
+// Callbacks are called sequentially in publishing order.
+func (ps *PubSub) Sub(topic string, cb func(data []byte)) (unsub func() error, err error) {
+	channel := channelName(topic)
+
+	ps.listenMu.Lock()
+	defer ps.listenMu.Unlock()
+
+	ps.mu.Lock()
+	_, listening := ps.channels[channel]
+	if !listening {
+		ps.channels[channel] = map[uint64]func(data []byte){}
+	}
+
+	ps.nextID++
+	id := ps.nextID
+	ps.channels[channel][id] = cb
+	ps.mu.Unlock()
+
+	if !listening {
+		err := ps.listener.Listen(channel)
+		if err != nil && !errors.Is(err, pq.ErrChannelAlreadyOpen) {
+			ps.mu.Lock()
+			delete(ps.channels, channel)
+			ps.mu.Unlock()
+
+			return nil, fmt.Errorf("could not listen: %w", err)
+		}
+	}
+
+	return func() error {
+		ps.listenMu.Lock()
+		defer ps.listenMu.Unlock()
+
+		ps.mu.Lock()
+		subs, ok := ps.channels[channel]
+		if !ok {
+			ps.mu.Unlock()
+			return nil
+		}
+
+		delete(subs, id)
+		if len(subs) != 0 {
+			ps.mu.Unlock()
+			return nil
+		}
+
+		delete(ps.channels, channel)
+		ps.mu.Unlock()
+
+		err := ps.listener.Unlisten(channel)
+		if err != nil && !errors.Is(err, pq.ErrChannelNotOpen) {
+			return fmt.Errorf("could not unlisten: %w", err)
+		}
+
+		return nil
+	}, nil
+}
+
+func (ps *PubSub) dispatch() {
+	for n := range ps.listener.Notify {
+		// nil notification after reconnecting;
+		// messages sent in between are lost.
+		if n == nil {
+			continue
+		}
+
+		data, err := ps.payload(n.Extra)
+		if err != nil {
+			if ps.Logger != nil {
+				_ = ps.Logger.Log("error", fmt.Errorf("could not read pubsub payload: %w", err), "channel", n.Channel)
+			}
+			continue
+		}
+
+		ps.mu.RLock()
+		cbs := make([]func(data []byte), 0, len(ps.channels[n.Channel]))
+		for _, cb := range ps.channels[n.Channel] {
+			cbs = append(cbs, cb)
+		}
+		ps.mu.RUnlock()
+
+		for _, cb := range cbs {
+			cb(append([]byte(nil), data...))
+		}
+	}
+}
+
+func (ps *PubSub) payload(s string) ([]byte, error) {
+	if strings.HasPrefix(s, inlinePrefix) {
+		return base64.StdEncoding.DecodeString(strings.TrimPrefix(s, inlinePrefix))
+	}
+
+	if strings.HasPrefix(s, spilloverPrefix) {
+		var data []byte
+		query := "SELECT data FROM pubsub_spillover WHERE id = $1"
+		err := ps.DB.QueryRow(query, strings.TrimPrefix(s, spilloverPrefix)).Scan(&data)
+		if err != nil {
+			return nil, fmt.Errorf("could not select pubsub spillover: %w", err)
+		}
+
+		return data, nil
+	}
+
+	return nil, errors.New("unknown pubsub payload")
+}
+
+// channelName maps a topic to a channel name that fits
+// PostgreSQL identifiers length limit.
+func channelName(topic string) string {
+	if len(topic) <= maxChannelLength {
+		return topic
+	}
+
+	sum := sha1.Sum([]byte(topic))
+	return "pubsub_" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nakamauwu/nakama/pubsub/tests"
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestPubSub(t *testing.T) {
+	ps := &PubSub{DB: testDB, DataSourceName: testDSN}
+	if err := ps.Setup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = ps.Close() })
+
+	tests.RunPubSubTests(t, ps)
+
+	t.Run("spillover", func(t *testing.T) {
+		data := bytes.Repeat([]byte("a"), maxPayloadSize*2)
+		ch := make(chan []byte, 1)
+		unsub, err := ps.Sub("spillover", func(data []byte) { ch <- data })
+		testutil.WantEq(t, nil, err, "sub error")
+
+		t.Cleanup(func() { _ = unsub() })
+
+		err = ps.Pub("spillover", data)
+		testutil.WantEq(t, nil, err, "pub error")
+
+		select {
+		case got := <-ch:
+			testutil.WantEq(t, data, got, "data")
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for spilled over message")
+		}
+	})
+}
+
+func Test_channelName(t *testing.T) {
+	testutil.WantEq(t, "notification_24ca6ce6-b3e9-4276-a99a-45c77115cc9f", channelName("notification_24ca6ce6-b3e9-4276-a99a-45c77115cc9f"), "short topic")
+
+	long := channelName(strings.Repeat("a", 100))
+	testutil.WantEq(t, true, len(long) <= maxChannelLength, "long topic fits")
+	testutil.WantEq(t, long, channelName(strings.Repeat("a", 100)), "stable")
+}
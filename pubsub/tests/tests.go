@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nakamauwu/nakama/pubsub"
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+// deliveryTimeout to wait for asynchronous implementations.
+const deliveryTimeout = time.Second * 5
+
+// RunPubSubTests checks the given implementation conforms to pubsub.PubSub.
+// Topics are prefixed with the test name so the same server can be reused.
+func RunPubSubTests(t *testing.T, ps pubsub.PubSub) {
+	topic := func(name string) string {
+		return fmt.Sprintf("test_%d_%s", time.Now().UnixNano(), name)
+	}
+
+	t.Run("fan_out", func(t *testing.T) {
+		topic := topic("fan_out")
+		a := subscribe(t, ps, topic)
+		b := subscribe(t, ps, topic)
+
+		err := ps.Pub(topic, []byte("hello"))
+		testutil.WantEq(t, nil, err, "pub error")
+
+		testutil.WantEq(t, "hello", receive(t, a), "first subscriber data")
+		testutil.WantEq(t, "hello", receive(t, b), "second subscriber data")
+	})
+
+	t.Run("topic_isolation", func(t *testing.T) {
+		a := subscribe(t, ps, topic("a"))
+		other := topic("b")
+		b := subscribe(t, ps, other)
+
+		err := ps.Pub(other, []byte("hello"))
+		testutil.WantEq(t, nil, err, "pub error")
+
+		testutil.WantEq(t, "hello", receive(t, b), "subscribed topic data")
+		wantNothing(t, a)
+	})
+
+	t.Run("order", func(t *testing.T) {
+		topic := topic("order")
+		a := subscribe(t, ps, topic)
+
+		for i := 0; i < 10; i++ {
+			err := ps.Pub(topic, []byte(fmt.Sprint(i)))
+			testutil.WantEq(t, nil, err, "pub error")
+		}
+
+		for i := 0; i < 10; i++ {
+			testutil.WantEq(t, fmt.Sprint(i), receive(t, a), "data")
+		}
+	})
+
+	t.Run("unsub", func(t *testing.T) {
+		topic := topic("unsub")
+		ch := make(chan string, 1)
+		unsub, err := ps.Sub(topic, func(data []byte) { ch <- string(data) })
+		testutil.WantEq(t, nil, err, "sub error")
+
+		err = unsub()
+		testutil.WantEq(t, nil, err, "unsub error")
+
+		err = ps.Pub(topic, []byte("hello"))
+		testutil.WantEq(t, nil, err, "pub error")
+
+		wantNothing(t, ch)
+	})
+
+	t.Run("no_subscribers", func(t *testing.T) {
+		err := ps.Pub(topic("nobody"), []byte("hello"))
+		testutil.WantEq(t, nil, err, "pub error")
+	})
+}
+
+func subscribe(t *testing.T, ps pubsub.PubSub, topic string) <-chan string {
+	t.Helper()
+
+	ch := make(chan string, 100)
+	unsub, err := ps.Sub(topic, func(data []byte) {
+		ch <- string(data)
+	})
+	if err != nil {
+		t.Fatalf("could not subscribe to %q: %v", topic, err)
+	}
+
+	t.Cleanup(func() { _ = unsub() })
+
+	return ch
+}
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(deliveryTimeout):
+		t.Fatal("timed out waiting for message")
+		return ""
+	}
+}
+
+func wantNothing(t *testing.T, ch <-chan string) {
+	t.Helper()
+
+	select {
+	case s := <-ch:
+		t.Fatalf("unexpected message %q", s)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
@@ -0,0 +1,52 @@
+package nakama
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backoff is the exponential delay before the given retry attempt
+// starting at base and capped at max.
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	d := base << attempt
+	if d <= 0 || d > max {
+		return max
+	}
+	return d
+}
+
+// retryDelay is the backoff delay before the given retry attempt
+// honoring the Retry-After requested by the remote server up to max.
+func retryDelay(base, max time.Duration, attempt int, retryAfter time.Duration) time.Duration {
+	delay := backoff(base, max, attempt)
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// parseRetryAfter header value; either delay seconds or an HTTP date.
+func parseRetryAfter(s string, now time.Time) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(s); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(s); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+}
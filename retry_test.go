@@ -0,0 +1,36 @@
+package nakama
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_backoff(t *testing.T) {
+	const base, max = time.Second, time.Minute
+	testutil.WantEq(t, base, backoff(base, max, 0), "first attempt")
+	testutil.WantEq(t, base*4, backoff(base, max, 2), "third attempt")
+	testutil.WantEq(t, max, backoff(base, max, 20), "capped")
+	testutil.WantEq(t, max, backoff(base, max, 100), "overflow")
+}
+
+func Test_retryDelay(t *testing.T) {
+	const base, max = time.Second, time.Minute
+	testutil.WantEq(t, base, retryDelay(base, max, 0, 0), "no retry after")
+	testutil.WantEq(t, time.Second*30, retryDelay(base, max, 0, time.Second*30), "retry after")
+	testutil.WantEq(t, base*4, retryDelay(base, max, 2, time.Second), "backoff over retry after")
+	testutil.WantEq(t, max, retryDelay(base, max, 0, time.Hour*24), "capped retry after")
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	testutil.WantEq(t, time.Duration(0), parseRetryAfter("", now), "empty")
+	testutil.WantEq(t, time.Second*120, parseRetryAfter("120", now), "seconds")
+	testutil.WantEq(t, time.Duration(0), parseRetryAfter("-1", now), "negative seconds")
+	testutil.WantEq(t, time.Minute, parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now), "http date")
+	testutil.WantEq(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now), "past http date")
+	testutil.WantEq(t, time.Duration(0), parseRetryAfter("nope", now), "invalid")
+}
@@ -20,6 +20,18 @@ CREATE TABLE IF NOT EXISTS users (
     created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS email_digest VARCHAR;
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS email_digest_sent_at TIMESTAMPTZ;
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS timezone VARCHAR NOT NULL DEFAULT 'UTC';
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS dms_from_followees_only BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS avatar_blurhash VARCHAR;
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS cover_blurhash VARCHAR;
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS cover_width INT;
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS cover_height INT;
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS storage_bytes INT NOT NULL DEFAULT 0;
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS avatar_bytes INT;
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS cover_bytes INT;
+
 CREATE TABLE IF NOT EXISTS email_verification_codes (
     email VARCHAR NOT NULL,
     code UUID NOT NULL DEFAULT gen_random_uuid(),
@@ -35,6 +47,13 @@ CREATE TABLE IF NOT EXISTS follows (
     PRIMARY KEY (follower_id, followee_id)
 );
 
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+
 CREATE TABLE IF NOT EXISTS posts (
     id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
     user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
@@ -49,6 +68,33 @@ CREATE TABLE IF NOT EXISTS posts (
     INDEX sorted_posts (created_at DESC, id)
 );
 
+CREATE TABLE IF NOT EXISTS media_items (
+    name VARCHAR NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    content_type VARCHAR,
+    width INT,
+    height INT,
+    blurhash VARCHAR,
+    alt_text VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS media_objects (
+    name VARCHAR NOT NULL PRIMARY KEY,
+    ref_count INT NOT NULL DEFAULT 0 CHECK (ref_count >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE IF EXISTS media_objects ADD COLUMN IF NOT EXISTS size INT NOT NULL DEFAULT 0;
+ALTER TABLE IF EXISTS media_objects ADD COLUMN IF NOT EXISTS deleting_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS post_media_alt_texts (
+    post_id UUID NOT NULL REFERENCES posts ON DELETE CASCADE,
+    name VARCHAR NOT NULL,
+    alt_text VARCHAR,
+    PRIMARY KEY (post_id, name)
+);
+
 CREATE TABLE IF NOT EXISTS post_reactions (
     user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
     post_id UUID NOT NULL REFERENCES posts ON DELETE CASCADE,
@@ -115,6 +161,86 @@ CREATE TABLE IF NOT EXISTS user_web_push_subscriptions (
     UNIQUE INDEX unique_user_web_push_subscriptions (user_id, (sub->>'endpoint'::TEXT))
 );
 
+ALTER TABLE IF EXISTS user_web_push_subscriptions ADD COLUMN IF NOT EXISTS device_label VARCHAR;
+ALTER TABLE IF EXISTS user_web_push_subscriptions ADD COLUMN IF NOT EXISTS user_agent VARCHAR;
+ALTER TABLE IF EXISTS user_web_push_subscriptions ADD COLUMN IF NOT EXISTS failures INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users ON DELETE CASCADE,
+    url VARCHAR NOT NULL,
+    events VARCHAR[] NOT NULL,
+    secret VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    INDEX webhooks_user (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks ON DELETE CASCADE,
+    event VARCHAR NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR NOT NULL DEFAULT 'pending' CHECK (status = 'pending' OR status = 'succeeded' OR status = 'failed'),
+    attempts INT NOT NULL DEFAULT 0,
+    status_code INT,
+    error VARCHAR,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    INDEX sorted_webhook_deliveries (webhook_id, created_at DESC, id),
+    INDEX due_webhook_deliveries (next_attempt_at) WHERE status = 'pending'
+);
+
+CREATE TABLE IF NOT EXISTS conversations (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    direct_key VARCHAR UNIQUE,
+    last_message_id UUID,
+    last_message_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS conversation_participants (
+    conversation_id UUID NOT NULL REFERENCES conversations ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    unread_count INT NOT NULL DEFAULT 0 CHECK (unread_count >= 0),
+    last_read_at TIMESTAMPTZ,
+    last_message_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (conversation_id, user_id),
+    INDEX sorted_user_conversations (user_id, last_message_at DESC, conversation_id)
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    conversation_id UUID NOT NULL REFERENCES conversations ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    content VARCHAR NOT NULL,
+    media VARCHAR[],
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    INDEX sorted_messages (conversation_id, created_at DESC, id)
+);
+
+CREATE TABLE IF NOT EXISTS uploads (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    purpose VARCHAR NOT NULL,
+    content_type VARCHAR NOT NULL,
+    size INT NOT NULL,
+    media VARCHAR,
+    expires_at TIMESTAMPTZ NOT NULL,
+    finalized_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    INDEX uploads_expiry (expires_at)
+);
+
+CREATE TABLE IF NOT EXISTS upload_events (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+    size INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    INDEX sorted_upload_events (user_id, created_at DESC)
+);
+
 -- INSERT INTO users (id, email, username) VALUES
 --     ('24ca6ce6-b3e9-4276-a99a-45c77115cc9f', 'shinji@example.org', 'shinji'),
 --     ('93dfcef9-0b45-46ae-933c-ea52fbf80edb', 'rei@example.org', 'rei');
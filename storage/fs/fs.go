@@ -3,12 +3,16 @@ package fs
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/nakamauwu/nakama/storage"
@@ -26,29 +30,58 @@ func (s *Store) init() {
 	}
 }
 
-func (s *Store) Store(_ context.Context, bucket, name string, data []byte, opts ...func(*storage.StoreOpts)) error {
-	s.once.Do(s.init)
+func (s *Store) Store(ctx context.Context, bucket, name string, data []byte, opts ...func(*storage.StoreOpts)) error {
+	return s.Put(ctx, bucket, name, bytes.NewReader(data), int64(len(data)), opts...)
+}
 
-	f, err := os.Create(filepath.Join(s.Root, bucket, name))
-	if os.IsNotExist(err) {
-		if err := os.MkdirAll(filepath.Join(s.Root, bucket), fs.ModePerm); err != nil {
-			return fmt.Errorf("could not create bucket dir: %w", err)
-		}
+// Put writes to a temporary file first and renames it once complete,
+// so readers never see partial files.
+// The SHA-256 of the contents gets stored next to it to use as ETag.
+func (s *Store) Put(_ context.Context, bucket, name string, r io.Reader, size int64, opts ...func(*storage.StoreOpts)) error {
+	s.once.Do(s.init)
 
-		f, err = os.Create(filepath.Join(s.Root, bucket, name))
+	dir := filepath.Join(s.Root, bucket)
+	if err := os.MkdirAll(dir, fs.ModePerm); err != nil {
+		return fmt.Errorf("could not create bucket dir: %w", err)
 	}
 
+	f, err := os.CreateTemp(dir, "."+name+".*.tmp")
 	if err != nil {
-		return fmt.Errorf("could not create file: %w", err)
+		return fmt.Errorf("could not create temp file: %w", err)
 	}
 
-	defer f.Close()
+	tmpName := f.Name()
+	defer os.Remove(tmpName)
 
-	_, err = io.Copy(f, bytes.NewReader(data))
+	h := sha256.New()
+	w := io.MultiWriter(f, h)
+	if size < 0 {
+		_, err = io.Copy(w, r)
+	} else {
+		_, err = io.CopyN(w, r, size)
+	}
 	if err != nil {
+		f.Close()
 		return fmt.Errorf("could not copy data to file: %w", err)
 	}
 
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not close temp file: %w", err)
+	}
+
+	if err := os.Chmod(tmpName, 0o644); err != nil {
+		return fmt.Errorf("could not chmod temp file: %w", err)
+	}
+
+	// the etag goes first so it is there once the file is.
+	if err := writeETag(dir, name, hex.EncodeToString(h.Sum(nil))); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpName, filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("could not rename temp file: %w", err)
+	}
+
 	return nil
 }
 
@@ -58,6 +91,10 @@ func (s *Store) Open(_ context.Context, bucket, name string) (*storage.File, err
 	filename := filepath.Join(s.Root, bucket, name)
 
 	stat, err := os.Stat(filename)
+	if os.IsNotExist(err) {
+		return nil, storage.ErrNotFound
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("could not stat file: %w", err)
 	}
@@ -71,33 +108,154 @@ func (s *Store) Open(_ context.Context, bucket, name string) (*storage.File, err
 	firstBytes := make([]byte, 512)
 	read, err := f.Read(firstBytes)
 	if err != nil && err != io.EOF {
+		_ = f.Close()
 		return nil, fmt.Errorf("could not read first 512 bytes from file: %w", err)
 	}
 
 	_, err = f.Seek(0, io.SeekStart)
 	if err != nil {
+		_ = f.Close()
 		return nil, fmt.Errorf("could not reset file reader after sniffing its content-type: %w", err)
 	}
 
 	// remove fill-up zero values which cause a wrong content type detection.
 	firstBytes = firstBytes[:read]
 
+	etag, err := s.etag(f, bucket, name)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
 	return &storage.File{
 		ReadSeekCloser: f,
 		Size:           stat.Size(),
 		ContentType:    http.DetectContentType(firstBytes),
-		ETag:           "",
+		ETag:           etag,
 		LastModified:   stat.ModTime(),
 	}, nil
 }
 
+// etag reads the stored content hash of the file,
+// computing and storing it for files stored before they had one.
+func (s *Store) etag(f *os.File, bucket, name string) (string, error) {
+	dir := filepath.Join(s.Root, bucket)
+	b, err := os.ReadFile(filepath.Join(dir, etagFileName(name)))
+	if err == nil {
+		return string(b), nil
+	}
+
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("could not read etag file: %w", err)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("could not hash file: %w", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("could not reset file reader after hashing it: %w", err)
+	}
+
+	// best effort; it gets computed again next time otherwise.
+	etag := hex.EncodeToString(h.Sum(nil))
+	_ = writeETag(dir, name, etag)
+
+	return etag, nil
+}
+
 func (s *Store) Delete(_ context.Context, bucket, name string) error {
 	s.once.Do(s.init)
 
 	err := os.Remove(filepath.Join(s.Root, bucket, name))
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("could not remove file: %w", err)
 	}
 
+	err = os.Remove(filepath.Join(s.Root, bucket, etagFileName(name)))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove etag file: %w", err)
+	}
+
+	return nil
+}
+
+// List skips the temporary files of uploads in progress and the etag files.
+func (s *Store) List(_ context.Context, bucket, prefix string) ([]storage.Object, error) {
+	s.once.Do(s.init)
+
+	entries, err := os.ReadDir(filepath.Join(s.Root, bucket))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read bucket dir: %w", err)
+	}
+
+	var out []storage.Object
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || isHiddenFile(name) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if os.IsNotExist(err) {
+			// deleted meanwhile.
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not stat file: %w", err)
+		}
+
+		out = append(out, storage.Object{
+			Name:         name,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	return out, nil
+}
+
+// isHiddenFile reports whether name is a temporary or etag file.
+func isHiddenFile(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+func etagFileName(name string) string {
+	return "." + name + ".sha256"
+}
+
+// writeETag writes the etag file atomically
+// since it may be read while being replaced.
+func writeETag(dir, name, etag string) error {
+	f, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not create temp etag file: %w", err)
+	}
+
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(etag); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write etag file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not close temp etag file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), filepath.Join(dir, etagFileName(name))); err != nil {
+		return fmt.Errorf("could not rename temp etag file: %w", err)
+	}
+
 	return nil
 }
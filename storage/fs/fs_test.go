@@ -1,9 +1,13 @@
 package fs
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 
 	"github.com/nakamauwu/nakama/storage/tests"
+	"github.com/nakamauwu/nakama/testutil"
 )
 
 func TestStore(t *testing.T) {
@@ -11,3 +15,31 @@ func TestStore(t *testing.T) {
 		Root: t.TempDir(),
 	}, "")
 }
+
+func TestStore_etag(t *testing.T) {
+	ctx := context.Background()
+	store := &Store{Root: t.TempDir()}
+	data := []byte("hello")
+	sum := sha256.Sum256(data)
+
+	err := store.Store(ctx, "bucket", "hello.txt", data)
+	testutil.WantEq(t, nil, err, "store error")
+
+	f, err := store.Open(ctx, "bucket", "hello.txt")
+	testutil.WantEq(t, nil, err, "open error")
+
+	defer f.Close()
+
+	testutil.WantEq(t, hex.EncodeToString(sum[:]), f.ETag, "etag")
+
+	objects, err := store.List(ctx, "bucket", "")
+	testutil.WantEq(t, nil, err, "list error")
+	testutil.WantEq(t, 1, len(objects), "etag files not listed")
+
+	err = store.Delete(ctx, "bucket", "hello.txt")
+	testutil.WantEq(t, nil, err, "delete error")
+
+	objects, err = store.List(ctx, "bucket", "")
+	testutil.WantEq(t, nil, err, "list error")
+	testutil.WantEq(t, 0, len(objects), "objects after delete")
+}
@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Presigner is implemented by stores clients can upload to directly.
+type Presigner interface {
+	// PresignPut returns a URL to upload an object of the given size and content type
+	// with a PUT request, along with the headers the request must include.
+	PresignPut(ctx context.Context, bucket, name string, size int64, contentType string, expiry time.Duration) (url string, header http.Header, err error)
+}
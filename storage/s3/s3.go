@@ -4,6 +4,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -11,6 +15,10 @@ import (
 	"github.com/nakamauwu/nakama/storage"
 )
 
+const defaultPartSize = 16 << 20 // 16MiB
+
+var _ storage.Presigner = (*Store)(nil)
+
 // Store must call Setup.
 type Store struct {
 	client *minio.Client
@@ -21,6 +29,10 @@ type Store struct {
 	AccessKey  string
 	SecretKey  string
 	BucketList []string
+	// PartSize of multipart uploads. Objects larger than it,
+	// or of unknown size, are uploaded in parts.
+	// Defaults to 16MiB; the minimum allowed by S3 is 5MiB.
+	PartSize uint64
 }
 
 func (s *Store) Setup(ctx context.Context) error {
@@ -59,17 +71,26 @@ func (s *Store) Setup(ctx context.Context) error {
 
 // Store a file.
 func (s *Store) Store(ctx context.Context, bucket, name string, data []byte, opts ...func(*storage.StoreOpts)) error {
+	return s.Put(ctx, bucket, name, bytes.NewReader(data), int64(len(data)), opts...)
+}
+
+// Put streams a file.
+// Large objects are uploaded in parts of PartSize.
+func (s *Store) Put(ctx context.Context, bucket, name string, r io.Reader, size int64, opts ...func(*storage.StoreOpts)) error {
 	var options storage.StoreOpts
 	for _, o := range opts {
 		o(&options)
 	}
 
-	r := bytes.NewReader(data)
-	size := int64(len(data))
+	if size < 0 {
+		size = -1
+	}
+
 	_, err := s.client.PutObject(ctx, bucket, name, r, size, minio.PutObjectOptions{
 		ContentType:     options.ContentType,
 		ContentEncoding: options.ContentEncoding,
 		CacheControl:    options.CacheControl,
+		PartSize:        s.partSize(),
 	})
 	if err != nil {
 		return fmt.Errorf("could not put object: %w", err)
@@ -78,6 +99,31 @@ func (s *Store) Store(ctx context.Context, bucket, name string, data []byte, opt
 	return nil
 }
 
+func (s *Store) partSize() uint64 {
+	if s.PartSize == 0 {
+		return defaultPartSize
+	}
+
+	return s.PartSize
+}
+
+// PresignPut returns a URL clients can upload an object to directly.
+// Both content type and length are signed, so the upload must match them.
+func (s *Store) PresignPut(ctx context.Context, bucket, name string, size int64, contentType string, expiry time.Duration) (string, http.Header, error) {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+
+	signed := header.Clone()
+	signed.Set("Content-Length", strconv.FormatInt(size, 10))
+
+	u, err := s.client.PresignHeader(ctx, http.MethodPut, bucket, name, expiry, nil, signed)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not presign put object: %w", err)
+	}
+
+	return u.String(), header, nil
+}
+
 // Open a file.
 func (s *Store) Open(ctx context.Context, bucket, name string) (*storage.File, error) {
 	obj, err := s.client.GetObject(ctx, bucket, name, minio.GetObjectOptions{})
@@ -112,3 +158,24 @@ func (s *Store) Delete(ctx context.Context, bucket, name string) error {
 
 	return nil
 }
+
+// List objects.
+func (s *Store) List(ctx context.Context, bucket, prefix string) ([]storage.Object, error) {
+	var out []storage.Object
+	for obj := range s.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("could not list objects: %w", obj.Err)
+		}
+
+		out = append(out, storage.Object{
+			Name:         obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+		})
+	}
+
+	return out, nil
+}
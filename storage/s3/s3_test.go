@@ -20,3 +20,18 @@ func TestStore(t *testing.T) {
 	}
 	tests.RunStoreTests(t, s, testBucket)
 }
+
+func TestStore_multipart(t *testing.T) {
+	s := &Store{
+		Endpoint:   testEndpoint,
+		Region:     testRegion,
+		AccessKey:  testAccessKey,
+		SecretKey:  testSecretKey,
+		BucketList: []string{testBucket},
+		PartSize:   5 << 20,
+	}
+	if err := s.Setup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tests.RunStoreTests(t, s, testBucket)
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"errors"
+	"io"
+	"time"
 )
 
 // ErrNotFound denotes that the object does not exists.
@@ -11,6 +13,19 @@ var ErrNotFound = errors.New("not found")
 // Store interface.
 type Store interface {
 	Store(ctx context.Context, bucket, name string, data []byte, opts ...func(*StoreOpts)) (err error)
+	// Put streams size bytes from r without buffering the whole object.
+	// Pass a negative size when unknown to read r until EOF.
+	Put(ctx context.Context, bucket, name string, r io.Reader, size int64, opts ...func(*StoreOpts)) (err error)
 	Open(ctx context.Context, bucket, name string) (f *File, err error)
+	// Delete succeeds for objects that do not exist.
 	Delete(ctx context.Context, bucket, name string) (err error)
+	// List the objects of a bucket whose name starts with prefix, sorted by name.
+	List(ctx context.Context, bucket, prefix string) (objects []Object, err error)
+}
+
+// Object info.
+type Object struct {
+	Name         string
+	Size         int64
+	LastModified time.Time
 }
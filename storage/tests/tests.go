@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"io"
 	"os"
 	"path/filepath"
@@ -45,4 +47,89 @@ func RunStoreTests(t *testing.T, store storage.Store, bucket string) {
 
 	err = store.Delete(ctx, bucket, logoName)
 	testutil.WantEq(t, nil, err, "error")
+
+	t.Run("put", func(t *testing.T) {
+		err := store.Put(ctx, bucket, logoName, bytes.NewReader(logoBytes), int64(len(logoBytes)), storage.StoreWithContentType(logoContentType))
+		testutil.WantEq(t, nil, err, "error")
+
+		t.Cleanup(func() { _ = store.Delete(ctx, bucket, logoName) })
+
+		wantFile(t, store, bucket, logoName, logoBytes)
+	})
+
+	t.Run("put_unknown_size", func(t *testing.T) {
+		name := "unknown_size.bin"
+		data := randomBytes(t, 6<<20)
+
+		// hide the underlying *bytes.Reader so implementations cannot peek the size.
+		r := struct{ io.Reader }{bytes.NewReader(data)}
+		err := store.Put(ctx, bucket, name, r, -1)
+		testutil.WantEq(t, nil, err, "error")
+
+		t.Cleanup(func() { _ = store.Delete(ctx, bucket, name) })
+
+		wantFile(t, store, bucket, name, data)
+	})
+
+	t.Run("put_short_reader", func(t *testing.T) {
+		name := "short.bin"
+		data := randomBytes(t, 1024)
+
+		err := store.Put(ctx, bucket, name, bytes.NewReader(data), int64(len(data))+1)
+		testutil.WantEq(t, true, err != nil, "error")
+
+		_, err = store.Open(ctx, bucket, name)
+		testutil.WantEq(t, true, err != nil, "open error")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		names := []string{"list_b.txt", "list_a.txt", "other.txt"}
+		for _, name := range names {
+			err := store.Store(ctx, bucket, name, []byte(name))
+			testutil.WantEq(t, nil, err, "store error")
+		}
+
+		t.Cleanup(func() {
+			for _, name := range names {
+				_ = store.Delete(ctx, bucket, name)
+			}
+		})
+
+		objects, err := store.List(ctx, bucket, "list_")
+		testutil.WantEq(t, nil, err, "error")
+		testutil.WantEq(t, 2, len(objects), "objects")
+		testutil.WantEq(t, "list_a.txt", objects[0].Name, "first name")
+		testutil.WantEq(t, int64(len("list_a.txt")), objects[0].Size, "first size")
+		testutil.WantEq(t, "list_b.txt", objects[1].Name, "second name")
+		testutil.WantEq(t, false, objects[1].LastModified.IsZero(), "last modified")
+	})
+
+	t.Run("delete_missing", func(t *testing.T) {
+		err := store.Delete(ctx, bucket, "missing.txt")
+		testutil.WantEq(t, nil, err, "error")
+	})
+}
+
+func wantFile(t *testing.T, store storage.Store, bucket, name string, want []byte) {
+	t.Helper()
+
+	f, err := store.Open(context.Background(), bucket, name)
+	testutil.WantEq(t, nil, err, "open error")
+
+	defer f.Close()
+
+	got, err := io.ReadAll(f)
+	testutil.WantEq(t, nil, err, "read error")
+	testutil.WantEq(t, int64(len(want)), f.Size, "size")
+	testutil.WantEq(t, true, bytes.Equal(want, got), "bytes")
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	testutil.WantEq(t, nil, err, "rand read")
+
+	return b
 }
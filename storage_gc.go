@@ -0,0 +1,193 @@
+package nakama
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	storageGCInterval = time.Hour * 24
+	// storageGCGracePeriod keeps recently stored objects
+	// since they get stored right before the rows referencing them.
+	storageGCGracePeriod = time.Hour
+)
+
+// StorageGCResult reports a storage garbage collection run.
+// Orphans are "<bucket>/<name>".
+type StorageGCResult struct {
+	Scanned int
+	Orphans []string
+	Deleted int
+}
+
+// CollectStorageGarbage deletes the avatars, covers and media objects
+// no longer referenced by any user, post, message or pending upload.
+// With dryRun it only reports them.
+func (s *Service) CollectStorageGarbage(ctx context.Context, dryRun bool) (StorageGCResult, error) {
+	var out StorageGCResult
+	now := time.Now()
+
+	buckets := []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  AvatarsBucket,
+			query: "SELECT avatar FROM users WHERE avatar IS NOT NULL",
+		},
+		{
+			name:  CoversBucket,
+			query: "SELECT cover FROM users WHERE cover IS NOT NULL",
+		},
+		{
+			name: MediaBucket,
+			query: `
+				SELECT unnest(media) FROM posts WHERE media IS NOT NULL
+				UNION SELECT unnest(media) FROM messages WHERE media IS NOT NULL
+				UNION SELECT media FROM uploads WHERE media IS NOT NULL`,
+		},
+	}
+
+	for _, bucket := range buckets {
+		// list before loading the references so objects referenced meanwhile
+		// are either in the references or within the grace period.
+		objects, err := s.Store.List(ctx, bucket.name, "")
+		if err != nil {
+			return out, fmt.Errorf("could not list %s objects: %w", bucket.name, err)
+		}
+
+		if len(objects) == 0 {
+			continue
+		}
+
+		refs, err := s.storageReferences(ctx, bucket.query)
+		if err != nil {
+			return out, fmt.Errorf("could not load %s references: %w", bucket.name, err)
+		}
+
+		for _, obj := range objects {
+			out.Scanned++
+
+			if _, ok := refs[obj.Name]; ok || now.Sub(obj.LastModified) < storageGCGracePeriod {
+				continue
+			}
+
+			// variants are kept along with their original.
+			if _, ok := refs[imageVariantBase(obj.Name)]; ok {
+				continue
+			}
+
+			// identical media might have been stored again since listed.
+			if bucket.name == MediaBucket {
+				recent, err := s.recentlyStoredMedia(ctx, obj.Name)
+				if err != nil {
+					_ = s.Logger.Log("error", fmt.Errorf("could not check orphan media object %q: %w", obj.Name, err))
+					continue
+				}
+
+				if recent {
+					continue
+				}
+			}
+
+			out.Orphans = append(out.Orphans, bucket.name+"/"+obj.Name)
+			if dryRun {
+				continue
+			}
+
+			if bucket.name == MediaBucket {
+				deleted, err := s.deleteOrphanMedia(ctx, obj.Name)
+				if err != nil {
+					_ = s.Logger.Log("error", fmt.Errorf("could not delete orphan media object %q: %w", obj.Name, err))
+					continue
+				}
+
+				if deleted {
+					out.Deleted++
+				}
+				continue
+			}
+
+			if err := s.Store.Delete(ctx, bucket.name, obj.Name); err != nil {
+				_ = s.Logger.Log("error", fmt.Errorf("could not delete orphan %s object %q: %w", bucket.name, obj.Name, err))
+				continue
+			}
+
+			out.Deleted++
+		}
+	}
+
+	return out, nil
+}
+
+// deleteOrphanMedia claims the deletion of the named media object base
+// whatever its reference count, since counts drift when references get deleted in cascade,
+// and deletes it.
+// Media referenced meanwhile was stored within the grace period
+// so deleteClaimedMedia keeps it.
+func (s *Service) deleteOrphanMedia(ctx context.Context, name string) (bool, error) {
+	base := imageVariantBase(name)
+	query := `
+		INSERT INTO media_objects (name, deleting_at) VALUES ($1, now())
+		ON CONFLICT (name) DO UPDATE SET deleting_at = now()
+		WHERE media_objects.deleting_at IS NULL OR media_objects.deleting_at < $2`
+	res, err := s.DB.ExecContext(ctx, query, base, time.Now().Add(-storageGCGracePeriod))
+	if err != nil {
+		return false, fmt.Errorf("could not sql upsert claim media object deletion: %w", err)
+	}
+
+	// another deletion claimed it.
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil
+	}
+
+	return s.deleteClaimedMedia(ctx, base, name)
+}
+
+func (s *Service) storageReferences(ctx context.Context, query string) (map[string]struct{}, error) {
+	rows, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select references: %w", err)
+	}
+
+	defer rows.Close()
+
+	refs := map[string]struct{}{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("could not sql scan reference: %w", err)
+		}
+
+		refs[name] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate over reference rows: %w", err)
+	}
+
+	return refs, nil
+}
+
+func (s *Service) storageGCJob(ctx context.Context) {
+	ticker := time.NewTicker(storageGCInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			res, err := s.CollectStorageGarbage(ctx, false)
+			if err != nil {
+				_ = s.Logger.Log("error", fmt.Errorf("could not collect storage garbage: %w", err))
+				continue
+			}
+
+			if res.Deleted != 0 {
+				_ = s.Logger.Log("message", "deleted orphan storage objects", "count", res.Deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package nakama
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	fsstorage "github.com/nakamauwu/nakama/storage/fs"
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestService_CollectStorageGarbage(t *testing.T) {
+	if testDB == nil {
+		t.Skip("integration test")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := &Service{
+		Logger: log.NewNopLogger(),
+		DB:     testDB,
+		Store:  &fsstorage.Store{Root: root},
+	}
+
+	kept := testutil.RandStr(t, 10) + ".jpg"
+	orphan := testutil.RandStr(t, 10) + ".jpg"
+	recent := testutil.RandStr(t, 10) + ".jpg"
+
+	_, err := testDB.ExecContext(ctx, "INSERT INTO users (email, username, avatar) VALUES ($1, $2, $3)",
+		testutil.RandStr(t, 10)+"@example.org", testutil.RandStr(t, 10), kept)
+	testutil.WantEq(t, nil, err, "insert user")
+
+	old := time.Now().Add(-storageGCGracePeriod * 2)
+	for _, name := range []string{kept, orphan, recent} {
+		err := svc.Store.Store(ctx, AvatarsBucket, name, []byte(name))
+		testutil.WantEq(t, nil, err, "store")
+
+		if name != recent {
+			err = os.Chtimes(filepath.Join(root, AvatarsBucket, name), old, old)
+			testutil.WantEq(t, nil, err, "chtimes")
+		}
+	}
+
+	t.Run("dry_run", func(t *testing.T) {
+		res, err := svc.CollectStorageGarbage(ctx, true)
+		testutil.WantEq(t, nil, err, "error")
+		testutil.WantEq(t, []string{AvatarsBucket + "/" + orphan}, res.Orphans, "orphans")
+		testutil.WantEq(t, 0, res.Deleted, "deleted")
+
+		f, err := svc.Store.Open(ctx, AvatarsBucket, orphan)
+		testutil.WantEq(t, nil, err, "open orphan")
+		f.Close()
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		res, err := svc.CollectStorageGarbage(ctx, false)
+		testutil.WantEq(t, nil, err, "error")
+		testutil.WantEq(t, 1, res.Deleted, "deleted")
+
+		f, err := svc.Store.Open(ctx, AvatarsBucket, kept)
+		testutil.WantEq(t, nil, err, "open kept")
+		f.Close()
+
+		f, err = svc.Store.Open(ctx, AvatarsBucket, recent)
+		testutil.WantEq(t, nil, err, "open recent")
+		f.Close()
+	})
+}
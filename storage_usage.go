@@ -0,0 +1,205 @@
+package nakama
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// uploadRateWindow is the window upload rate limits apply to.
+const uploadRateWindow = time.Hour
+
+var (
+	// ErrStorageQuotaExceeded denotes a user storing more bytes than StorageQuota allows.
+	ErrStorageQuotaExceeded = PermissionDeniedError("storage quota exceeded")
+	// ErrUploadRateLimited denotes a user uploading more than
+	// UploadsPerHour or UploadBytesPerHour allow.
+	ErrUploadRateLimited = ResourceExhaustedError("upload rate limit exceeded")
+)
+
+// StorageUsage of a user across avatars, covers and media.
+// Zero limits mean unlimited.
+type StorageUsage struct {
+	Bytes               int64 `json:"bytes"`
+	Quota               int64 `json:"quota"`
+	UploadsLastHour     int   `json:"uploadsLastHour"`
+	UploadBytesLastHour int64 `json:"uploadBytesLastHour"`
+	UploadsPerHour      int   `json:"uploadsPerHour"`
+	UploadBytesPerHour  int64 `json:"uploadBytesPerHour"`
+}
+
+// storageUsage of the given user.
+func (s *Service) storageUsage(ctx context.Context, uid string, storageBytes int64) (StorageUsage, error) {
+	out := StorageUsage{
+		Bytes:              storageBytes,
+		Quota:              s.StorageQuota,
+		UploadsPerHour:     s.UploadsPerHour,
+		UploadBytesPerHour: s.UploadBytesPerHour,
+	}
+
+	var err error
+	out.UploadsLastHour, out.UploadBytesLastHour, err = recentUploads(ctx, s.DB, uid)
+	if err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+func recentUploads(ctx context.Context, db queryRower, uid string) (int, int64, error) {
+	var count int
+	var size int64
+	query := `
+		SELECT count(*), COALESCE(sum(size), 0)::INT FROM upload_events
+		WHERE user_id = $1 AND created_at > $2`
+	err := db.QueryRowContext(ctx, query, uid, time.Now().Add(-uploadRateWindow)).Scan(&count, &size)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not sql query select recent uploads: %w", err)
+	}
+
+	return count, size, nil
+}
+
+// checkUploadLimits fails if the given uploads of a user
+// would go over the hourly upload limits.
+func (s *Service) checkUploadLimits(ctx context.Context, db queryRower, uid string, sizes ...int64) error {
+	if len(sizes) == 0 || s.UploadsPerHour <= 0 && s.UploadBytesPerHour <= 0 {
+		return nil
+	}
+
+	count, size, err := recentUploads(ctx, db, uid)
+	if err != nil {
+		return err
+	}
+
+	count += len(sizes)
+	for _, n := range sizes {
+		size += n
+	}
+
+	if s.UploadsPerHour > 0 && count > s.UploadsPerHour ||
+		s.UploadBytesPerHour > 0 && size > s.UploadBytesPerHour {
+		return ErrUploadRateLimited
+	}
+
+	return nil
+}
+
+// recordUploads checks the hourly upload limits and records the given uploads
+// within the same transaction that persists their media,
+// so concurrent uploads conflict and failed ones are not counted.
+func (s *Service) recordUploads(ctx context.Context, tx *sql.Tx, uid string, sizes ...int64) error {
+	if err := s.checkUploadLimits(ctx, tx, uid, sizes...); err != nil {
+		return err
+	}
+
+	for _, n := range sizes {
+		query := "INSERT INTO upload_events (user_id, size) VALUES ($1, $2)"
+		if _, err := tx.ExecContext(ctx, query, uid, n); err != nil {
+			if isForeignKeyViolation(err) {
+				return ErrUserGone
+			}
+
+			return fmt.Errorf("could not sql insert upload event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// limitMedia checks the storage quota and the upload rate limits
+// before storing the given processed media files
+// so rejected files never get stored.
+// The persisting transaction enforces them again with recordUploads and chargeStorage.
+func (s *Service) limitMedia(ctx context.Context, uid string, files []mediaFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	if err := s.checkStorageQuota(ctx, uid, imageFilesSize(files)); err != nil {
+		return err
+	}
+
+	return s.checkUploadLimits(ctx, s.DB, uid, mediaFileSizes(files)...)
+}
+
+// mediaFileSizes are the sizes the upload rate limits count.
+func mediaFileSizes(files []mediaFile) []int64 {
+	sizes := make([]int64, len(files))
+	for i, f := range files {
+		sizes[i] = int64(len(f.Content))
+	}
+	return sizes
+}
+
+// checkStorageQuota fails if storing the given bytes
+// would take the user over the quota.
+// Use it to avoid storing objects that chargeStorage would reject anyway.
+func (s *Service) checkStorageQuota(ctx context.Context, uid string, size int64) error {
+	if s.StorageQuota <= 0 || size <= 0 {
+		return nil
+	}
+
+	var used int64
+	query := "SELECT storage_bytes FROM users WHERE id = $1"
+	err := s.DB.QueryRowContext(ctx, query, uid).Scan(&used)
+	if err == sql.ErrNoRows {
+		return ErrUserGone
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not sql query select user storage usage: %w", err)
+	}
+
+	if used+size > s.StorageQuota {
+		return ErrStorageQuotaExceeded
+	}
+
+	return nil
+}
+
+// chargeStorage adds the given bytes to the user storage usage
+// failing if it goes over the quota.
+// Negative sizes free storage and never fail the quota.
+func (s *Service) chargeStorage(ctx context.Context, tx *sql.Tx, uid string, size int64) error {
+	if size == 0 {
+		return nil
+	}
+
+	var used int64
+	query := "UPDATE users SET storage_bytes = greatest(storage_bytes + $1, 0) WHERE id = $2 RETURNING storage_bytes"
+	err := tx.QueryRowContext(ctx, query, size, uid).Scan(&used)
+	if err == sql.ErrNoRows {
+		return ErrUserGone
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not sql update user storage usage: %w", err)
+	}
+
+	if s.StorageQuota > 0 && size > 0 && used > s.StorageQuota {
+		return ErrStorageQuotaExceeded
+	}
+
+	return nil
+}
+
+// pruneUploadEvents deletes the upload events out of the rate limit window.
+func (s *Service) pruneUploadEvents(ctx context.Context) error {
+	query := "DELETE FROM upload_events WHERE created_at < $1"
+	if _, err := s.DB.ExecContext(ctx, query, time.Now().Add(-uploadRateWindow)); err != nil {
+		return fmt.Errorf("could not sql delete old upload events: %w", err)
+	}
+
+	return nil
+}
+
+// imageFilesSize is the total bytes of the given files and their variants.
+func imageFilesSize(files []mediaFile) int64 {
+	var size int64
+	for _, f := range files {
+		size += int64(len(f.Content)) + imageFilesSize(f.Variants)
+	}
+	return size
+}
@@ -0,0 +1,91 @@
+package nakama
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_imageFilesSize(t *testing.T) {
+	files := []mediaFile{
+		{Content: make([]byte, 10), Variants: []mediaFile{{Content: make([]byte, 3)}, {Content: make([]byte, 2)}}},
+		{Content: make([]byte, 5)},
+	}
+	testutil.WantEq(t, int64(20), imageFilesSize(files), "size")
+}
+
+func TestService_chargeStorage(t *testing.T) {
+	if testDB == nil {
+		t.Skip("integration test")
+	}
+
+	ctx := context.Background()
+	svc := &Service{
+		Logger:             log.NewNopLogger(),
+		DB:                 testDB,
+		StorageQuota:       100,
+		UploadsPerHour:     2,
+		UploadBytesPerHour: 50,
+	}
+
+	var uid string
+	err := testDB.QueryRowContext(ctx, "INSERT INTO users (email, username) VALUES ($1, $2) RETURNING id",
+		testutil.RandStr(t, 10)+"@example.org", testutil.RandStr(t, 10)).Scan(&uid)
+	testutil.WantEq(t, nil, err, "insert user")
+
+	charge := func(size int64) error {
+		t.Helper()
+
+		tx, err := testDB.BeginTx(ctx, nil)
+		testutil.WantEq(t, nil, err, "begin")
+		defer tx.Rollback()
+
+		if err := svc.chargeStorage(ctx, tx, uid, size); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	t.Run("quota", func(t *testing.T) {
+		testutil.WantEq(t, nil, charge(80), "within quota")
+		testutil.WantEq(t, ErrStorageQuotaExceeded, charge(30), "over quota")
+		testutil.WantEq(t, ErrStorageQuotaExceeded, svc.checkStorageQuota(ctx, uid, 30), "check over quota")
+		testutil.WantEq(t, nil, charge(-100), "free")
+
+		var storageBytes int64
+		err := testDB.QueryRowContext(ctx, "SELECT storage_bytes FROM users WHERE id = $1", uid).Scan(&storageBytes)
+		testutil.WantEq(t, nil, err, "select storage bytes")
+		testutil.WantEq(t, int64(0), storageBytes, "storage bytes never negative")
+	})
+
+	record := func(sizes ...int64) error {
+		t.Helper()
+
+		tx, err := testDB.BeginTx(ctx, nil)
+		testutil.WantEq(t, nil, err, "begin")
+		defer tx.Rollback()
+
+		if err := svc.recordUploads(ctx, tx, uid, sizes...); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	t.Run("rate", func(t *testing.T) {
+		testutil.WantEq(t, nil, record(20), "first")
+		testutil.WantEq(t, ErrUploadRateLimited, record(40), "too many bytes")
+		testutil.WantEq(t, ErrUploadRateLimited, svc.checkUploadLimits(ctx, testDB, uid, 40), "check too many bytes")
+		testutil.WantEq(t, nil, record(20), "second")
+		testutil.WantEq(t, ErrUploadRateLimited, record(1), "too many uploads")
+
+		usage, err := svc.storageUsage(ctx, uid, 0)
+		testutil.WantEq(t, nil, err, "usage error")
+		testutil.WantEq(t, 2, usage.UploadsLastHour, "uploads last hour")
+		testutil.WantEq(t, int64(40), usage.UploadBytesLastHour, "upload bytes last hour")
+	})
+}
@@ -0,0 +1,369 @@
+package nakama
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// streamReplaySize is the number of events retained per topic
+	// to replay to clients reconnecting with a last event ID.
+	streamReplaySize = 100
+	// streamReplayTTL is how long a topic keeps recording events
+	// after its last subscriber is gone, so clients can reconnect.
+	streamReplayTTL = time.Minute * 5
+
+	defaultStreamBufferSize = 64
+)
+
+// SlowConsumerPolicy decides what happens to stream subscribers
+// that do not keep up and fill their buffer.
+type SlowConsumerPolicy string
+
+const (
+	// SlowConsumerDrop drops the oldest buffered events.
+	SlowConsumerDrop SlowConsumerPolicy = "drop"
+	// SlowConsumerDisconnect closes the stream.
+	// Clients can reconnect with their last event ID to resume.
+	SlowConsumerDisconnect SlowConsumerPolicy = "disconnect"
+)
+
+var (
+	streamEventsDropped           = promauto.NewCounterVec(prometheus.CounterOpts{Name: "stream_events_dropped_total"}, []string{"stream"})
+	streamSubscribersDisconnected = promauto.NewCounterVec(prometheus.CounterOpts{Name: "stream_subscribers_disconnected_total"}, []string{"stream"})
+)
+
+// StreamEvent is an item delivered by a realtime stream
+// along with the ID clients can pass back as lastEventID
+// when reconnecting to resume right after it.
+type StreamEvent[T any] struct {
+	ID   string
+	Item T
+}
+
+type streamEntry struct {
+	seq  uint64
+	id   string
+	data []byte
+}
+
+// streamReplays records the recent events of every subscribed topic.
+// Zero value is ready to use.
+type streamReplays struct {
+	mu     sync.Mutex
+	topics map[string]*topicReplay
+}
+
+// topicReplay holds a bounded ring of the recent events of a single topic.
+// Event IDs are "<epoch>-<seq>" where epoch identifies this buffer,
+// so IDs from a previous buffer, or another server, are never mistaken.
+type topicReplay struct {
+	mu        sync.Mutex
+	epoch     string
+	seq       uint64
+	entries   []streamEntry
+	listeners map[uint64]func(streamEntry)
+	nextID    uint64
+	expiry    *time.Timer
+	// pending subscribers keep the topic from expiring
+	// until they add their listener.
+	pending int
+	// ready is closed once the pubsub subscription is done.
+	// Do not use the topic if err is set by then.
+	ready chan struct{}
+	err   error
+	unsub func() error
+}
+
+func (t *topicReplay) record(data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// ephemeral events have no ID and are not retained.
+	if isEphemeralWire(data) {
+		for _, l := range t.listeners {
+			l(streamEntry{data: data})
+		}
+		return
+	}
+
+	t.seq++
+	e := streamEntry{
+		seq:  t.seq,
+		id:   t.epoch + "-" + strconv.FormatUint(t.seq, 10),
+		data: data,
+	}
+	if len(t.entries) == streamReplaySize {
+		copy(t.entries, t.entries[1:])
+		t.entries = t.entries[:len(t.entries)-1]
+	}
+	t.entries = append(t.entries, e)
+
+	for _, l := range t.listeners {
+		l(e)
+	}
+}
+
+// since returns the retained entries after the given event ID.
+// Unknown IDs replay nothing since we cannot tell what the client has seen.
+func (t *topicReplay) since(lastEventID string) []streamEntry {
+	epoch, seqStr, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != t.epoch {
+		return nil
+	}
+
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || seq > t.seq {
+		return nil
+	}
+
+	for i, e := range t.entries {
+		if e.seq > seq {
+			return t.entries[i:]
+		}
+	}
+
+	return nil
+}
+
+// subscribeReplay calls cb, in order, for up to max events recorded after lastEventID
+// followed by every new event published to the topic.
+// cb must not block.
+func (s *Service) subscribeReplay(topic, lastEventID string, max int, cb func(streamEntry)) (unsub func(), err error) {
+	r := &s.streamReplays
+	r.mu.Lock()
+	t, ok := r.topics[topic]
+	if !ok {
+		t = &topicReplay{
+			epoch:     strconv.FormatInt(time.Now().UnixNano(), 36),
+			listeners: map[uint64]func(streamEntry){},
+			ready:     make(chan struct{}),
+		}
+		if r.topics == nil {
+			r.topics = map[string]*topicReplay{}
+		}
+		r.topics[topic] = t
+	}
+
+	t.mu.Lock()
+	if t.expiry != nil {
+		t.expiry.Stop()
+		t.expiry = nil
+	}
+	t.pending++
+	t.mu.Unlock()
+	r.mu.Unlock()
+
+	// pubsub subscriptions might take a network round trip
+	// so they happen out of the lock; concurrent subscribers wait for it.
+	if !ok {
+		t.unsub, t.err = s.PubSub.Sub(topic, t.record)
+		if t.err != nil {
+			r.mu.Lock()
+			if r.topics[topic] == t {
+				delete(r.topics, topic)
+			}
+			r.mu.Unlock()
+		}
+		close(t.ready)
+	}
+
+	<-t.ready
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	t.mu.Lock()
+	t.pending--
+
+	replay := t.since(lastEventID)
+	if len(replay) > max {
+		replay = replay[len(replay)-max:]
+	}
+	for _, e := range replay {
+		cb(e)
+	}
+
+	t.nextID++
+	listenerID := t.nextID
+	t.listeners[listenerID] = cb
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			delete(t.listeners, listenerID)
+			if len(t.listeners) == 0 && t.pending == 0 {
+				t.expiry = time.AfterFunc(streamReplayTTL, func() {
+					s.expireReplay(topic, t)
+				})
+			}
+		})
+	}, nil
+}
+
+func (s *Service) expireReplay(topic string, t *topicReplay) {
+	r := &s.streamReplays
+	r.mu.Lock()
+	t.mu.Lock()
+	if len(t.listeners) != 0 || t.pending != 0 || r.topics[topic] != t {
+		t.mu.Unlock()
+		r.mu.Unlock()
+		return
+	}
+
+	delete(r.topics, topic)
+	t.mu.Unlock()
+	r.mu.Unlock()
+
+	if err := t.unsub(); err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not unsubcribe from %s: %w", topic, err))
+	}
+}
+
+// streamQueue is a bounded ordered queue between a topic and a single subscriber.
+// Pushing never blocks; when full, the slow consumer policy applies.
+type streamQueue struct {
+	mu      sync.Mutex
+	entries []streamEntry
+	size    int
+	policy  SlowConsumerPolicy
+	stream  string
+	closed  bool
+	ready   chan struct{}
+}
+
+func (s *Service) newStreamQueue(topic string) *streamQueue {
+	size := s.StreamBufferSize
+	if size <= 0 {
+		size = defaultStreamBufferSize
+	}
+
+	return &streamQueue{
+		size:   size,
+		policy: s.SlowConsumerPolicy,
+		stream: streamName(topic),
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+func (q *streamQueue) push(e streamEntry) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+
+	if len(q.entries) >= q.size {
+		if q.policy == SlowConsumerDisconnect {
+			q.closed = true
+			q.entries = nil
+			q.mu.Unlock()
+
+			streamSubscribersDisconnected.WithLabelValues(q.stream).Inc()
+			q.signal()
+			return
+		}
+
+		q.entries[0] = streamEntry{}
+		q.entries = q.entries[1:]
+		streamEventsDropped.WithLabelValues(q.stream).Inc()
+	}
+
+	q.entries = append(q.entries, e)
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+func (q *streamQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop waits for the next entry.
+// Returns false once ctx is canceled or the subscriber got disconnected.
+func (q *streamQueue) pop(ctx context.Context) (streamEntry, bool) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return streamEntry{}, false
+		}
+
+		if len(q.entries) != 0 {
+			e := q.entries[0]
+			q.entries[0] = streamEntry{}
+			q.entries = q.entries[1:]
+			q.mu.Unlock()
+			return e, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return streamEntry{}, false
+		}
+	}
+}
+
+// subscribeStream delivers the topic events decoded in order
+// until ctx is canceled, resuming after lastEventID if possible.
+// Events for which decode returns false are skipped.
+// The returned channel is also closed when the subscriber falls behind
+// under the SlowConsumerDisconnect policy, so it can reconnect and resume.
+func subscribeStream[T any](ctx context.Context, s *Service, topic, lastEventID string, decode func(data []byte) (T, bool)) (<-chan StreamEvent[T], error) {
+	q := s.newStreamQueue(topic)
+	unsub, err := s.subscribeReplay(topic, lastEventID, q.size, q.push)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamEvent[T])
+	go func() {
+		defer close(out)
+		defer unsub()
+
+		for {
+			e, ok := q.pop(ctx)
+			if !ok {
+				return
+			}
+
+			v, ok := decode(e.data)
+			if !ok {
+				continue
+			}
+
+			select {
+			case out <- StreamEvent[T]{ID: e.id, Item: v}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// streamName is the topic family used to label stream metrics;
+// "notification_<user_id>" becomes "notification".
+func streamName(topic string) string {
+	if i := strings.LastIndexByte(topic, '_'); i != -1 {
+		return topic[:i]
+	}
+	return topic
+}
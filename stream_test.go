@@ -0,0 +1,188 @@
+package nakama
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama/pubsub/memory"
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_subscribeStream(t *testing.T) {
+	ps := &memory.PubSub{}
+	svc := &Service{Logger: log.NewNopLogger(), PubSub: ps}
+	decode := func(data []byte) (string, bool) {
+		return string(data), string(data) != "skip"
+	}
+
+	recv := func(t *testing.T, ch <-chan StreamEvent[string]) StreamEvent[string] {
+		t.Helper()
+		select {
+		case ev := <-ch:
+			return ev
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for stream event")
+			return StreamEvent[string]{}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := subscribeStream(ctx, svc, "topic", "", decode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = ps.Pub("topic", []byte("one"))
+	_ = ps.Pub("topic", []byte("skip"))
+	_ = ps.Pub("topic", []byte("two"))
+
+	one := recv(t, ch)
+	testutil.WantEq(t, "one", one.Item, "first item")
+	testutil.WantEq(t, "two", recv(t, ch).Item, "second item")
+
+	cancel()
+	for range ch {
+	}
+
+	// published while disconnected.
+	_ = ps.Pub("topic", []byte("three"))
+	_ = ps.Pub("topic", []byte("four"))
+
+	t.Run("resume", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := subscribeStream(ctx, svc, "topic", one.ID, decode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = ps.Pub("topic", []byte("five"))
+
+		for _, want := range []string{"two", "three", "four", "five"} {
+			testutil.WantEq(t, want, recv(t, ch).Item, "item")
+		}
+	})
+
+	t.Run("unknown_last_event_id", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := subscribeStream(ctx, svc, "topic", "nope-1", decode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = ps.Pub("topic", []byte("six"))
+		testutil.WantEq(t, "six", recv(t, ch).Item, "item")
+	})
+}
+
+func Test_streamQueue(t *testing.T) {
+	entries := func(n int) []streamEntry {
+		ee := make([]streamEntry, n)
+		for i := range ee {
+			ee[i] = streamEntry{seq: uint64(i + 1)}
+		}
+		return ee
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		svc := &Service{StreamBufferSize: 2, SlowConsumerPolicy: SlowConsumerDrop}
+		q := svc.newStreamQueue("comment_id")
+		for _, e := range entries(3) {
+			q.push(e)
+		}
+
+		ctx := context.Background()
+		e, ok := q.pop(ctx)
+		testutil.WantEq(t, true, ok, "ok")
+		testutil.WantEq(t, uint64(2), e.seq, "oldest event dropped")
+		e, _ = q.pop(ctx)
+		testutil.WantEq(t, uint64(3), e.seq, "last event")
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		svc := &Service{StreamBufferSize: 2, SlowConsumerPolicy: SlowConsumerDisconnect}
+		q := svc.newStreamQueue("comment_id")
+		for _, e := range entries(3) {
+			q.push(e)
+		}
+
+		_, ok := q.pop(context.Background())
+		testutil.WantEq(t, false, ok, "ok")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		svc := &Service{}
+		q := svc.newStreamQueue("posts")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, ok := q.pop(ctx)
+		testutil.WantEq(t, false, ok, "ok")
+	})
+}
+
+func Test_streamName(t *testing.T) {
+	testutil.WantEq(t, "notification", streamName("notification_24ca6ce6-b3e9-4276-a99a-45c77115cc9f"), "notification")
+	testutil.WantEq(t, "timeline_item", streamName("timeline_item_24ca6ce6-b3e9-4276-a99a-45c77115cc9f"), "timeline item")
+	testutil.WantEq(t, "posts", streamName("posts"), "posts")
+}
+
+// slowSubPubSub blocks subscriptions to the "slow" topic until released.
+type slowSubPubSub struct {
+	memory.PubSub
+	release chan struct{}
+	subs    atomic.Int32
+}
+
+func (ps *slowSubPubSub) Sub(topic string, cb func(data []byte)) (func() error, error) {
+	if topic == "slow" {
+		ps.subs.Add(1)
+		<-ps.release
+	}
+	return ps.PubSub.Sub(topic, cb)
+}
+
+func TestService_subscribeReplay_concurrent(t *testing.T) {
+	ps := &slowSubPubSub{release: make(chan struct{})}
+	svc := &Service{Logger: log.NewNopLogger(), PubSub: ps}
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := svc.subscribeReplay("slow", "", streamReplaySize, func(streamEntry) {})
+			errs <- err
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.subscribeReplay("fast", "", streamReplaySize, func(streamEntry) {})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		testutil.WantEq(t, nil, err, "fast topic error")
+	case <-time.After(time.Second * 5):
+		t.Fatal("fast topic blocked behind slow topic subscription")
+	}
+
+	close(ps.release)
+	for i := 0; i < 2; i++ {
+		testutil.WantEq(t, nil, <-errs, "slow topic error")
+	}
+	testutil.WantEq(t, int32(1), ps.subs.Load(), "pubsub subscriptions")
+
+	r := svc.streamReplays.topics["slow"]
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	testutil.WantEq(t, 2, len(r.listeners), "listeners")
+	testutil.WantEq(t, 0, r.pending, "pending")
+}
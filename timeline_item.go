@@ -1,29 +1,17 @@
 package nakama
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/gob"
 	"encoding/json"
 	"fmt"
-	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
 	"strings"
-	"sync"
 	"time"
 	"unicode/utf8"
 
 	"github.com/cockroachdb/cockroach-go/v2/crdb"
-	"github.com/disintegration/imaging"
-	"github.com/go-kit/log/level"
 	"github.com/lib/pq"
-	gonanoid "github.com/matoous/go-nanoid/v2"
-	"golang.org/x/sync/errgroup"
-
-	"github.com/nakamauwu/nakama/storage"
 )
 
 const MediaBucket = "media"
@@ -51,7 +39,8 @@ type TimelineItem struct {
 }
 
 // CreateTimelineItem publishes a post to the user timeline and fan-outs it to his followers.
-func (s *Service) CreateTimelineItem(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker) (TimelineItem, error) {
+// mediaAltTexts are the alt texts of the media items followed by the ones of the uploads.
+func (s *Service) CreateTimelineItem(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker, uploadIDs []string, mediaAltTexts []string) (TimelineItem, error) {
 	var ti TimelineItem
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
@@ -59,10 +48,16 @@ func (s *Service) CreateTimelineItem(ctx context.Context, content string, spoile
 	}
 
 	content = smartTrim(content)
-	if len(media) == 0 && content == "" || utf8.RuneCountInString(content) > postContentMaxLength {
+	if len(media) == 0 && len(uploadIDs) == 0 && content == "" || utf8.RuneCountInString(content) > postContentMaxLength {
 		return ti, ErrInvalidContent
 	}
 
+	for _, uploadID := range uploadIDs {
+		if !reUUID.MatchString(uploadID) {
+			return ti, ErrInvalidUploadID
+		}
+	}
+
 	if spoilerOf != nil {
 		*spoilerOf = smartTrim(*spoilerOf)
 		if *spoilerOf == "" || utf8.RuneCountInString(*spoilerOf) > postSpoilerMaxLength {
@@ -70,118 +65,60 @@ func (s *Service) CreateTimelineItem(ctx context.Context, content string, spoile
 		}
 	}
 
-	tags := collectTags(content)
-
-	type File struct {
-		Name        string
-		ContentType string
-		Content     []byte
+	altTexts, err := normalizeMediaAltTexts(mediaAltTexts, len(media)+len(uploadIDs))
+	if err != nil {
+		return ti, err
 	}
 
-	var files []File
-
-	if len(media) != 0 {
-		files = make([]File, len(media))
-
-		g := errgroup.Group{}
-		var mu sync.Mutex
-
-		for i, mediaItem := range media {
-			i := i
-			mediaItem := mediaItem
-
-			g.Go(func() error {
-				ct, err := detectContentType(mediaItem)
-				if err != nil {
-					return fmt.Errorf("create timeline item: detect media content type: %w", err)
-				}
-
-				if ct != "image/png" && ct != "image/jpeg" {
-					return ErrUnsupportedAvatarFormat
-				}
-
-				img, err := imaging.Decode(io.LimitReader(mediaItem, MaxMediaItemBytes), imaging.AutoOrientation(true))
-				if err == image.ErrFormat {
-					return ErrUnsupportedMediaItemFormat
-				}
-
-				if err != nil {
-					return fmt.Errorf("could not image decode post media item: %w", err)
-				}
-
-				buf := &bytes.Buffer{}
-				if ct == "image/png" {
-					err = png.Encode(buf, img)
-				} else {
-					err = jpeg.Encode(buf, img, nil)
-				}
-				if err != nil {
-					return fmt.Errorf("could not encode post media item: %w", err)
-				}
+	tags := collectTags(content)
 
-				fileName, err := gonanoid.New()
-				if err != nil {
-					return fmt.Errorf("could not generate media item filename: %w", err)
-				}
+	files, err := processMedia(media)
+	if err != nil {
+		return ti, err
+	}
 
-				if ct == "image/png" {
-					fileName += ".png"
-				} else {
-					fileName += ".jpg"
-				}
+	if err := s.limitMedia(ctx, uid, files); err != nil {
+		return ti, err
+	}
 
-				mu.Lock()
+	fileNames := mediaFileNames(files)
+	if len(files) != 0 {
+		if err := s.storeMediaFiles(ctx, files); err != nil {
+			return ti, fmt.Errorf("could not store post media: %w", err)
+		}
+	}
 
-				files[i] = File{
-					Name:        fileName,
-					ContentType: ct,
-					Content:     buf.Bytes(),
-				}
+	var p Post
+	err = crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
+		postMedia := fileNames
+		var uploaded []string
+		if len(uploadIDs) != 0 {
+			var err error
+			uploaded, err = attachUploads(ctx, tx, uid, uploadIDs)
+			if err != nil {
+				return err
+			}
 
-				mu.Unlock()
-				return nil
-			})
+			postMedia = append(append([]string{}, fileNames...), uploaded...)
 		}
 
-		if err := g.Wait(); err != nil {
-			return ti, err
+		if err := s.recordUploads(ctx, tx, uid, mediaFileSizes(files)...); err != nil {
+			return err
 		}
-	}
-
-	var mediaItemsBytes int64
-	var fileNames []string
-	for _, file := range files {
-		mediaItemsBytes += int64(len(file.Content))
-		fileNames = append(fileNames, file.Name)
-	}
 
-	if mediaItemsBytes > MaxMediaBytes {
-		return ti, ErrMediaTooLarge
-	}
-
-	if len(files) != 0 {
-		g, gctx := errgroup.WithContext(ctx)
-		for _, file := range files {
-			file := file
-			g.Go(func() error {
-				err := s.Store.Store(gctx, MediaBucket, file.Name, file.Content, storage.StoreWithContentType(file.ContentType))
-				if err != nil {
-					return fmt.Errorf("could not store post media item: %w", err)
-				}
-				return nil
-			})
+		if err := insertMediaItems(ctx, tx, uid, files); err != nil {
+			return err
 		}
-		if err := g.Wait(); err != nil {
-			return ti, err
+
+		// uploaded media keeps the reference acquired by its upload.
+		if err := s.acquireMedia(ctx, tx, uid, files); err != nil {
+			return err
 		}
-	}
 
-	var p Post
-	err := crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
 		query := `
 			INSERT INTO posts (user_id, content, spoiler_of, nsfw, media) VALUES ($1, $2, $3, $4, $5)
 			RETURNING id, created_at`
-		row := tx.QueryRowContext(ctx, query, uid, content, spoilerOf, nsfw, pq.Array(fileNames))
+		row := tx.QueryRowContext(ctx, query, uid, content, spoilerOf, nsfw, pq.Array(postMedia))
 		err := row.Scan(&p.ID, &p.CreatedAt)
 		if isForeignKeyViolation(err) {
 			return ErrUserGone
@@ -191,12 +128,17 @@ func (s *Service) CreateTimelineItem(ctx context.Context, content string, spoile
 			return fmt.Errorf("could not insert post: %w", err)
 		}
 
+		if err := insertPostMediaAltTexts(ctx, tx, p.ID, postMedia, altTexts); err != nil {
+			return err
+		}
+
 		p.UserID = uid
 		p.Content = content
 		p.SpoilerOf = spoilerOf
 		p.NSFW = nsfw
 		p.Mine = true
-		p.MediaURLs = s.mediaURLs(fileNames)
+		p.Media = s.mediaItems(postMedia)
+		p.MediaURLs = s.mediaURLs(postMedia)
 		p.UpdatedAt = p.CreatedAt
 
 		query = "INSERT INTO post_subscriptions (user_id, post_id) VALUES ($1, $2)"
@@ -233,29 +175,16 @@ func (s *Service) CreateTimelineItem(ctx context.Context, content string, spoile
 
 		return nil
 	})
+	// stored media is left to the storage garbage collector on error
+	// since identical media might be getting referenced concurrently.
 	if err != nil {
-		if len(files) != 0 {
-			go func() {
-				g, gctx := errgroup.WithContext(ctx)
-				for _, fileName := range fileNames {
-					fileName := fileName
-					g.Go(func() error {
-						err := s.Store.Delete(gctx, MediaBucket, fileName)
-						if err != nil {
-							return fmt.Errorf("could not delete post media item: %w", err)
-						}
-						return nil
-					})
-				}
-				if err := g.Wait(); err != nil {
-					_ = level.Error(s.Logger).Log("msg", "could not delete post media items", "err", err)
-				}
-			}()
-		}
-
 		return ti, err
 	}
 
+	if err := s.loadMediaItems(ctx, &p); err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not load created post media items: %w", err))
+	}
+
 	go s.postCreated(p)
 
 	return ti, nil
@@ -275,6 +204,7 @@ func (s *Service) postCreated(p Post) {
 	go s.broadcastPost(p)
 	go s.fanoutPost(p)
 	go s.notifyPostMention(p)
+	go s.emitWebhookEvent(WebhookEventPost, []string{p.UserID}, webhookPostData{Post: p})
 }
 
 type Timeline []TimelineItem
@@ -327,6 +257,7 @@ func (s *Service) Timeline(ctx context.Context, last uint64, before *string) (Ti
 		, subscriptions.user_id IS NOT NULL AS post_subscribed
 		, users.username
 		, users.avatar
+		, users.avatar_blurhash
 		FROM timeline
 		INNER JOIN posts ON timeline.post_id = posts.id
 		INNER JOIN users ON posts.user_id = users.id
@@ -372,7 +303,7 @@ func (s *Service) Timeline(ctx context.Context, last uint64, before *string) (Ti
 		var rawReactions []byte
 		var rawUserReactions []byte
 		var u User
-		var avatar sql.NullString
+		var avatar, avatarBlurHash sql.NullString
 		var media []string
 		if err = rows.Scan(
 			&ti.ID,
@@ -390,6 +321,7 @@ func (s *Service) Timeline(ctx context.Context, last uint64, before *string) (Ti
 			&p.Subscribed,
 			&u.Username,
 			&avatar,
+			&avatarBlurHash,
 		); err != nil {
 			return nil, fmt.Errorf("could not scan timeline item: %w", err)
 		}
@@ -420,8 +352,10 @@ func (s *Service) Timeline(ctx context.Context, last uint64, before *string) (Ti
 			}
 		}
 
+		p.Media = s.mediaItems(media)
 		p.MediaURLs = s.mediaURLs(media)
 		u.AvatarURL = s.avatarURL(avatar)
+		u.Avatar = s.avatarImage(avatar, avatarBlurHash)
 		p.User = &u
 		ti.Post = &p
 		tt = append(tt, ti)
@@ -431,43 +365,39 @@ func (s *Service) Timeline(ctx context.Context, last uint64, before *string) (Ti
 		return nil, fmt.Errorf("could not iterate timeline rows: %w", err)
 	}
 
+	posts := make([]*Post, len(tt))
+	for i := range tt {
+		posts[i] = tt[i].Post
+	}
+
+	if err := s.loadMediaItems(ctx, posts...); err != nil {
+		return nil, err
+	}
+
 	return tt, nil
 }
 
 // TimelineItemStream to receive timeline items in realtime.
-func (s *Service) TimelineItemStream(ctx context.Context) (<-chan TimelineItem, error) {
+// Pass the ID of the last received event to resume right after it.
+func (s *Service) TimelineItemStream(ctx context.Context, lastEventID string) (<-chan StreamEvent[TimelineItem], error) {
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
 		return nil, ErrUnauthenticated
 	}
 
-	tt := make(chan TimelineItem)
-	unsub, err := s.PubSub.Sub(timelineTopic(uid), func(data []byte) {
-		go func(r io.Reader) {
-			var ti TimelineItem
-			err := gob.NewDecoder(r).Decode(&ti)
-			if err != nil {
-				_ = s.Logger.Log("error", fmt.Errorf("could not gob decode timeline item: %w", err))
-				return
-			}
+	tt, err := subscribeStream(ctx, s, timelineTopic(uid), lastEventID, func(data []byte) (TimelineItem, bool) {
+		ti, err := unmarshalTimelineItem(data)
+		if err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not decode timeline item: %w", err))
+			return ti, false
+		}
 
-			tt <- ti
-		}(bytes.NewReader(data))
+		return ti, true
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could not subscribe to timeline: %w", err)
 	}
 
-	go func() {
-		<-ctx.Done()
-		if err := unsub(); err != nil {
-			_ = s.Logger.Log("error", fmt.Errorf("could not unsubcribe from timeline: %w", err))
-			// don't return
-		}
-
-		close(tt)
-	}()
-
 	return tt, nil
 }
 
@@ -524,14 +454,13 @@ func (s *Service) fanoutPost(p Post) {
 }
 
 func (s *Service) broadcastTimelineItem(ti TimelineItem) {
-	var b bytes.Buffer
-	err := gob.NewEncoder(&b).Encode(ti)
+	b, err := marshalTimelineItem(ti)
 	if err != nil {
-		_ = s.Logger.Log("error", fmt.Errorf("could not gob encode timeline item: %w", err))
+		_ = s.Logger.Log("error", fmt.Errorf("could not encode timeline item: %w", err))
 		return
 	}
 
-	err = s.PubSub.Pub(timelineTopic(ti.UserID), b.Bytes())
+	err = s.PubSub.Pub(timelineTopic(ti.UserID), b)
 	if err != nil {
 		_ = s.Logger.Log("error", fmt.Errorf("could not publish timeline item: %w", err))
 		return
@@ -545,8 +474,13 @@ func (s *Service) mediaURL(mediaItem string) string {
 }
 
 func (s *Service) mediaURLs(media []string) []string {
+	if media == nil {
+		return nil
+	}
+
+	out := make([]string, len(media))
 	for i, item := range media {
-		media[i] = s.mediaURL(item)
+		out[i] = s.mediaURL(item)
 	}
-	return media
+	return out
 }
@@ -81,7 +81,13 @@ func (h *handler) commentStream(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	postID := way.Param(ctx, "post_id")
-	cc, err := h.svc.CommentStream(ctx, postID)
+	cc, err := h.svc.CommentStream(ctx, postID, lastEventID(r))
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	pp, err := h.svc.CommentPresenceStream(ctx, postID)
 	if err != nil {
 		h.respondErr(w, err)
 		return
@@ -92,16 +98,42 @@ func (h *handler) commentStream(w http.ResponseWriter, r *http.Request) {
 	header.Set("Connection", "keep-alive")
 	header.Set("Content-Type", "text/event-stream; charset=utf-8")
 
-	select {
-	case c := <-cc:
-		if c.Reactions == nil {
-			c.Reactions = []nakama.Reaction{}
+	for {
+		select {
+		case ev, ok := <-cc:
+			if !ok {
+				return
+			}
+
+			c := ev.Item
+			if c.Reactions == nil {
+				c.Reactions = []nakama.Reaction{}
+			}
+			h.writeSSE(w, ev.ID, c)
+			f.Flush()
+		case p, ok := <-pp:
+			if !ok {
+				return
+			}
+
+			h.writeSSEEvent(w, "presence", p)
+			f.Flush()
+		case <-ctx.Done():
+			return
 		}
-		h.writeSSE(w, c)
-		f.Flush()
-	case <-ctx.Done():
+	}
+}
+
+func (h *handler) sendCommentTyping(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	postID := way.Param(ctx, "post_id")
+	err := h.svc.SendCommentTyping(ctx, postID)
+	if err != nil {
+		h.respondErr(w, err)
 		return
 	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *handler) updateComment(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,290 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/matryer/way"
+
+	"github.com/nakamauwu/nakama"
+)
+
+type createConversationInput struct {
+	Usernames []string `json:"usernames"`
+}
+
+type sendMessageInput struct {
+	Content string          `json:"content"`
+	Media   []io.ReadSeeker `json:"-"`
+}
+
+func (h *handler) createConversation(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var in createConversationInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		h.respondErr(w, errBadRequest)
+		return
+	}
+
+	c, err := h.svc.CreateConversation(r.Context(), in.Usernames)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	h.respond(w, c, http.StatusCreated)
+}
+
+func (h *handler) conversations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	last, _ := strconv.ParseUint(q.Get("last"), 10, 64)
+	before := emptyStrPtr(q.Get("before"))
+	cc, err := h.svc.Conversations(ctx, last, before)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	if cc == nil {
+		cc = []nakama.Conversation{} // non null array
+	}
+
+	for i := range cc {
+		if cc[i].LastMessage != nil && cc[i].LastMessage.MediaURLs == nil {
+			cc[i].LastMessage.MediaURLs = []string{} // non null array
+		}
+
+		if cc[i].LastMessage != nil && cc[i].LastMessage.Media == nil {
+			cc[i].LastMessage.Media = []nakama.Image{} // non null array
+		}
+	}
+
+	h.respond(w, paginatedRespBody{
+		Items:     cc,
+		EndCursor: cc.EndCursor(),
+	}, http.StatusOK)
+}
+
+func (h *handler) conversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	conversationID := way.Param(ctx, "conversation_id")
+	c, err := h.svc.Conversation(ctx, conversationID)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	if c.LastMessage != nil && c.LastMessage.MediaURLs == nil {
+		c.LastMessage.MediaURLs = []string{} // non null array
+	}
+
+	if c.LastMessage != nil && c.LastMessage.Media == nil {
+		c.LastMessage.Media = []nakama.Image{} // non null array
+	}
+
+	h.respond(w, c, http.StatusOK)
+}
+
+func (h *handler) sendMessage(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var in sendMessageInput
+
+	var closeFuncs []func() error
+
+	defer func() {
+		for _, f := range closeFuncs {
+			_ = f()
+		}
+	}()
+
+	mediatype, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil && strings.Contains(strings.ToLower(mediatype), "multipart/form-data") {
+		in.Content = r.FormValue("content")
+		if files, ok := r.MultipartForm.File["media"]; ok {
+			for _, header := range files {
+				if header.Size > nakama.MaxMediaItemBytes {
+					h.respondErr(w, nakama.ErrMediaItemTooLarge)
+					return
+				}
+
+				f, err := header.Open()
+				if err != nil {
+					h.respondErr(w, errBadRequest)
+					return
+				}
+
+				closeFuncs = append(closeFuncs, f.Close)
+
+				in.Media = append(in.Media, f)
+			}
+		}
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			h.respondErr(w, errBadRequest)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	conversationID := way.Param(ctx, "conversation_id")
+	m, err := h.svc.SendMessage(ctx, conversationID, in.Content, in.Media)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	if m.MediaURLs == nil {
+		m.MediaURLs = []string{} // non null array
+	}
+
+	if m.Media == nil {
+		m.Media = []nakama.Image{} // non null array
+	}
+
+	h.respond(w, m, http.StatusCreated)
+}
+
+func (h *handler) messages(w http.ResponseWriter, r *http.Request) {
+	if a, _, err := mime.ParseMediaType(r.Header.Get("Accept")); err == nil && a == "text/event-stream" {
+		h.conversationStream(w, r)
+		return
+	}
+
+	ctx := r.Context()
+	q := r.URL.Query()
+	conversationID := way.Param(ctx, "conversation_id")
+	last, _ := strconv.ParseUint(q.Get("last"), 10, 64)
+	before := emptyStrPtr(q.Get("before"))
+	mm, err := h.svc.Messages(ctx, conversationID, last, before)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	if mm == nil {
+		mm = []nakama.Message{} // non null array
+	}
+
+	for i := range mm {
+		if mm[i].MediaURLs == nil {
+			mm[i].MediaURLs = []string{} // non null array
+		}
+
+		if mm[i].Media == nil {
+			mm[i].Media = []nakama.Image{} // non null array
+		}
+	}
+
+	h.respond(w, paginatedRespBody{
+		Items:     mm,
+		EndCursor: mm.EndCursor(),
+	}, http.StatusOK)
+}
+
+// conversationStream writes new messages as default events
+// and read receipts as "read" events.
+func (h *handler) conversationStream(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		h.respondErr(w, errStreamingUnsupported)
+		return
+	}
+
+	ctx := r.Context()
+	conversationID := way.Param(ctx, "conversation_id")
+	ee, err := h.svc.ConversationStream(ctx, conversationID, lastEventID(r))
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("Content-Type", "text/event-stream; charset=utf-8")
+
+	for {
+		select {
+		case ev, ok := <-ee:
+			if !ok {
+				return
+			}
+
+			switch {
+			case ev.Item.Message != nil:
+				m := ev.Item.Message
+				if m.MediaURLs == nil {
+					m.MediaURLs = []string{} // non null array
+				}
+
+				if m.Media == nil {
+					m.Media = []nakama.Image{} // non null array
+				}
+
+				h.writeSSE(w, ev.ID, m)
+			case ev.Item.Read != nil:
+				h.writeSSEEvent(w, "read", ev.Item.Read)
+			}
+			f.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *handler) markConversationAsRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	conversationID := way.Param(ctx, "conversation_id")
+	err := h.svc.MarkConversationAsRead(ctx, conversationID)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) unreadMessagesCount(w http.ResponseWriter, r *http.Request) {
+	count, err := h.svc.UnreadMessagesCount(r.Context())
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	h.respond(w, count, http.StatusOK)
+}
+
+func (h *handler) dmSettings(w http.ResponseWriter, r *http.Request) {
+	out, err := h.svc.DMSettings(r.Context())
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	h.respond(w, out, http.StatusOK)
+}
+
+func (h *handler) updateDMSettings(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var in nakama.DMSettings
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		h.respondErr(w, errBadRequest)
+		return
+	}
+
+	err := h.svc.UpdateDMSettings(r.Context(), in)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
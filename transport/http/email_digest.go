@@ -0,0 +1,76 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/nakamauwu/nakama"
+	webtemplate "github.com/nakamauwu/nakama/web"
+)
+
+var unsubscribeTmpl = template.Must(template.ParseFS(webtemplate.TemplateFiles, "template/unsubscribe.html.tmpl"))
+
+func (h *handler) emailDigestSettings(w http.ResponseWriter, r *http.Request) {
+	out, err := h.svc.EmailDigestSettings(r.Context())
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	h.respond(w, out, http.StatusOK)
+}
+
+func (h *handler) updateEmailDigestSettings(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var in nakama.EmailDigestSettings
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		h.respondErr(w, errBadRequest)
+		return
+	}
+
+	err := h.svc.UpdateEmailDigestSettings(r.Context(), in)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unsubscribeEmailDigestPage is where the unsubscribe link sent within each digest lands.
+// It only renders a confirmation form that POSTs back to unsubscribeEmailDigest
+// so link scanners and prefetchers do not unsubscribe anybody.
+func (h *handler) unsubscribeEmailDigestPage(w http.ResponseWriter, r *http.Request) {
+	var buff bytes.Buffer
+	err := unsubscribeTmpl.Execute(&buff, r.URL.RequestURI())
+	if err != nil {
+		h.respondErr(w, fmt.Errorf("could not render unsubscribe template: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, err = w.Write(buff.Bytes())
+	if err != nil && !errors.Is(err, context.Canceled) {
+		_ = level.Error(h.logger).Log("msg", "could not write http response", "err", err)
+	}
+}
+
+func (h *handler) unsubscribeEmailDigest(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	err := h.svc.UnsubscribeEmailDigest(r.Context(), q.Get("user_id"), q.Get("token"))
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	http.Redirect(w, r, h.origin.String(), http.StatusSeeOther)
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama/testutil"
+	"github.com/nakamauwu/nakama/transport"
+)
+
+func Test_handler_unsubscribeEmailDigest(t *testing.T) {
+	svc := &transport.ServiceMock{
+		UnsubscribeEmailDigestFunc: func(ctx context.Context, userID, token string) error {
+			return nil
+		},
+	}
+	origin := &url.URL{Scheme: "https", Host: "nakama.social"}
+	srv := httptest.NewServer(New(svc, nil, origin, log.NewNopLogger(), nil, nil, nil, false))
+	defer srv.Close()
+
+	const path = "/api/email_digest/unsubscribe?token=token&user_id=user"
+
+	resp, err := srv.Client().Get(srv.URL + path)
+	testutil.WantEq(t, nil, err, "get error")
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	testutil.WantEq(t, nil, err, "read body error")
+	testutil.WantEq(t, http.StatusOK, resp.StatusCode, "get status")
+	testutil.WantEq(t, true, strings.Contains(string(body), `method="POST"`), "confirmation form")
+	testutil.WantEq(t, 0, len(svc.UnsubscribeEmailDigestCalls()), "get does not unsubscribe")
+
+	client := srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err = client.Post(srv.URL+path, "application/x-www-form-urlencoded", nil)
+	testutil.WantEq(t, nil, err, "post error")
+
+	defer resp.Body.Close()
+
+	testutil.WantEq(t, http.StatusSeeOther, resp.StatusCode, "post status")
+	testutil.WantEq(t, origin.String(), resp.Header.Get("Location"), "redirect")
+
+	calls := svc.UnsubscribeEmailDigestCalls()
+	testutil.WantEq(t, 1, len(calls), "post unsubscribes")
+	testutil.WantEq(t, "user", calls[0].UserID, "user id")
+	testutil.WantEq(t, "token", calls[0].Token, "token")
+}
@@ -51,6 +51,7 @@ func New(svc transport.Service, oauthProviders []OauthProvider, origin *url.URL,
 	api.HandleFunc("PUT", "/api/auth_user/avatar", h.updateAvatar)
 	api.HandleFunc("PUT", "/api/auth_user/cover", h.updateCover)
 	api.HandleFunc("POST", "/api/users/:username/toggle_follow", h.toggleFollow)
+	api.HandleFunc("POST", "/api/users/:username/toggle_block", h.toggleBlock)
 	api.HandleFunc("GET", "/api/users/:username/followers", h.followers)
 	api.HandleFunc("GET", "/api/users/:username/followees", h.followees)
 	api.HandleFunc("GET", "/api/users/:username/posts", h.userPosts)
@@ -58,6 +59,7 @@ func New(svc transport.Service, oauthProviders []OauthProvider, origin *url.URL,
 	api.HandleFunc("GET", "/api/posts/:post_id", h.post)
 	api.HandleFunc("PATCH", "/api/posts/:post_id", h.updatePost)
 	api.HandleFunc("DELETE", "/api/posts/:post_id", h.deletePost)
+	api.HandleFunc("PATCH", "/api/posts/:post_id/media/:name", h.updatePostMediaAltText)
 	api.HandleFunc("POST", "/api/posts/:post_id/toggle_reaction", h.togglePostReaction)
 	api.HandleFunc("POST", "/api/posts/:post_id/toggle_subscription", h.togglePostSubscription)
 	api.HandleFunc("POST", "/api/timeline", h.createTimelineItem)
@@ -65,14 +67,42 @@ func New(svc transport.Service, oauthProviders []OauthProvider, origin *url.URL,
 	api.HandleFunc("DELETE", "/api/timeline/:timeline_item_id", h.deleteTimelineItem)
 	api.HandleFunc("POST", "/api/posts/:post_id/comments", h.createComment)
 	api.HandleFunc("GET", "/api/posts/:post_id/comments", h.comments)
+	api.HandleFunc("POST", "/api/posts/:post_id/typing", h.sendCommentTyping)
 	api.HandleFunc("PATCH", "/api/comments/:comment_id", h.updateComment)
 	api.HandleFunc("DELETE", "/api/comments/:comment_id", h.deleteComment)
 	api.HandleFunc("POST", "/api/comments/:comment_id/toggle_reaction", h.toggleCommentReaction)
 	api.HandleFunc("GET", "/api/notifications", h.notifications)
+	api.HandleFunc("DELETE", "/api/notifications", h.deleteAllNotifications)
+	api.HandleFunc("DELETE", "/api/notifications/:notification_id", h.deleteNotification)
 	api.HandleFunc("GET", "/api/has_unread_notifications", h.hasUnreadNotifications)
+	api.HandleFunc("GET", "/api/unread_notifications_count", h.unreadNotificationsCount)
 	api.HandleFunc("POST", "/api/notifications/:notification_id/mark_as_read", h.markNotificationAsRead)
 	api.HandleFunc("POST", "/api/mark_notifications_as_read", h.markNotificationsAsRead)
+	api.HandleFunc("GET", "/api/realtime", h.realtime)
 	api.HandleFunc("POST", "/api/web_push_subscriptions", h.addWebPushSubscription)
+	api.HandleFunc("GET", "/api/web_push_subscriptions", h.webPushSubscriptions)
+	api.HandleFunc("DELETE", "/api/web_push_subscriptions/:subscription_id", h.deleteWebPushSubscription)
+	api.HandleFunc("GET", "/api/web_push_public_key", h.webPushPublicKey)
+	api.HandleFunc("POST", "/api/webhooks", h.createWebhook)
+	api.HandleFunc("GET", "/api/webhooks", h.webhooks)
+	api.HandleFunc("DELETE", "/api/webhooks/:webhook_id", h.deleteWebhook)
+	api.HandleFunc("GET", "/api/webhooks/:webhook_id/deliveries", h.webhookDeliveries)
+	api.HandleFunc("POST", "/api/uploads", h.createUploadIntent)
+	api.HandleFunc("PUT", "/api/uploads/:upload_id", h.putUpload)
+	api.HandleFunc("POST", "/api/uploads/:upload_id/finalize", h.finalizeUpload)
+	api.HandleFunc("POST", "/api/conversations", h.createConversation)
+	api.HandleFunc("GET", "/api/conversations", h.conversations)
+	api.HandleFunc("GET", "/api/conversations/:conversation_id", h.conversation)
+	api.HandleFunc("POST", "/api/conversations/:conversation_id/messages", h.sendMessage)
+	api.HandleFunc("GET", "/api/conversations/:conversation_id/messages", h.messages)
+	api.HandleFunc("POST", "/api/conversations/:conversation_id/mark_as_read", h.markConversationAsRead)
+	api.HandleFunc("GET", "/api/unread_messages_count", h.unreadMessagesCount)
+	api.HandleFunc("GET", "/api/auth_user/dm_settings", h.dmSettings)
+	api.HandleFunc("PUT", "/api/auth_user/dm_settings", h.updateDMSettings)
+	api.HandleFunc("GET", "/api/auth_user/email_digest", h.emailDigestSettings)
+	api.HandleFunc("PUT", "/api/auth_user/email_digest", h.updateEmailDigestSettings)
+	api.HandleFunc("GET", "/api/email_digest/unsubscribe", h.unsubscribeEmailDigestPage)
+	api.HandleFunc("POST", "/api/email_digest/unsubscribe", h.unsubscribeEmailDigest)
 
 	proxy := withCacheControl(proxyCacheControl)(h.proxy)
 	api.HandleFunc("HEAD", "/api/proxy", proxy)
@@ -43,7 +43,7 @@ func (h *handler) notificationStream(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	nn, err := h.svc.NotificationStream(ctx)
+	nn, err := h.svc.NotificationStream(ctx, lastEventID(r))
 	if err != nil {
 		h.respondErr(w, err)
 		return
@@ -54,12 +54,18 @@ func (h *handler) notificationStream(w http.ResponseWriter, r *http.Request) {
 	header.Set("Connection", "keep-alive")
 	header.Set("Content-Type", "text/event-stream; charset=utf-8")
 
-	select {
-	case n := <-nn:
-		h.writeSSE(w, n)
-		f.Flush()
-	case <-ctx.Done():
-		return
+	for {
+		select {
+		case ev, ok := <-nn:
+			if !ok {
+				return
+			}
+
+			h.writeSSE(w, ev.ID, ev.Item)
+			f.Flush()
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
@@ -73,6 +79,16 @@ func (h *handler) hasUnreadNotifications(w http.ResponseWriter, r *http.Request)
 	h.respond(w, unread, http.StatusOK)
 }
 
+func (h *handler) unreadNotificationsCount(w http.ResponseWriter, r *http.Request) {
+	count, err := h.svc.UnreadNotificationsCount(r.Context())
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	h.respond(w, count, http.StatusOK)
+}
+
 func (h *handler) markNotificationAsRead(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	notificationID := way.Param(ctx, "notification_id")
@@ -94,3 +110,25 @@ func (h *handler) markNotificationsAsRead(w http.ResponseWriter, r *http.Request
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *handler) deleteNotification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	notificationID := way.Param(ctx, "notification_id")
+	err := h.svc.DeleteNotification(ctx, notificationID)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) deleteAllNotifications(w http.ResponseWriter, r *http.Request) {
+	err := h.svc.DeleteAllNotifications(r.Context())
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -35,6 +35,10 @@ func (h *handler) userPosts(w http.ResponseWriter, r *http.Request) {
 		if pp[i].MediaURLs == nil {
 			pp[i].MediaURLs = []string{} // non null array
 		}
+
+		if pp[i].Media == nil {
+			pp[i].Media = []nakama.MediaItem{} // non null array
+		}
 	}
 
 	h.respond(w, paginatedRespBody{
@@ -75,6 +79,10 @@ func (h *handler) posts(w http.ResponseWriter, r *http.Request) {
 		if pp[i].MediaURLs == nil {
 			pp[i].MediaURLs = []string{} // non null array
 		}
+
+		if pp[i].Media == nil {
+			pp[i].Media = []nakama.MediaItem{} // non null array
+		}
 	}
 
 	h.respond(w, paginatedRespBody{
@@ -111,7 +119,11 @@ func (h *handler) postStream(w http.ResponseWriter, r *http.Request) {
 			p.MediaURLs = []string{} // non null array
 		}
 
-		h.writeSSE(w, p)
+		if p.Media == nil {
+			p.Media = []nakama.MediaItem{} // non null array
+		}
+
+		h.writeSSE(w, "", p)
 		f.Flush()
 	case <-ctx.Done():
 		return
@@ -134,6 +146,10 @@ func (h *handler) post(w http.ResponseWriter, r *http.Request) {
 		p.MediaURLs = []string{} // non null array
 	}
 
+	if p.Media == nil {
+		p.Media = []nakama.MediaItem{} // non null array
+	}
+
 	h.respond(w, p, http.StatusOK)
 }
 
@@ -158,6 +174,31 @@ func (h *handler) updatePost(w http.ResponseWriter, r *http.Request) {
 	h.respond(w, out, http.StatusOK)
 }
 
+type updatePostMediaAltTextInput struct {
+	AltText *string `json:"altText"`
+}
+
+func (h *handler) updatePostMediaAltText(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var in updatePostMediaAltTextInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		h.respondErr(w, errBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	postID := way.Param(ctx, "post_id")
+	name := way.Param(ctx, "name")
+	err := h.svc.UpdatePostMediaAltText(ctx, postID, name, in.AltText)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *handler) deletePost(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	postID := way.Param(ctx, "post_id")
@@ -0,0 +1,448 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/nakamauwu/nakama"
+)
+
+const (
+	realtimeWriteWait        = time.Second * 10
+	realtimePongWait         = time.Second * 60
+	realtimePingPeriod       = realtimePongWait * 9 / 10
+	realtimeMaxMessageSize   = 1 << 12
+	realtimeSendBufferSize   = 64
+	realtimeMaxSubscriptions = 32
+)
+
+const (
+	realtimeChannelPosts            = "posts"
+	realtimeChannelTimeline         = "timeline"
+	realtimeChannelNotifications    = "notifications"
+	realtimeChannelCommentsPfx      = "comments:"
+	realtimeChannelConversationsPfx = "conversations:"
+)
+
+var (
+	errInvalidRealtimeChannel    = nakama.InvalidArgumentError("invalid realtime channel")
+	errInvalidRealtimeMessage    = nakama.InvalidArgumentError("invalid realtime message")
+	errRealtimeAlreadySubscribed = nakama.AlreadyExistsError("already subscribed to realtime channel")
+	errRealtimeNotSubscribed     = nakama.NotFoundError("not subscribed to realtime channel")
+	errRealtimeTooManyChannels   = nakama.InvalidArgumentError("too many realtime channel subscriptions")
+	errRealtimeStreamClosed      = errors.New("realtime stream closed; resubscribe with the last event ID")
+)
+
+// realtimeMessage is the envelope of every message sent over the realtime websocket.
+// Clients send "subscribe", "unsubscribe" and "ping" messages;
+// the server replies with "subscribed", "unsubscribed", "pong", "event" and "error".
+// Comments channels also deliver "presence" messages without ID,
+// and conversations channels deliver "read" messages for read receipts.
+// Subscribe with the ID of the last received event to resume right after it.
+type realtimeMessage struct {
+	Type        string      `json:"type"`
+	Channel     string      `json:"channel,omitempty"`
+	ID          string      `json:"id,omitempty"`
+	LastEventID string      `json:"lastEventID,omitempty"`
+	Data        interface{} `json:"data,omitempty"`
+	Error       string      `json:"error,omitempty"`
+}
+
+// realtime upgrades the request to a websocket connection which multiplexes
+// the posts, timeline, notifications, comments and conversations streams.
+// Authenticate with the "auth_token" query string parameter since
+// browsers cannot set headers on websocket requests.
+func (h *handler) realtime(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: h.checkRealtimeOrigin,
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// upgrader already replied with an error.
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	rc := &realtimeConn{
+		h:    h,
+		conn: conn,
+		ctx:  ctx,
+		send: make(chan realtimeMessage, realtimeSendBufferSize),
+		subs: map[string]context.CancelFunc{},
+	}
+
+	go rc.writeLoop(cancel)
+	rc.readLoop()
+}
+
+func (h *handler) checkRealtimeOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || h.origin == nil {
+		return true
+	}
+
+	return strings.EqualFold(origin, h.origin.Scheme+"://"+h.origin.Host)
+}
+
+type realtimeConn struct {
+	h    *handler
+	conn *websocket.Conn
+	ctx  context.Context
+	send chan realtimeMessage
+
+	mu   sync.Mutex
+	subs map[string]context.CancelFunc
+}
+
+func (rc *realtimeConn) readLoop() {
+	defer func() {
+		rc.mu.Lock()
+		for _, unsub := range rc.subs {
+			unsub()
+		}
+		rc.mu.Unlock()
+
+		_ = rc.conn.Close()
+	}()
+
+	rc.conn.SetReadLimit(realtimeMaxMessageSize)
+	_ = rc.conn.SetReadDeadline(time.Now().Add(realtimePongWait))
+	rc.conn.SetPongHandler(func(string) error {
+		return rc.conn.SetReadDeadline(time.Now().Add(realtimePongWait))
+	})
+
+	for {
+		var msg realtimeMessage
+		err := rc.conn.ReadJSON(&msg)
+		if err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+				rc.reply(realtimeMessage{Type: "error", Error: errInvalidRealtimeMessage.Error()})
+				continue
+			}
+
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
+				_ = rc.h.logger.Log("err", fmt.Errorf("could not read realtime message: %w", err))
+			}
+			return
+		}
+
+		_ = rc.conn.SetReadDeadline(time.Now().Add(realtimePongWait))
+
+		switch msg.Type {
+		case "ping":
+			rc.reply(realtimeMessage{Type: "pong"})
+		case "subscribe":
+			rc.subscribe(msg.Channel, msg.LastEventID)
+		case "unsubscribe":
+			rc.unsubscribe(msg.Channel)
+		default:
+			rc.reply(realtimeMessage{Type: "error", Channel: msg.Channel, Error: errInvalidRealtimeMessage.Error()})
+		}
+	}
+}
+
+func (rc *realtimeConn) writeLoop(cancel context.CancelFunc) {
+	ticker := time.NewTicker(realtimePingPeriod)
+	defer func() {
+		ticker.Stop()
+		cancel()
+		_ = rc.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-rc.ctx.Done():
+			_ = rc.conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(realtimeWriteWait),
+			)
+			return
+		case msg := <-rc.send:
+			_ = rc.conn.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+			if err := rc.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = rc.conn.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+			if err := rc.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// reply queues a message to be written, giving up if the connection is gone.
+func (rc *realtimeConn) reply(msg realtimeMessage) {
+	select {
+	case rc.send <- msg:
+	case <-rc.ctx.Done():
+	}
+}
+
+func (rc *realtimeConn) replyErr(channel string, err error) {
+	if err2code(err) == http.StatusInternalServerError {
+		if !errors.Is(err, context.Canceled) {
+			_ = rc.h.logger.Log("err", err)
+		}
+		err = errors.New("internal server error")
+	}
+
+	rc.reply(realtimeMessage{Type: "error", Channel: channel, Error: err.Error()})
+}
+
+func (rc *realtimeConn) subscribe(channel, lastEventID string) {
+	rc.mu.Lock()
+	if _, ok := rc.subs[channel]; ok {
+		rc.mu.Unlock()
+		rc.replyErr(channel, errRealtimeAlreadySubscribed)
+		return
+	}
+
+	if len(rc.subs) >= realtimeMaxSubscriptions {
+		rc.mu.Unlock()
+		rc.replyErr(channel, errRealtimeTooManyChannels)
+		return
+	}
+
+	// reserve the channel so opening it, which might query the database
+	// and subscribe to pubsub, does not stall the rest of the connection.
+	// Unsubscribing meanwhile cancels ctx.
+	ctx, unsub := context.WithCancel(rc.ctx)
+	rc.subs[channel] = unsub
+	rc.mu.Unlock()
+
+	events, err := rc.h.realtimeChannel(ctx, channel, lastEventID)
+
+	rc.mu.Lock()
+	canceled := ctx.Err() != nil
+	if err != nil && !canceled {
+		delete(rc.subs, channel)
+	}
+	rc.mu.Unlock()
+
+	if err != nil {
+		unsub()
+		if !canceled {
+			rc.replyErr(channel, err)
+		}
+		return
+	}
+
+	if canceled {
+		// drain so the stream gets closed.
+		go func() {
+			for range events {
+			}
+		}()
+		return
+	}
+
+	rc.reply(realtimeMessage{Type: "subscribed", Channel: channel})
+
+	go func() {
+		// keep draining after the connection is gone
+		// so the stream gets closed.
+		for ev := range events {
+			typ := ev.Type
+			if typ == "" {
+				typ = "event"
+			}
+			if ev.ID != "" {
+				lastEventID = ev.ID
+			}
+			rc.reply(realtimeMessage{Type: typ, Channel: channel, ID: ev.ID, Data: ev.Data})
+		}
+
+		// subscriptions are canceled under the lock,
+		// so a live context means the service closed the stream;
+		// like slow consumers being disconnected.
+		rc.mu.Lock()
+		closed := ctx.Err() == nil
+		if closed {
+			delete(rc.subs, channel)
+		}
+		rc.mu.Unlock()
+
+		if !closed {
+			return
+		}
+
+		unsub()
+		rc.reply(realtimeMessage{
+			Type:        "unsubscribed",
+			Channel:     channel,
+			LastEventID: lastEventID,
+			Error:       errRealtimeStreamClosed.Error(),
+		})
+	}()
+}
+
+func (rc *realtimeConn) unsubscribe(channel string) {
+	rc.mu.Lock()
+	unsub, ok := rc.subs[channel]
+	delete(rc.subs, channel)
+	if ok {
+		unsub()
+	}
+	rc.mu.Unlock()
+
+	if !ok {
+		rc.replyErr(channel, errRealtimeNotSubscribed)
+		return
+	}
+
+	rc.reply(realtimeMessage{Type: "unsubscribed", Channel: channel})
+}
+
+type realtimeEvent struct {
+	Type string
+	ID   string
+	Data interface{}
+}
+
+// realtimeChannel opens the service stream behind the given channel name,
+// resuming after lastEventID when the stream supports it.
+// The returned channel is closed once ctx is canceled.
+func (h *handler) realtimeChannel(ctx context.Context, channel, lastEventID string) (<-chan realtimeEvent, error) {
+	switch {
+	case channel == realtimeChannelPosts:
+		pp, err := h.svc.PostStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return realtimePipe(pp, func(p nakama.Post) realtimeEvent {
+			if p.Reactions == nil {
+				p.Reactions = []nakama.Reaction{} // non null array
+			}
+			if p.MediaURLs == nil {
+				p.MediaURLs = []string{} // non null array
+			}
+			if p.Media == nil {
+				p.Media = []nakama.MediaItem{} // non null array
+			}
+			return realtimeEvent{Data: p}
+		}), nil
+	case channel == realtimeChannelTimeline:
+		tt, err := h.svc.TimelineItemStream(ctx, lastEventID)
+		if err != nil {
+			return nil, err
+		}
+
+		return realtimePipe(tt, func(ev nakama.StreamEvent[nakama.TimelineItem]) realtimeEvent {
+			ti := ev.Item
+			if ti.Post.Reactions == nil {
+				ti.Post.Reactions = []nakama.Reaction{} // non null array
+			}
+			if ti.Post.MediaURLs == nil {
+				ti.Post.MediaURLs = []string{} // non null array
+			}
+			if ti.Post.Media == nil {
+				ti.Post.Media = []nakama.MediaItem{} // non null array
+			}
+			return realtimeEvent{ID: ev.ID, Data: ti}
+		}), nil
+	case channel == realtimeChannelNotifications:
+		nn, err := h.svc.NotificationStream(ctx, lastEventID)
+		if err != nil {
+			return nil, err
+		}
+
+		return realtimePipe(nn, func(ev nakama.StreamEvent[nakama.Notification]) realtimeEvent {
+			return realtimeEvent{ID: ev.ID, Data: ev.Item}
+		}), nil
+	case strings.HasPrefix(channel, realtimeChannelCommentsPfx):
+		postID := strings.TrimPrefix(channel, realtimeChannelCommentsPfx)
+		cc, err := h.svc.CommentStream(ctx, postID, lastEventID)
+		if err != nil {
+			return nil, err
+		}
+
+		pp, err := h.svc.CommentPresenceStream(ctx, postID)
+		if err != nil {
+			return nil, err
+		}
+
+		return realtimeMerge(
+			realtimePipe(cc, func(ev nakama.StreamEvent[nakama.Comment]) realtimeEvent {
+				c := ev.Item
+				if c.Reactions == nil {
+					c.Reactions = []nakama.Reaction{} // non null array
+				}
+				return realtimeEvent{ID: ev.ID, Data: c}
+			}),
+			realtimePipe(pp, func(p nakama.CommentPresence) realtimeEvent {
+				return realtimeEvent{Type: "presence", Data: p}
+			}),
+		), nil
+	case strings.HasPrefix(channel, realtimeChannelConversationsPfx):
+		conversationID := strings.TrimPrefix(channel, realtimeChannelConversationsPfx)
+		ee, err := h.svc.ConversationStream(ctx, conversationID, lastEventID)
+		if err != nil {
+			return nil, err
+		}
+
+		return realtimePipe(ee, func(ev nakama.StreamEvent[nakama.ConversationEvent]) realtimeEvent {
+			if ev.Item.Read != nil {
+				return realtimeEvent{Type: "read", ID: ev.ID, Data: ev.Item.Read}
+			}
+
+			m := ev.Item.Message
+			if m.MediaURLs == nil {
+				m.MediaURLs = []string{} // non null array
+			}
+			if m.Media == nil {
+				m.Media = []nakama.Image{} // non null array
+			}
+			return realtimeEvent{ID: ev.ID, Data: m}
+		}), nil
+	}
+
+	return nil, errInvalidRealtimeChannel
+}
+
+// realtimeMerge forwards the events of every input
+// and gets closed once all of them are.
+func realtimeMerge(ins ...<-chan realtimeEvent) <-chan realtimeEvent {
+	out := make(chan realtimeEvent)
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		go func(in <-chan realtimeEvent) {
+			defer wg.Done()
+			for ev := range in {
+				out <- ev
+			}
+		}(in)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+func realtimePipe[T any](in <-chan T, fn func(T) realtimeEvent) <-chan realtimeEvent {
+	out := make(chan realtimeEvent)
+	go func() {
+		defer close(out)
+		for v := range in {
+			out <- fn(v)
+		}
+	}()
+	return out
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/websocket"
+
+	"github.com/nakamauwu/nakama"
+	"github.com/nakamauwu/nakama/testutil"
+	"github.com/nakamauwu/nakama/transport"
+)
+
+func Test_handler_realtime(t *testing.T) {
+	nn := make(chan nakama.StreamEvent[nakama.Notification])
+	closeNN := make(chan struct{}, 1)
+	svc := &transport.ServiceMock{
+		NotificationStreamFunc: func(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.Notification], error) {
+			out := make(chan nakama.StreamEvent[nakama.Notification])
+			go func() {
+				defer close(out)
+				for {
+					select {
+					case n := <-nn:
+						out <- n
+					case <-closeNN:
+						return
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return out, nil
+		},
+		CommentStreamFunc: func(ctx context.Context, postID, lastEventID string) (<-chan nakama.StreamEvent[nakama.Comment], error) {
+			return nil, nakama.ErrInvalidPostID
+		},
+	}
+
+	h := New(svc, nil, nil, log.NewNopLogger(), nil, nil, nil, true)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/realtime", nil)
+	if err != nil {
+		t.Fatalf("failed to dial realtime websocket: %v", err)
+	}
+
+	defer conn.Close()
+
+	send := func(t *testing.T, msg realtimeMessage) {
+		t.Helper()
+		if err := conn.WriteJSON(msg); err != nil {
+			t.Fatalf("failed to write realtime message: %v", err)
+		}
+	}
+
+	recv := func(t *testing.T) realtimeMessage {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+		var msg realtimeMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("failed to read realtime message: %v", err)
+		}
+		return msg
+	}
+
+	t.Run("ping", func(t *testing.T) {
+		send(t, realtimeMessage{Type: "ping"})
+		testutil.WantEq(t, realtimeMessage{Type: "pong"}, recv(t), "message")
+	})
+
+	t.Run("invalid_channel", func(t *testing.T) {
+		send(t, realtimeMessage{Type: "subscribe", Channel: "nope"})
+		testutil.WantEq(t, realtimeMessage{Type: "error", Channel: "nope", Error: "invalid realtime channel"}, recv(t), "message")
+	})
+
+	t.Run("invalid_post_id", func(t *testing.T) {
+		send(t, realtimeMessage{Type: "subscribe", Channel: "comments:nope"})
+		testutil.WantEq(t, realtimeMessage{Type: "error", Channel: "comments:nope", Error: "invalid post ID"}, recv(t), "message")
+	})
+
+	t.Run("subscribe", func(t *testing.T) {
+		send(t, realtimeMessage{Type: "subscribe", Channel: "notifications"})
+		testutil.WantEq(t, realtimeMessage{Type: "subscribed", Channel: "notifications"}, recv(t), "message")
+
+		nn <- nakama.StreamEvent[nakama.Notification]{ID: "event_id", Item: nakama.Notification{ID: "notification_id", Type: "follow"}}
+		msg := recv(t)
+		testutil.WantEq(t, "event", msg.Type, "type")
+		testutil.WantEq(t, "notifications", msg.Channel, "channel")
+		testutil.WantEq(t, "event_id", msg.ID, "event id")
+		testutil.WantEq(t, "notification_id", msg.Data.(map[string]interface{})["id"], "data id")
+	})
+
+	t.Run("already_subscribed", func(t *testing.T) {
+		send(t, realtimeMessage{Type: "subscribe", Channel: "notifications"})
+		testutil.WantEq(t, realtimeMessage{Type: "error", Channel: "notifications", Error: "already subscribed to realtime channel"}, recv(t), "message")
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		send(t, realtimeMessage{Type: "unsubscribe", Channel: "notifications"})
+		testutil.WantEq(t, realtimeMessage{Type: "unsubscribed", Channel: "notifications"}, recv(t), "message")
+
+		send(t, realtimeMessage{Type: "unsubscribe", Channel: "notifications"})
+		testutil.WantEq(t, realtimeMessage{Type: "error", Channel: "notifications", Error: "not subscribed to realtime channel"}, recv(t), "message")
+	})
+
+	t.Run("stream_closed", func(t *testing.T) {
+		send(t, realtimeMessage{Type: "subscribe", Channel: "notifications", LastEventID: "first_event_id"})
+		testutil.WantEq(t, realtimeMessage{Type: "subscribed", Channel: "notifications"}, recv(t), "subscribed")
+
+		nn <- nakama.StreamEvent[nakama.Notification]{ID: "last_event_id", Item: nakama.Notification{ID: "notification_id"}}
+		testutil.WantEq(t, "last_event_id", recv(t).ID, "event id")
+
+		closeNN <- struct{}{}
+		testutil.WantEq(t, realtimeMessage{
+			Type:        "unsubscribed",
+			Channel:     "notifications",
+			LastEventID: "last_event_id",
+			Error:       errRealtimeStreamClosed.Error(),
+		}, recv(t), "unsubscribed")
+
+		send(t, realtimeMessage{Type: "subscribe", Channel: "notifications", LastEventID: "last_event_id"})
+		testutil.WantEq(t, realtimeMessage{Type: "subscribed", Channel: "notifications"}, recv(t), "resubscribed")
+	})
+}
@@ -1,10 +1,8 @@
 package http
 
 import (
-	"context"
 	"errors"
 	"fmt"
-	"io"
 	"io/fs"
 	"net/http"
 	"os"
@@ -12,11 +10,11 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
-	"syscall"
 
 	"github.com/matryer/way"
 
 	"github.com/nakamauwu/nakama"
+	"github.com/nakamauwu/nakama/storage"
 	"github.com/nakamauwu/nakama/web"
 )
 
@@ -53,64 +51,53 @@ func (fs *spaFileSystem) Open(name string) (http.File, error) {
 }
 
 func (h *handler) avatar(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	name := way.Param(ctx, "name")
+	h.serveImage(w, r, nakama.AvatarsBucket)
+}
 
-	f, err := h.store.Open(ctx, nakama.AvatarsBucket, name)
-	if err != nil {
-		h.respondErr(w, err)
-		return
-	}
+func (h *handler) cover(w http.ResponseWriter, r *http.Request) {
+	h.serveImage(w, r, nakama.CoversBucket)
+}
 
-	w.Header().Set("Content-Type", f.ContentType)
-	w.Header().Set("Content-Length", strconv.FormatInt(f.Size, 10))
-	w.Header().Set("Etag", f.ETag)
-	w.Header().Set("Last-Modified", f.LastModified.Format(http.TimeFormat))
-	w.WriteHeader(http.StatusOK)
-	_, err = io.Copy(w, f)
-	if err != nil && !errors.Is(err, syscall.EPIPE) && !errors.Is(err, context.Canceled) {
-		_ = h.logger.Log("err", fmt.Errorf("could not write down avatar: %w", err))
-	}
+func (h *handler) media(w http.ResponseWriter, r *http.Request) {
+	h.serveImage(w, r, nakama.MediaBucket)
 }
 
-func (h *handler) cover(w http.ResponseWriter, r *http.Request) {
+// serveImage writes down the image variant given in the "variant"
+// query parameter, falling back to the original
+// for images stored before variants existed.
+// Conditional and range requests are honored.
+func (h *handler) serveImage(w http.ResponseWriter, r *http.Request, bucket string) {
 	ctx := r.Context()
 	name := way.Param(ctx, "name")
-
-	f, err := h.store.Open(ctx, nakama.CoversBucket, name)
-	if err != nil {
-		h.respondErr(w, err)
+	variant := nakama.ImageVariant(r.URL.Query().Get("variant"))
+	if variant != "" && !nakama.ValidImageVariant(variant) {
+		h.respondErr(w, errBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", f.ContentType)
-	w.Header().Set("Content-Length", strconv.FormatInt(f.Size, 10))
-	w.Header().Set("Etag", f.ETag)
-	w.Header().Set("Last-Modified", f.LastModified.Format(http.TimeFormat))
-	w.WriteHeader(http.StatusOK)
-	_, err = io.Copy(w, f)
-	if err != nil && !errors.Is(err, syscall.EPIPE) && !errors.Is(err, context.Canceled) {
-		_ = h.logger.Log("err", fmt.Errorf("could not write down cover: %w", err))
+	f, err := h.store.Open(ctx, bucket, nakama.ImageVariantName(name, variant))
+	fallback := errors.Is(err, storage.ErrNotFound) && nakama.ImageVariantName(name, variant) != name
+	if fallback {
+		f, err = h.store.Open(ctx, bucket, name)
 	}
-}
-
-func (h *handler) media(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	name := way.Param(ctx, "name")
-
-	f, err := h.store.Open(ctx, nakama.MediaBucket, name)
 	if err != nil {
 		h.respondErr(w, err)
 		return
 	}
 
+	defer f.Close()
+
+	// names are never reused with other content so they can be cached forever.
+	// Missing variants might still be generated, so the original served instead
+	// must be revalidated.
+	if fallback {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
 	w.Header().Set("Content-Type", f.ContentType)
-	w.Header().Set("Content-Length", strconv.FormatInt(f.Size, 10))
-	w.Header().Set("Etag", f.ETag)
-	w.Header().Set("Last-Modified", f.LastModified.Format(http.TimeFormat))
-	w.WriteHeader(http.StatusOK)
-	_, err = io.Copy(w, f)
-	if err != nil && !errors.Is(err, syscall.EPIPE) && !errors.Is(err, context.Canceled) {
-		_ = h.logger.Log("err", fmt.Errorf("could not write down cover: %w", err))
+	if f.ETag != "" {
+		w.Header().Set("Etag", strconv.Quote(f.ETag))
 	}
+	http.ServeContent(w, r, name, f.LastModified, f)
 }
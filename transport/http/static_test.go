@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama"
+	"github.com/nakamauwu/nakama/storage/fs"
+	"github.com/nakamauwu/nakama/testutil"
+	"github.com/nakamauwu/nakama/transport"
+)
+
+func Test_handler_media(t *testing.T) {
+	store := &fs.Store{Root: t.TempDir()}
+	content := []byte("not really an image but enough bytes to serve")
+	err := store.Store(context.Background(), nakama.MediaBucket, "abc.jpg", content)
+	testutil.WantEq(t, nil, err, "store error")
+
+	h := New(&transport.ServiceMock{}, nil, nil, log.NewNopLogger(), store, nil, nil, true)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	get := func(t *testing.T, path string, header http.Header) (*http.Response, []byte) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		testutil.WantEq(t, nil, err, "new request error")
+
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		resp, err := srv.Client().Do(req)
+		testutil.WantEq(t, nil, err, "request error")
+
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		testutil.WantEq(t, nil, err, "read body error")
+
+		return resp, b
+	}
+
+	resp, body := get(t, "/img/media/abc.jpg", nil)
+	testutil.WantEq(t, http.StatusOK, resp.StatusCode, "status")
+	testutil.WantEq(t, string(content), string(body), "body")
+	testutil.WantEq(t, "public, max-age=31536000, immutable", resp.Header.Get("Cache-Control"), "cache control")
+
+	etag := resp.Header.Get("Etag")
+	testutil.WantEq(t, 66, len(etag), "quoted sha256 etag")
+
+	t.Run("if_none_match", func(t *testing.T) {
+		resp, _ := get(t, "/img/media/abc.jpg", http.Header{"If-None-Match": {etag}})
+		testutil.WantEq(t, http.StatusNotModified, resp.StatusCode, "status")
+	})
+
+	t.Run("if_modified_since", func(t *testing.T) {
+		resp, _ := get(t, "/img/media/abc.jpg", http.Header{"If-Modified-Since": {resp.Header.Get("Last-Modified")}})
+		testutil.WantEq(t, http.StatusNotModified, resp.StatusCode, "status")
+	})
+
+	t.Run("range", func(t *testing.T) {
+		resp, body := get(t, "/img/media/abc.jpg", http.Header{"Range": {"bytes=4-9"}})
+		testutil.WantEq(t, http.StatusPartialContent, resp.StatusCode, "status")
+		testutil.WantEq(t, string(content[4:10]), string(body), "body")
+	})
+
+	t.Run("variant_fallback", func(t *testing.T) {
+		resp, body := get(t, "/img/media/abc.jpg?variant=thumb", nil)
+		testutil.WantEq(t, http.StatusOK, resp.StatusCode, "status")
+		testutil.WantEq(t, string(content), string(body), "body")
+		testutil.WantEq(t, "no-cache", resp.Header.Get("Cache-Control"), "cache control")
+	})
+
+	t.Run("variant", func(t *testing.T) {
+		thumb := []byte("not really a thumbnail either")
+		err := store.Store(context.Background(), nakama.MediaBucket, nakama.ImageVariantName("abc.jpg", "thumb"), thumb)
+		testutil.WantEq(t, nil, err, "store error")
+
+		resp, body := get(t, "/img/media/abc.jpg?variant=thumb", nil)
+		testutil.WantEq(t, http.StatusOK, resp.StatusCode, "status")
+		testutil.WantEq(t, string(thumb), string(body), "body")
+		testutil.WantEq(t, "public, max-age=31536000, immutable", resp.Header.Get("Cache-Control"), "cache control")
+	})
+
+	t.Run("invalid_variant", func(t *testing.T) {
+		resp, _ := get(t, "/img/media/abc.jpg?variant=huge", nil)
+		testutil.WantEq(t, http.StatusBadRequest, resp.StatusCode, "status")
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		resp, _ := get(t, "/img/media/nope.jpg", nil)
+		testutil.WantEq(t, http.StatusNotFound, resp.StatusCode, "status")
+		testutil.WantEq(t, "", resp.Header.Get("Cache-Control"), "cache control")
+	})
+}
@@ -14,10 +14,12 @@ import (
 )
 
 type createTimelineItemInput struct {
-	Content   string          `json:"content"`
-	SpoilerOf *string         `json:"spoilerOf"`
-	NSFW      bool            `json:"nsfw"`
-	Media     []io.ReadSeeker `json:"-"`
+	Content       string          `json:"content"`
+	SpoilerOf     *string         `json:"spoilerOf"`
+	NSFW          bool            `json:"nsfw"`
+	Media         []io.ReadSeeker `json:"-"`
+	UploadIDs     []string        `json:"uploadIDs"`
+	MediaAltTexts []string        `json:"mediaAltTexts"`
 }
 
 func (h *handler) createTimelineItem(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +44,8 @@ func (h *handler) createTimelineItem(w http.ResponseWriter, r *http.Request) {
 		if v, err := strconv.ParseBool(r.FormValue("nsfw")); err == nil {
 			in.NSFW = v
 		}
+		in.UploadIDs = r.MultipartForm.Value["upload_ids"]
+		in.MediaAltTexts = r.MultipartForm.Value["media_alt_text"]
 		if files, ok := r.MultipartForm.File["media"]; ok {
 			for _, header := range files {
 				if header.Size > nakama.MaxMediaItemBytes {
@@ -67,7 +71,7 @@ func (h *handler) createTimelineItem(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	ti, err := h.svc.CreateTimelineItem(r.Context(), in.Content, in.SpoilerOf, in.NSFW, in.Media)
+	ti, err := h.svc.CreateTimelineItem(r.Context(), in.Content, in.SpoilerOf, in.NSFW, in.Media, in.UploadIDs, in.MediaAltTexts)
 	if err != nil {
 		h.respondErr(w, err)
 		return
@@ -81,6 +85,10 @@ func (h *handler) createTimelineItem(w http.ResponseWriter, r *http.Request) {
 		ti.Post.MediaURLs = []string{} // non null array
 	}
 
+	if ti.Post.Media == nil {
+		ti.Post.Media = []nakama.MediaItem{} // non null array
+	}
+
 	h.respond(w, ti, http.StatusCreated)
 }
 
@@ -111,6 +119,10 @@ func (h *handler) timeline(w http.ResponseWriter, r *http.Request) {
 		if tt[i].Post.MediaURLs == nil {
 			tt[i].Post.MediaURLs = []string{} // non null array
 		}
+
+		if tt[i].Post.Media == nil {
+			tt[i].Post.Media = []nakama.MediaItem{} // non null array
+		}
 	}
 
 	h.respond(w, paginatedRespBody{
@@ -127,7 +139,7 @@ func (h *handler) timelineItemStream(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	tt, err := h.svc.TimelineItemStream(ctx)
+	tt, err := h.svc.TimelineItemStream(ctx, lastEventID(r))
 	if err != nil {
 		h.respondErr(w, err)
 		return
@@ -138,19 +150,30 @@ func (h *handler) timelineItemStream(w http.ResponseWriter, r *http.Request) {
 	header.Set("Connection", "keep-alive")
 	header.Set("Content-Type", "text/event-stream; charset=utf-8")
 
-	select {
-	case ti := <-tt:
-		if ti.Post.Reactions == nil {
-			ti.Post.Reactions = []nakama.Reaction{} // non null array
-		}
-		if ti.Post.MediaURLs == nil {
-			ti.Post.MediaURLs = []string{} // non null array
-		}
+	for {
+		select {
+		case ev, ok := <-tt:
+			if !ok {
+				return
+			}
 
-		h.writeSSE(w, ti)
-		f.Flush()
-	case <-ctx.Done():
-		return
+			ti := ev.Item
+			if ti.Post.Reactions == nil {
+				ti.Post.Reactions = []nakama.Reaction{} // non null array
+			}
+			if ti.Post.MediaURLs == nil {
+				ti.Post.MediaURLs = []string{} // non null array
+			}
+
+			if ti.Post.Media == nil {
+				ti.Post.Media = []nakama.MediaItem{} // non null array
+			}
+
+			h.writeSSE(w, ev.ID, ti)
+			f.Flush()
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
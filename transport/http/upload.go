@@ -0,0 +1,58 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/matryer/way"
+
+	"github.com/nakamauwu/nakama"
+)
+
+func (h *handler) createUploadIntent(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var in nakama.CreateUploadIntent
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		h.respondErr(w, errBadRequest)
+		return
+	}
+
+	out, err := h.svc.CreateUploadIntent(r.Context(), in)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	h.respond(w, out, http.StatusCreated)
+}
+
+// putUpload receives the objects of signed local upload URLs,
+// used when the storage cannot presign them.
+func (h *handler) putUpload(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	ctx := r.Context()
+	q := r.URL.Query()
+	uploadID := way.Param(ctx, "upload_id")
+	body := http.MaxBytesReader(w, r.Body, r.ContentLength)
+	err := h.svc.PutUpload(ctx, uploadID, q.Get("expires"), q.Get("signature"), body, r.ContentLength)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) finalizeUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uploadID := way.Param(ctx, "upload_id")
+	out, err := h.svc.FinalizeUpload(ctx, uploadID)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	h.respond(w, out, http.StatusOK)
+}
@@ -94,14 +94,19 @@ func err2code(err error) int {
 		return http.StatusNotImplemented
 	case errors.Is(err, nakama.ErrGone):
 		return http.StatusGone
-	case err == errServiceUnavailable:
+	case errors.Is(err, nakama.ErrResourceExhausted):
+		return http.StatusTooManyRequests
+	case err == errServiceUnavailable ||
+		errors.Is(err, nakama.ErrUnavailable):
 		return http.StatusServiceUnavailable
 	}
 
 	return http.StatusInternalServerError
 }
 
-func (h *handler) writeSSE(w io.Writer, v interface{}) {
+// writeSSE writes v as a server-sent event.
+// An empty id omits the "id:" field.
+func (h *handler) writeSSE(w io.Writer, id string, v interface{}) {
 	b, err := json.Marshal(v)
 	if err != nil {
 		_ = h.logger.Log("err", fmt.Errorf("could not json marshal sse data: %w", err))
@@ -109,9 +114,33 @@ func (h *handler) writeSSE(w io.Writer, v interface{}) {
 		return
 	}
 
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
 	fmt.Fprintf(w, "data: %s\n\n", b)
 }
 
+// writeSSEEvent writes a named event without ID;
+// EventSource clients listen to those with addEventListener.
+func (h *handler) writeSSEEvent(w io.Writer, event string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		_ = h.logger.Log("err", fmt.Errorf("could not json marshal sse data: %w", err))
+		fmt.Fprintf(w, "event: error\ndata: %v\n\n", err)
+		return
+	}
+
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+}
+
+// lastEventID sent by EventSource when reconnecting.
+func lastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("last_event_id")
+}
+
 func (h *handler) proxy(w http.ResponseWriter, r *http.Request) {
 	targetStr := r.URL.Query().Get("target")
 	if targetStr == "" {
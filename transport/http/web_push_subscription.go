@@ -4,19 +4,49 @@ import (
 	"encoding/json"
 	"net/http"
 
-	"github.com/SherClockHolmes/webpush-go"
+	"github.com/matryer/way"
+
+	"github.com/nakamauwu/nakama"
 )
 
 func (h *handler) addWebPushSubscription(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	var sub webpush.Subscription
-	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+	var in nakama.AddWebPushSubscription
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		h.respondErr(w, errBadRequest)
 		return
 	}
 
-	err := h.svc.AddWebPushSubscription(r.Context(), sub)
+	in.UserAgent = r.UserAgent()
+
+	err := h.svc.AddWebPushSubscription(r.Context(), in)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) webPushSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.svc.WebPushSubscriptions(r.Context())
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	if subs == nil {
+		subs = []nakama.WebPushSubscription{} // non null array
+	}
+
+	h.respond(w, subs, http.StatusOK)
+}
+
+func (h *handler) deleteWebPushSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subscriptionID := way.Param(ctx, "subscription_id")
+	err := h.svc.DeleteWebPushSubscription(ctx, subscriptionID)
 	if err != nil {
 		h.respondErr(w, err)
 		return
@@ -24,3 +54,13 @@ func (h *handler) addWebPushSubscription(w http.ResponseWriter, r *http.Request)
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *handler) webPushPublicKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.svc.WebPushPublicKey(r.Context())
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	h.respond(w, key, http.StatusOK)
+}
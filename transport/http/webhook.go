@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"github.com/nakamauwu/nakama"
+)
+
+func (h *handler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var in nakama.CreateWebhook
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		h.respondErr(w, errBadRequest)
+		return
+	}
+
+	wh, err := h.svc.CreateWebhook(r.Context(), in)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	h.respond(w, wh, http.StatusCreated)
+}
+
+func (h *handler) webhooks(w http.ResponseWriter, r *http.Request) {
+	ww, err := h.svc.Webhooks(r.Context())
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	if ww == nil {
+		ww = []nakama.Webhook{} // non null array
+	}
+
+	h.respond(w, ww, http.StatusOK)
+}
+
+func (h *handler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webhookID := way.Param(ctx, "webhook_id")
+	err := h.svc.DeleteWebhook(ctx, webhookID)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) webhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	webhookID := way.Param(ctx, "webhook_id")
+	last, _ := strconv.ParseUint(q.Get("last"), 10, 64)
+	before := emptyStrPtr(q.Get("before"))
+	dd, err := h.svc.WebhookDeliveries(ctx, webhookID, last, before)
+	if err != nil {
+		h.respondErr(w, err)
+		return
+	}
+
+	if dd == nil {
+		dd = []nakama.WebhookDelivery{} // non null array
+	}
+
+	h.respond(w, paginatedRespBody{
+		Items:     dd,
+		EndCursor: dd.EndCursor(),
+	}, http.StatusOK)
+}
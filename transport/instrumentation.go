@@ -6,7 +6,6 @@ import (
 	"net/url"
 	"time"
 
-	"github.com/SherClockHolmes/webpush-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
@@ -14,46 +13,76 @@ import (
 )
 
 var (
-	reqDur_SendMagicLink           = promauto.NewHistogram(prometheus.HistogramOpts{Name: "send_magic_link_request_duration_ms"})
-	reqDur_ParseRedirectURI        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "parse_redirect_uri_request_duration_ms"})
-	reqDur_VerifyMagicLink         = promauto.NewHistogram(prometheus.HistogramOpts{Name: "verify_magic_link_request_duration_ms"})
-	reqDur_LoginFromProvider       = promauto.NewHistogram(prometheus.HistogramOpts{Name: "login_from_provider_request_duration_ms"})
-	reqDur_DevLogin                = promauto.NewHistogram(prometheus.HistogramOpts{Name: "dev_login_request_duration_ms"})
-	reqDur_AuthUserIDFromToken     = promauto.NewHistogram(prometheus.HistogramOpts{Name: "auth_user_id_from_token_request_duration_ms"})
-	reqDur_AuthUser                = promauto.NewHistogram(prometheus.HistogramOpts{Name: "auth_user_request_duration_ms"})
-	reqDur_Token                   = promauto.NewHistogram(prometheus.HistogramOpts{Name: "token_request_duration_ms"})
-	reqDur_CreateComment           = promauto.NewHistogram(prometheus.HistogramOpts{Name: "create_comment_request_duration_ms"})
-	reqDur_Comments                = promauto.NewHistogram(prometheus.HistogramOpts{Name: "comments_request_duration_ms"})
-	reqDur_CommentStream           = promauto.NewHistogram(prometheus.HistogramOpts{Name: "comment_stream_request_duration_ms"})
-	reqDur_UpdateComment           = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_comment_request_duration_ms"})
-	reqDur_DeleteComment           = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_comment_request_duration_ms"})
-	reqDur_ToggleCommentReaction   = promauto.NewHistogram(prometheus.HistogramOpts{Name: "toggle_comment_reaction_request_duration_ms"})
-	reqDur_Notifications           = promauto.NewHistogram(prometheus.HistogramOpts{Name: "notifications_request_duration_ms"})
-	reqDur_NotificationStream      = promauto.NewHistogram(prometheus.HistogramOpts{Name: "notification_stream_request_duration_ms"})
-	reqDur_HasUnreadNotifications  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "has_unread_notifications_request_duration_ms"})
-	reqDur_MarkNotificationAsRead  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "mark_notification_as_read_request_duration_ms"})
-	reqDur_MarkNotificationsAsRead = promauto.NewHistogram(prometheus.HistogramOpts{Name: "mark_notifications_as_read_request_duration_ms"})
-	reqDur_Posts                   = promauto.NewHistogram(prometheus.HistogramOpts{Name: "posts_request_duration_ms"})
-	reqDur_PostStream              = promauto.NewHistogram(prometheus.HistogramOpts{Name: "post_stream_request_duration_ms"})
-	reqDur_Post                    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "post_request_duration_ms"})
-	reqDur_UpdatePost              = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_post_request_duration_ms"})
-	reqDur_DeletePost              = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_post_request_duration_ms"})
-	reqDur_TogglePostReaction      = promauto.NewHistogram(prometheus.HistogramOpts{Name: "toggle_post_reaction_request_duration_ms"})
-	reqDur_TogglePostSubscription  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "toggle_post_subscription_request_duration_ms"})
-	reqDur_CreateTimelineItem      = promauto.NewHistogram(prometheus.HistogramOpts{Name: "create_timeline_item_request_duration_ms"})
-	reqDur_Timeline                = promauto.NewHistogram(prometheus.HistogramOpts{Name: "timeline_request_duration_ms"})
-	reqDur_TimelineItemStream      = promauto.NewHistogram(prometheus.HistogramOpts{Name: "timeline_item_stream_request_duration_ms"})
-	reqDur_DeleteTimelineItem      = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_timeline_item_request_duration_ms"})
-	reqDur_Users                   = promauto.NewHistogram(prometheus.HistogramOpts{Name: "users_request_duration_ms"})
-	reqDur_Usernames               = promauto.NewHistogram(prometheus.HistogramOpts{Name: "usernames_request_duration_ms"})
-	reqDur_User                    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "user_request_duration_ms"})
-	reqDur_UpdateUser              = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_user_request_duration_ms"})
-	reqDur_UpdateAvatar            = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_avatar_request_duration_ms"})
-	reqDur_UpdateCover             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_cover_request_duration_ms"})
-	reqDur_ToggleFollow            = promauto.NewHistogram(prometheus.HistogramOpts{Name: "toggle_follow_request_duration_ms"})
-	reqDur_Followers               = promauto.NewHistogram(prometheus.HistogramOpts{Name: "followers_request_duration_ms"})
-	reqDur_Followees               = promauto.NewHistogram(prometheus.HistogramOpts{Name: "followees_request_duration_ms"})
-	reqDur_AddWebPushSubscription  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "add_web_push_subscription_request_duration_ms"})
+	reqDur_SendMagicLink             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "send_magic_link_request_duration_ms"})
+	reqDur_ParseRedirectURI          = promauto.NewHistogram(prometheus.HistogramOpts{Name: "parse_redirect_uri_request_duration_ms"})
+	reqDur_VerifyMagicLink           = promauto.NewHistogram(prometheus.HistogramOpts{Name: "verify_magic_link_request_duration_ms"})
+	reqDur_LoginFromProvider         = promauto.NewHistogram(prometheus.HistogramOpts{Name: "login_from_provider_request_duration_ms"})
+	reqDur_DevLogin                  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "dev_login_request_duration_ms"})
+	reqDur_AuthUserIDFromToken       = promauto.NewHistogram(prometheus.HistogramOpts{Name: "auth_user_id_from_token_request_duration_ms"})
+	reqDur_AuthUser                  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "auth_user_request_duration_ms"})
+	reqDur_Token                     = promauto.NewHistogram(prometheus.HistogramOpts{Name: "token_request_duration_ms"})
+	reqDur_CreateComment             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "create_comment_request_duration_ms"})
+	reqDur_Comments                  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "comments_request_duration_ms"})
+	reqDur_CommentStream             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "comment_stream_request_duration_ms"})
+	reqDur_UpdateComment             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_comment_request_duration_ms"})
+	reqDur_DeleteComment             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_comment_request_duration_ms"})
+	reqDur_ToggleCommentReaction     = promauto.NewHistogram(prometheus.HistogramOpts{Name: "toggle_comment_reaction_request_duration_ms"})
+	reqDur_SendCommentTyping         = promauto.NewHistogram(prometheus.HistogramOpts{Name: "send_comment_typing_request_duration_ms"})
+	reqDur_CommentPresenceStream     = promauto.NewHistogram(prometheus.HistogramOpts{Name: "comment_presence_stream_request_duration_ms"})
+	reqDur_Notifications             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "notifications_request_duration_ms"})
+	reqDur_NotificationStream        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "notification_stream_request_duration_ms"})
+	reqDur_HasUnreadNotifications    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "has_unread_notifications_request_duration_ms"})
+	reqDur_MarkNotificationAsRead    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "mark_notification_as_read_request_duration_ms"})
+	reqDur_UnreadNotificationsCount  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "unread_notifications_count_request_duration_ms"})
+	reqDur_DeleteNotification        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_notification_request_duration_ms"})
+	reqDur_DeleteAllNotifications    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_all_notifications_request_duration_ms"})
+	reqDur_MarkNotificationsAsRead   = promauto.NewHistogram(prometheus.HistogramOpts{Name: "mark_notifications_as_read_request_duration_ms"})
+	reqDur_Posts                     = promauto.NewHistogram(prometheus.HistogramOpts{Name: "posts_request_duration_ms"})
+	reqDur_PostStream                = promauto.NewHistogram(prometheus.HistogramOpts{Name: "post_stream_request_duration_ms"})
+	reqDur_Post                      = promauto.NewHistogram(prometheus.HistogramOpts{Name: "post_request_duration_ms"})
+	reqDur_UpdatePost                = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_post_request_duration_ms"})
+	reqDur_DeletePost                = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_post_request_duration_ms"})
+	reqDur_TogglePostReaction        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "toggle_post_reaction_request_duration_ms"})
+	reqDur_TogglePostSubscription    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "toggle_post_subscription_request_duration_ms"})
+	reqDur_CreateTimelineItem        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "create_timeline_item_request_duration_ms"})
+	reqDur_Timeline                  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "timeline_request_duration_ms"})
+	reqDur_TimelineItemStream        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "timeline_item_stream_request_duration_ms"})
+	reqDur_DeleteTimelineItem        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_timeline_item_request_duration_ms"})
+	reqDur_Users                     = promauto.NewHistogram(prometheus.HistogramOpts{Name: "users_request_duration_ms"})
+	reqDur_Usernames                 = promauto.NewHistogram(prometheus.HistogramOpts{Name: "usernames_request_duration_ms"})
+	reqDur_User                      = promauto.NewHistogram(prometheus.HistogramOpts{Name: "user_request_duration_ms"})
+	reqDur_UpdateUser                = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_user_request_duration_ms"})
+	reqDur_UpdateAvatar              = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_avatar_request_duration_ms"})
+	reqDur_UpdateCover               = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_cover_request_duration_ms"})
+	reqDur_ToggleFollow              = promauto.NewHistogram(prometheus.HistogramOpts{Name: "toggle_follow_request_duration_ms"})
+	reqDur_Followers                 = promauto.NewHistogram(prometheus.HistogramOpts{Name: "followers_request_duration_ms"})
+	reqDur_Followees                 = promauto.NewHistogram(prometheus.HistogramOpts{Name: "followees_request_duration_ms"})
+	reqDur_AddWebPushSubscription    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "add_web_push_subscription_request_duration_ms"})
+	reqDur_WebPushSubscriptions      = promauto.NewHistogram(prometheus.HistogramOpts{Name: "web_push_subscriptions_request_duration_ms"})
+	reqDur_DeleteWebPushSubscription = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_web_push_subscription_request_duration_ms"})
+	reqDur_WebPushPublicKey          = promauto.NewHistogram(prometheus.HistogramOpts{Name: "web_push_public_key_request_duration_ms"})
+	reqDur_EmailDigestSettings       = promauto.NewHistogram(prometheus.HistogramOpts{Name: "email_digest_settings_request_duration_ms"})
+	reqDur_UpdateEmailDigestSettings = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_email_digest_settings_request_duration_ms"})
+	reqDur_UnsubscribeEmailDigest    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "unsubscribe_email_digest_request_duration_ms"})
+	reqDur_CreateWebhook             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "create_webhook_request_duration_ms"})
+	reqDur_Webhooks                  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "webhooks_request_duration_ms"})
+	reqDur_DeleteWebhook             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "delete_webhook_request_duration_ms"})
+	reqDur_WebhookDeliveries         = promauto.NewHistogram(prometheus.HistogramOpts{Name: "webhook_deliveries_request_duration_ms"})
+	reqDur_CreateConversation        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "create_conversation_request_duration_ms"})
+	reqDur_Conversations             = promauto.NewHistogram(prometheus.HistogramOpts{Name: "conversations_request_duration_ms"})
+	reqDur_Conversation              = promauto.NewHistogram(prometheus.HistogramOpts{Name: "conversation_request_duration_ms"})
+	reqDur_SendMessage               = promauto.NewHistogram(prometheus.HistogramOpts{Name: "send_message_request_duration_ms"})
+	reqDur_Messages                  = promauto.NewHistogram(prometheus.HistogramOpts{Name: "messages_request_duration_ms"})
+	reqDur_ConversationStream        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "conversation_stream_request_duration_ms"})
+	reqDur_MarkConversationAsRead    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "mark_conversation_as_read_request_duration_ms"})
+	reqDur_UnreadMessagesCount       = promauto.NewHistogram(prometheus.HistogramOpts{Name: "unread_messages_count_request_duration_ms"})
+	reqDur_DMSettings                = promauto.NewHistogram(prometheus.HistogramOpts{Name: "dm_settings_request_duration_ms"})
+	reqDur_UpdateDMSettings          = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_dm_settings_request_duration_ms"})
+	reqDur_ToggleBlock               = promauto.NewHistogram(prometheus.HistogramOpts{Name: "toggle_block_request_duration_ms"})
+	reqDur_CreateUploadIntent        = promauto.NewHistogram(prometheus.HistogramOpts{Name: "create_upload_intent_request_duration_ms"})
+	reqDur_PutUpload                 = promauto.NewHistogram(prometheus.HistogramOpts{Name: "put_upload_request_duration_ms"})
+	reqDur_FinalizeUpload            = promauto.NewHistogram(prometheus.HistogramOpts{Name: "finalize_upload_request_duration_ms"})
+	reqDur_UpdatePostMediaAltText    = promauto.NewHistogram(prometheus.HistogramOpts{Name: "update_post_media_alt_text_request_duration_ms"})
 )
 
 type ServiceWithInstrumentation struct {
@@ -130,11 +159,11 @@ func (mw *ServiceWithInstrumentation) Comments(ctx context.Context, postID strin
 	return mw.Next.Comments(ctx, postID, last, before)
 }
 
-func (mw *ServiceWithInstrumentation) CommentStream(ctx context.Context, postID string) (<-chan nakama.Comment, error) {
+func (mw *ServiceWithInstrumentation) CommentStream(ctx context.Context, postID, lastEventID string) (<-chan nakama.StreamEvent[nakama.Comment], error) {
 	defer func(begin time.Time) {
 		reqDur_CommentStream.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
 	}(time.Now())
-	return mw.Next.CommentStream(ctx, postID)
+	return mw.Next.CommentStream(ctx, postID, lastEventID)
 }
 
 func (mw *ServiceWithInstrumentation) UpdateComment(ctx context.Context, in nakama.UpdateComment) (nakama.UpdatedComment, error) {
@@ -158,6 +187,20 @@ func (mw *ServiceWithInstrumentation) ToggleCommentReaction(ctx context.Context,
 	return mw.Next.ToggleCommentReaction(ctx, commentID, in)
 }
 
+func (mw *ServiceWithInstrumentation) SendCommentTyping(ctx context.Context, postID string) error {
+	defer func(begin time.Time) {
+		reqDur_SendCommentTyping.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.SendCommentTyping(ctx, postID)
+}
+
+func (mw *ServiceWithInstrumentation) CommentPresenceStream(ctx context.Context, postID string) (<-chan nakama.CommentPresence, error) {
+	defer func(begin time.Time) {
+		reqDur_CommentPresenceStream.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.CommentPresenceStream(ctx, postID)
+}
+
 func (mw *ServiceWithInstrumentation) Notifications(ctx context.Context, last uint64, before *string) (nakama.Notifications, error) {
 	defer func(begin time.Time) {
 		reqDur_Notifications.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
@@ -165,11 +208,11 @@ func (mw *ServiceWithInstrumentation) Notifications(ctx context.Context, last ui
 	return mw.Next.Notifications(ctx, last, before)
 }
 
-func (mw *ServiceWithInstrumentation) NotificationStream(ctx context.Context) (<-chan nakama.Notification, error) {
+func (mw *ServiceWithInstrumentation) NotificationStream(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.Notification], error) {
 	defer func(begin time.Time) {
 		reqDur_NotificationStream.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
 	}(time.Now())
-	return mw.Next.NotificationStream(ctx)
+	return mw.Next.NotificationStream(ctx, lastEventID)
 }
 
 func (mw *ServiceWithInstrumentation) HasUnreadNotifications(ctx context.Context) (bool, error) {
@@ -193,6 +236,27 @@ func (mw *ServiceWithInstrumentation) MarkNotificationsAsRead(ctx context.Contex
 	return mw.Next.MarkNotificationsAsRead(ctx)
 }
 
+func (mw *ServiceWithInstrumentation) UnreadNotificationsCount(ctx context.Context) (uint64, error) {
+	defer func(begin time.Time) {
+		reqDur_UnreadNotificationsCount.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.UnreadNotificationsCount(ctx)
+}
+
+func (mw *ServiceWithInstrumentation) DeleteNotification(ctx context.Context, notificationID string) error {
+	defer func(begin time.Time) {
+		reqDur_DeleteNotification.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.DeleteNotification(ctx, notificationID)
+}
+
+func (mw *ServiceWithInstrumentation) DeleteAllNotifications(ctx context.Context) error {
+	defer func(begin time.Time) {
+		reqDur_DeleteAllNotifications.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.DeleteAllNotifications(ctx)
+}
+
 func (mw *ServiceWithInstrumentation) Posts(ctx context.Context, last uint64, before *string, opts ...nakama.PostsOpt) (nakama.Posts, error) {
 	defer func(begin time.Time) {
 		reqDur_Posts.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
@@ -242,11 +306,11 @@ func (mw *ServiceWithInstrumentation) TogglePostSubscription(ctx context.Context
 	return mw.Next.TogglePostSubscription(ctx, postID)
 }
 
-func (mw *ServiceWithInstrumentation) CreateTimelineItem(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker) (nakama.TimelineItem, error) {
+func (mw *ServiceWithInstrumentation) CreateTimelineItem(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker, uploadIDs []string, mediaAltTexts []string) (nakama.TimelineItem, error) {
 	defer func(begin time.Time) {
 		reqDur_CreateTimelineItem.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
 	}(time.Now())
-	return mw.Next.CreateTimelineItem(ctx, content, spoilerOf, nsfw, media)
+	return mw.Next.CreateTimelineItem(ctx, content, spoilerOf, nsfw, media, uploadIDs, mediaAltTexts)
 }
 
 func (mw *ServiceWithInstrumentation) Timeline(ctx context.Context, last uint64, before *string) (nakama.Timeline, error) {
@@ -256,11 +320,11 @@ func (mw *ServiceWithInstrumentation) Timeline(ctx context.Context, last uint64,
 	return mw.Next.Timeline(ctx, last, before)
 }
 
-func (mw *ServiceWithInstrumentation) TimelineItemStream(ctx context.Context) (<-chan nakama.TimelineItem, error) {
+func (mw *ServiceWithInstrumentation) TimelineItemStream(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.TimelineItem], error) {
 	defer func(begin time.Time) {
 		reqDur_TimelineItemStream.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
 	}(time.Now())
-	return mw.Next.TimelineItemStream(ctx)
+	return mw.Next.TimelineItemStream(ctx, lastEventID)
 }
 
 func (mw *ServiceWithInstrumentation) DeleteTimelineItem(ctx context.Context, timelineItemID string) error {
@@ -333,9 +397,184 @@ func (mw *ServiceWithInstrumentation) Followees(ctx context.Context, username st
 	return mw.Next.Followees(ctx, username, first, after)
 }
 
-func (mw *ServiceWithInstrumentation) AddWebPushSubscription(ctx context.Context, sub webpush.Subscription) error {
+func (mw *ServiceWithInstrumentation) AddWebPushSubscription(ctx context.Context, in nakama.AddWebPushSubscription) error {
 	defer func(begin time.Time) {
 		reqDur_AddWebPushSubscription.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
 	}(time.Now())
-	return mw.Next.AddWebPushSubscription(ctx, sub)
+	return mw.Next.AddWebPushSubscription(ctx, in)
+}
+
+func (mw *ServiceWithInstrumentation) WebPushSubscriptions(ctx context.Context) ([]nakama.WebPushSubscription, error) {
+	defer func(begin time.Time) {
+		reqDur_WebPushSubscriptions.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.WebPushSubscriptions(ctx)
+}
+
+func (mw *ServiceWithInstrumentation) DeleteWebPushSubscription(ctx context.Context, subscriptionID string) error {
+	defer func(begin time.Time) {
+		reqDur_DeleteWebPushSubscription.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.DeleteWebPushSubscription(ctx, subscriptionID)
+}
+
+func (mw *ServiceWithInstrumentation) WebPushPublicKey(ctx context.Context) (string, error) {
+	defer func(begin time.Time) {
+		reqDur_WebPushPublicKey.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.WebPushPublicKey(ctx)
+}
+
+func (mw *ServiceWithInstrumentation) EmailDigestSettings(ctx context.Context) (nakama.EmailDigestSettings, error) {
+	defer func(begin time.Time) {
+		reqDur_EmailDigestSettings.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.EmailDigestSettings(ctx)
+}
+
+func (mw *ServiceWithInstrumentation) UpdateEmailDigestSettings(ctx context.Context, in nakama.EmailDigestSettings) error {
+	defer func(begin time.Time) {
+		reqDur_UpdateEmailDigestSettings.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.UpdateEmailDigestSettings(ctx, in)
+}
+
+func (mw *ServiceWithInstrumentation) UnsubscribeEmailDigest(ctx context.Context, userID, token string) error {
+	defer func(begin time.Time) {
+		reqDur_UnsubscribeEmailDigest.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.UnsubscribeEmailDigest(ctx, userID, token)
+}
+
+func (mw *ServiceWithInstrumentation) CreateWebhook(ctx context.Context, in nakama.CreateWebhook) (nakama.Webhook, error) {
+	defer func(begin time.Time) {
+		reqDur_CreateWebhook.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.CreateWebhook(ctx, in)
+}
+
+func (mw *ServiceWithInstrumentation) Webhooks(ctx context.Context) ([]nakama.Webhook, error) {
+	defer func(begin time.Time) {
+		reqDur_Webhooks.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.Webhooks(ctx)
+}
+
+func (mw *ServiceWithInstrumentation) DeleteWebhook(ctx context.Context, webhookID string) error {
+	defer func(begin time.Time) {
+		reqDur_DeleteWebhook.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.DeleteWebhook(ctx, webhookID)
+}
+
+func (mw *ServiceWithInstrumentation) WebhookDeliveries(ctx context.Context, webhookID string, last uint64, before *string) (nakama.WebhookDeliveries, error) {
+	defer func(begin time.Time) {
+		reqDur_WebhookDeliveries.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.WebhookDeliveries(ctx, webhookID, last, before)
+}
+
+func (mw *ServiceWithInstrumentation) CreateConversation(ctx context.Context, usernames []string) (nakama.Conversation, error) {
+	defer func(begin time.Time) {
+		reqDur_CreateConversation.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.CreateConversation(ctx, usernames)
+}
+
+func (mw *ServiceWithInstrumentation) Conversations(ctx context.Context, last uint64, before *string) (nakama.Conversations, error) {
+	defer func(begin time.Time) {
+		reqDur_Conversations.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.Conversations(ctx, last, before)
+}
+
+func (mw *ServiceWithInstrumentation) Conversation(ctx context.Context, conversationID string) (nakama.Conversation, error) {
+	defer func(begin time.Time) {
+		reqDur_Conversation.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.Conversation(ctx, conversationID)
+}
+
+func (mw *ServiceWithInstrumentation) SendMessage(ctx context.Context, conversationID, content string, media []io.ReadSeeker) (nakama.Message, error) {
+	defer func(begin time.Time) {
+		reqDur_SendMessage.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.SendMessage(ctx, conversationID, content, media)
+}
+
+func (mw *ServiceWithInstrumentation) Messages(ctx context.Context, conversationID string, last uint64, before *string) (nakama.Messages, error) {
+	defer func(begin time.Time) {
+		reqDur_Messages.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.Messages(ctx, conversationID, last, before)
+}
+
+func (mw *ServiceWithInstrumentation) ConversationStream(ctx context.Context, conversationID, lastEventID string) (<-chan nakama.StreamEvent[nakama.ConversationEvent], error) {
+	defer func(begin time.Time) {
+		reqDur_ConversationStream.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.ConversationStream(ctx, conversationID, lastEventID)
+}
+
+func (mw *ServiceWithInstrumentation) MarkConversationAsRead(ctx context.Context, conversationID string) error {
+	defer func(begin time.Time) {
+		reqDur_MarkConversationAsRead.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.MarkConversationAsRead(ctx, conversationID)
+}
+
+func (mw *ServiceWithInstrumentation) UnreadMessagesCount(ctx context.Context) (uint64, error) {
+	defer func(begin time.Time) {
+		reqDur_UnreadMessagesCount.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.UnreadMessagesCount(ctx)
+}
+
+func (mw *ServiceWithInstrumentation) DMSettings(ctx context.Context) (nakama.DMSettings, error) {
+	defer func(begin time.Time) {
+		reqDur_DMSettings.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.DMSettings(ctx)
+}
+
+func (mw *ServiceWithInstrumentation) UpdateDMSettings(ctx context.Context, in nakama.DMSettings) error {
+	defer func(begin time.Time) {
+		reqDur_UpdateDMSettings.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.UpdateDMSettings(ctx, in)
+}
+
+func (mw *ServiceWithInstrumentation) ToggleBlock(ctx context.Context, username string) (nakama.ToggleBlockOutput, error) {
+	defer func(begin time.Time) {
+		reqDur_ToggleBlock.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.ToggleBlock(ctx, username)
+}
+
+func (mw *ServiceWithInstrumentation) CreateUploadIntent(ctx context.Context, in nakama.CreateUploadIntent) (nakama.UploadIntent, error) {
+	defer func(begin time.Time) {
+		reqDur_CreateUploadIntent.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.CreateUploadIntent(ctx, in)
+}
+
+func (mw *ServiceWithInstrumentation) PutUpload(ctx context.Context, uploadID, expires, signature string, r io.Reader, size int64) error {
+	defer func(begin time.Time) {
+		reqDur_PutUpload.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.PutUpload(ctx, uploadID, expires, signature, r, size)
+}
+
+func (mw *ServiceWithInstrumentation) FinalizeUpload(ctx context.Context, uploadID string) (nakama.FinalizedUpload, error) {
+	defer func(begin time.Time) {
+		reqDur_FinalizeUpload.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.FinalizeUpload(ctx, uploadID)
+}
+
+func (mw *ServiceWithInstrumentation) UpdatePostMediaAltText(ctx context.Context, postID, name string, altText *string) error {
+	defer func(begin time.Time) {
+		reqDur_UpdatePostMediaAltText.Observe(float64(time.Since(begin)) / float64(time.Millisecond))
+	}(time.Now())
+	return mw.Next.UpdatePostMediaAltText(ctx, postID, name, altText)
 }
@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/url"
 
-	"github.com/SherClockHolmes/webpush-go"
 	"github.com/nakamauwu/nakama"
 )
 
@@ -27,28 +26,34 @@ type Service interface {
 
 	CreateComment(ctx context.Context, postID, content string) (nakama.Comment, error)
 	Comments(ctx context.Context, postID string, last uint64, before *string) (nakama.Comments, error)
-	CommentStream(ctx context.Context, postID string) (<-chan nakama.Comment, error)
+	CommentStream(ctx context.Context, postID, lastEventID string) (<-chan nakama.StreamEvent[nakama.Comment], error)
 	UpdateComment(ctx context.Context, in nakama.UpdateComment) (nakama.UpdatedComment, error)
 	DeleteComment(ctx context.Context, commentID string) error
 	ToggleCommentReaction(ctx context.Context, commentID string, in nakama.ReactionInput) ([]nakama.Reaction, error)
+	SendCommentTyping(ctx context.Context, postID string) error
+	CommentPresenceStream(ctx context.Context, postID string) (<-chan nakama.CommentPresence, error)
 
 	Notifications(ctx context.Context, last uint64, before *string) (nakama.Notifications, error)
-	NotificationStream(ctx context.Context) (<-chan nakama.Notification, error)
+	NotificationStream(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.Notification], error)
 	HasUnreadNotifications(ctx context.Context) (bool, error)
+	UnreadNotificationsCount(ctx context.Context) (uint64, error)
 	MarkNotificationAsRead(ctx context.Context, notificationID string) error
 	MarkNotificationsAsRead(ctx context.Context) error
+	DeleteNotification(ctx context.Context, notificationID string) error
+	DeleteAllNotifications(ctx context.Context) error
 
 	Posts(ctx context.Context, last uint64, before *string, opts ...nakama.PostsOpt) (nakama.Posts, error)
 	PostStream(ctx context.Context) (<-chan nakama.Post, error)
 	Post(ctx context.Context, postID string) (nakama.Post, error)
 	UpdatePost(ctx context.Context, postID string, in nakama.UpdatePost) (nakama.UpdatedPost, error)
+	UpdatePostMediaAltText(ctx context.Context, postID, name string, altText *string) error
 	DeletePost(ctx context.Context, postID string) error
 	TogglePostReaction(ctx context.Context, postID string, in nakama.ReactionInput) ([]nakama.Reaction, error)
 	TogglePostSubscription(ctx context.Context, postID string) (nakama.ToggleSubscriptionOutput, error)
 
-	CreateTimelineItem(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker) (nakama.TimelineItem, error)
+	CreateTimelineItem(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker, uploadIDs []string, mediaAltTexts []string) (nakama.TimelineItem, error)
 	Timeline(ctx context.Context, last uint64, before *string) (nakama.Timeline, error)
-	TimelineItemStream(ctx context.Context) (<-chan nakama.TimelineItem, error)
+	TimelineItemStream(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.TimelineItem], error)
 	DeleteTimelineItem(ctx context.Context, timelineItemID string) error
 
 	Users(ctx context.Context, search string, first uint64, after *string) (nakama.UserProfiles, error)
@@ -61,5 +66,33 @@ type Service interface {
 	Followers(ctx context.Context, username string, first uint64, after *string) (nakama.UserProfiles, error)
 	Followees(ctx context.Context, username string, first uint64, after *string) (nakama.UserProfiles, error)
 
-	AddWebPushSubscription(ctx context.Context, sub webpush.Subscription) error
+	AddWebPushSubscription(ctx context.Context, in nakama.AddWebPushSubscription) error
+	WebPushSubscriptions(ctx context.Context) ([]nakama.WebPushSubscription, error)
+	DeleteWebPushSubscription(ctx context.Context, subscriptionID string) error
+	WebPushPublicKey(ctx context.Context) (string, error)
+
+	CreateWebhook(ctx context.Context, in nakama.CreateWebhook) (nakama.Webhook, error)
+	Webhooks(ctx context.Context) ([]nakama.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID string) error
+	WebhookDeliveries(ctx context.Context, webhookID string, last uint64, before *string) (nakama.WebhookDeliveries, error)
+
+	CreateConversation(ctx context.Context, usernames []string) (nakama.Conversation, error)
+	Conversations(ctx context.Context, last uint64, before *string) (nakama.Conversations, error)
+	Conversation(ctx context.Context, conversationID string) (nakama.Conversation, error)
+	SendMessage(ctx context.Context, conversationID, content string, media []io.ReadSeeker) (nakama.Message, error)
+	Messages(ctx context.Context, conversationID string, last uint64, before *string) (nakama.Messages, error)
+	ConversationStream(ctx context.Context, conversationID, lastEventID string) (<-chan nakama.StreamEvent[nakama.ConversationEvent], error)
+	MarkConversationAsRead(ctx context.Context, conversationID string) error
+	UnreadMessagesCount(ctx context.Context) (uint64, error)
+	DMSettings(ctx context.Context) (nakama.DMSettings, error)
+	UpdateDMSettings(ctx context.Context, in nakama.DMSettings) error
+	ToggleBlock(ctx context.Context, username string) (nakama.ToggleBlockOutput, error)
+
+	CreateUploadIntent(ctx context.Context, in nakama.CreateUploadIntent) (nakama.UploadIntent, error)
+	PutUpload(ctx context.Context, uploadID, expires, signature string, r io.Reader, size int64) error
+	FinalizeUpload(ctx context.Context, uploadID string) (nakama.FinalizedUpload, error)
+
+	EmailDigestSettings(ctx context.Context) (nakama.EmailDigestSettings, error)
+	UpdateEmailDigestSettings(ctx context.Context, in nakama.EmailDigestSettings) error
+	UnsubscribeEmailDigest(ctx context.Context, userID, token string) error
 }
@@ -5,7 +5,6 @@ package transport
 
 import (
 	"context"
-	"github.com/SherClockHolmes/webpush-go"
 	"github.com/nakamauwu/nakama"
 	"io"
 	"net/url"
@@ -22,7 +21,7 @@ var _ Service = &ServiceMock{}
 //
 //		// make and configure a mocked Service
 //		mockedService := &ServiceMock{
-//			AddWebPushSubscriptionFunc: func(ctx context.Context, sub webpush.Subscription) error {
+//			AddWebPushSubscriptionFunc: func(ctx context.Context, in nakama.AddWebPushSubscription) error {
 //				panic("mock out the AddWebPushSubscription method")
 //			},
 //			AuthUserFunc: func(ctx context.Context) (nakama.User, error) {
@@ -31,30 +30,72 @@ var _ Service = &ServiceMock{}
 //			AuthUserIDFromTokenFunc: func(token string) (string, error) {
 //				panic("mock out the AuthUserIDFromToken method")
 //			},
-//			CommentStreamFunc: func(ctx context.Context, postID string) (<-chan nakama.Comment, error) {
+//			CommentPresenceStreamFunc: func(ctx context.Context, postID string) (<-chan nakama.CommentPresence, error) {
+//				panic("mock out the CommentPresenceStream method")
+//			},
+//			CommentStreamFunc: func(ctx context.Context, postID string, lastEventID string) (<-chan nakama.StreamEvent[nakama.Comment], error) {
 //				panic("mock out the CommentStream method")
 //			},
 //			CommentsFunc: func(ctx context.Context, postID string, last uint64, before *string) (nakama.Comments, error) {
 //				panic("mock out the Comments method")
 //			},
+//			ConversationFunc: func(ctx context.Context, conversationID string) (nakama.Conversation, error) {
+//				panic("mock out the Conversation method")
+//			},
+//			ConversationStreamFunc: func(ctx context.Context, conversationID string, lastEventID string) (<-chan nakama.StreamEvent[nakama.ConversationEvent], error) {
+//				panic("mock out the ConversationStream method")
+//			},
+//			ConversationsFunc: func(ctx context.Context, last uint64, before *string) (nakama.Conversations, error) {
+//				panic("mock out the Conversations method")
+//			},
 //			CreateCommentFunc: func(ctx context.Context, postID string, content string) (nakama.Comment, error) {
 //				panic("mock out the CreateComment method")
 //			},
-//			CreateTimelineItemFunc: func(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker) (nakama.TimelineItem, error) {
+//			CreateConversationFunc: func(ctx context.Context, usernames []string) (nakama.Conversation, error) {
+//				panic("mock out the CreateConversation method")
+//			},
+//			CreateTimelineItemFunc: func(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker, uploadIDs []string, mediaAltTexts []string) (nakama.TimelineItem, error) {
 //				panic("mock out the CreateTimelineItem method")
 //			},
+//			CreateUploadIntentFunc: func(ctx context.Context, in nakama.CreateUploadIntent) (nakama.UploadIntent, error) {
+//				panic("mock out the CreateUploadIntent method")
+//			},
+//			CreateWebhookFunc: func(ctx context.Context, in nakama.CreateWebhook) (nakama.Webhook, error) {
+//				panic("mock out the CreateWebhook method")
+//			},
+//			DMSettingsFunc: func(ctx context.Context) (nakama.DMSettings, error) {
+//				panic("mock out the DMSettings method")
+//			},
+//			DeleteAllNotificationsFunc: func(ctx context.Context) error {
+//				panic("mock out the DeleteAllNotifications method")
+//			},
 //			DeleteCommentFunc: func(ctx context.Context, commentID string) error {
 //				panic("mock out the DeleteComment method")
 //			},
+//			DeleteNotificationFunc: func(ctx context.Context, notificationID string) error {
+//				panic("mock out the DeleteNotification method")
+//			},
 //			DeletePostFunc: func(ctx context.Context, postID string) error {
 //				panic("mock out the DeletePost method")
 //			},
 //			DeleteTimelineItemFunc: func(ctx context.Context, timelineItemID string) error {
 //				panic("mock out the DeleteTimelineItem method")
 //			},
+//			DeleteWebPushSubscriptionFunc: func(ctx context.Context, subscriptionID string) error {
+//				panic("mock out the DeleteWebPushSubscription method")
+//			},
+//			DeleteWebhookFunc: func(ctx context.Context, webhookID string) error {
+//				panic("mock out the DeleteWebhook method")
+//			},
 //			DevLoginFunc: func(ctx context.Context, email string) (nakama.AuthOutput, error) {
 //				panic("mock out the DevLogin method")
 //			},
+//			EmailDigestSettingsFunc: func(ctx context.Context) (nakama.EmailDigestSettings, error) {
+//				panic("mock out the EmailDigestSettings method")
+//			},
+//			FinalizeUploadFunc: func(ctx context.Context, uploadID string) (nakama.FinalizedUpload, error) {
+//				panic("mock out the FinalizeUpload method")
+//			},
 //			FolloweesFunc: func(ctx context.Context, username string, first uint64, after *string) (nakama.UserProfiles, error) {
 //				panic("mock out the Followees method")
 //			},
@@ -67,13 +108,19 @@ var _ Service = &ServiceMock{}
 //			LoginFromProviderFunc: func(ctx context.Context, name string, user nakama.ProvidedUser) (nakama.User, error) {
 //				panic("mock out the LoginFromProvider method")
 //			},
+//			MarkConversationAsReadFunc: func(ctx context.Context, conversationID string) error {
+//				panic("mock out the MarkConversationAsRead method")
+//			},
 //			MarkNotificationAsReadFunc: func(ctx context.Context, notificationID string) error {
 //				panic("mock out the MarkNotificationAsRead method")
 //			},
 //			MarkNotificationsAsReadFunc: func(ctx context.Context) error {
 //				panic("mock out the MarkNotificationsAsRead method")
 //			},
-//			NotificationStreamFunc: func(ctx context.Context) (<-chan nakama.Notification, error) {
+//			MessagesFunc: func(ctx context.Context, conversationID string, last uint64, before *string) (nakama.Messages, error) {
+//				panic("mock out the Messages method")
+//			},
+//			NotificationStreamFunc: func(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.Notification], error) {
 //				panic("mock out the NotificationStream method")
 //			},
 //			NotificationsFunc: func(ctx context.Context, last uint64, before *string) (nakama.Notifications, error) {
@@ -91,15 +138,27 @@ var _ Service = &ServiceMock{}
 //			PostsFunc: func(ctx context.Context, last uint64, before *string, opts ...nakama.PostsOpt) (nakama.Posts, error) {
 //				panic("mock out the Posts method")
 //			},
+//			PutUploadFunc: func(ctx context.Context, uploadID string, expires string, signature string, r io.Reader, size int64) error {
+//				panic("mock out the PutUpload method")
+//			},
+//			SendCommentTypingFunc: func(ctx context.Context, postID string) error {
+//				panic("mock out the SendCommentTyping method")
+//			},
 //			SendMagicLinkFunc: func(ctx context.Context, in nakama.SendMagicLink) error {
 //				panic("mock out the SendMagicLink method")
 //			},
+//			SendMessageFunc: func(ctx context.Context, conversationID string, content string, media []io.ReadSeeker) (nakama.Message, error) {
+//				panic("mock out the SendMessage method")
+//			},
 //			TimelineFunc: func(ctx context.Context, last uint64, before *string) (nakama.Timeline, error) {
 //				panic("mock out the Timeline method")
 //			},
-//			TimelineItemStreamFunc: func(ctx context.Context) (<-chan nakama.TimelineItem, error) {
+//			TimelineItemStreamFunc: func(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.TimelineItem], error) {
 //				panic("mock out the TimelineItemStream method")
 //			},
+//			ToggleBlockFunc: func(ctx context.Context, username string) (nakama.ToggleBlockOutput, error) {
+//				panic("mock out the ToggleBlock method")
+//			},
 //			ToggleCommentReactionFunc: func(ctx context.Context, commentID string, in nakama.ReactionInput) ([]nakama.Reaction, error) {
 //				panic("mock out the ToggleCommentReaction method")
 //			},
@@ -115,6 +174,15 @@ var _ Service = &ServiceMock{}
 //			TokenFunc: func(ctx context.Context) (nakama.TokenOutput, error) {
 //				panic("mock out the Token method")
 //			},
+//			UnreadMessagesCountFunc: func(ctx context.Context) (uint64, error) {
+//				panic("mock out the UnreadMessagesCount method")
+//			},
+//			UnreadNotificationsCountFunc: func(ctx context.Context) (uint64, error) {
+//				panic("mock out the UnreadNotificationsCount method")
+//			},
+//			UnsubscribeEmailDigestFunc: func(ctx context.Context, userID string, token string) error {
+//				panic("mock out the UnsubscribeEmailDigest method")
+//			},
 //			UpdateAvatarFunc: func(ctx context.Context, r io.ReadSeeker) (string, error) {
 //				panic("mock out the UpdateAvatar method")
 //			},
@@ -124,9 +192,18 @@ var _ Service = &ServiceMock{}
 //			UpdateCoverFunc: func(ctx context.Context, r io.ReadSeeker) (string, error) {
 //				panic("mock out the UpdateCover method")
 //			},
+//			UpdateDMSettingsFunc: func(ctx context.Context, in nakama.DMSettings) error {
+//				panic("mock out the UpdateDMSettings method")
+//			},
+//			UpdateEmailDigestSettingsFunc: func(ctx context.Context, in nakama.EmailDigestSettings) error {
+//				panic("mock out the UpdateEmailDigestSettings method")
+//			},
 //			UpdatePostFunc: func(ctx context.Context, postID string, in nakama.UpdatePost) (nakama.UpdatedPost, error) {
 //				panic("mock out the UpdatePost method")
 //			},
+//			UpdatePostMediaAltTextFunc: func(ctx context.Context, postID string, name string, altText *string) error {
+//				panic("mock out the UpdatePostMediaAltText method")
+//			},
 //			UpdateUserFunc: func(ctx context.Context, params nakama.UpdateUserParams) error {
 //				panic("mock out the UpdateUser method")
 //			},
@@ -142,6 +219,18 @@ var _ Service = &ServiceMock{}
 //			VerifyMagicLinkFunc: func(ctx context.Context, email string, code string, username *string) (nakama.AuthOutput, error) {
 //				panic("mock out the VerifyMagicLink method")
 //			},
+//			WebPushPublicKeyFunc: func(ctx context.Context) (string, error) {
+//				panic("mock out the WebPushPublicKey method")
+//			},
+//			WebPushSubscriptionsFunc: func(ctx context.Context) ([]nakama.WebPushSubscription, error) {
+//				panic("mock out the WebPushSubscriptions method")
+//			},
+//			WebhookDeliveriesFunc: func(ctx context.Context, webhookID string, last uint64, before *string) (nakama.WebhookDeliveries, error) {
+//				panic("mock out the WebhookDeliveries method")
+//			},
+//			WebhooksFunc: func(ctx context.Context) ([]nakama.Webhook, error) {
+//				panic("mock out the Webhooks method")
+//			},
 //		}
 //
 //		// use mockedService in code that requires Service
@@ -150,7 +239,7 @@ var _ Service = &ServiceMock{}
 //	}
 type ServiceMock struct {
 	// AddWebPushSubscriptionFunc mocks the AddWebPushSubscription method.
-	AddWebPushSubscriptionFunc func(ctx context.Context, sub webpush.Subscription) error
+	AddWebPushSubscriptionFunc func(ctx context.Context, in nakama.AddWebPushSubscription) error
 
 	// AuthUserFunc mocks the AuthUser method.
 	AuthUserFunc func(ctx context.Context) (nakama.User, error)
@@ -158,30 +247,72 @@ type ServiceMock struct {
 	// AuthUserIDFromTokenFunc mocks the AuthUserIDFromToken method.
 	AuthUserIDFromTokenFunc func(token string) (string, error)
 
+	// CommentPresenceStreamFunc mocks the CommentPresenceStream method.
+	CommentPresenceStreamFunc func(ctx context.Context, postID string) (<-chan nakama.CommentPresence, error)
+
 	// CommentStreamFunc mocks the CommentStream method.
-	CommentStreamFunc func(ctx context.Context, postID string) (<-chan nakama.Comment, error)
+	CommentStreamFunc func(ctx context.Context, postID string, lastEventID string) (<-chan nakama.StreamEvent[nakama.Comment], error)
 
 	// CommentsFunc mocks the Comments method.
 	CommentsFunc func(ctx context.Context, postID string, last uint64, before *string) (nakama.Comments, error)
 
+	// ConversationFunc mocks the Conversation method.
+	ConversationFunc func(ctx context.Context, conversationID string) (nakama.Conversation, error)
+
+	// ConversationStreamFunc mocks the ConversationStream method.
+	ConversationStreamFunc func(ctx context.Context, conversationID string, lastEventID string) (<-chan nakama.StreamEvent[nakama.ConversationEvent], error)
+
+	// ConversationsFunc mocks the Conversations method.
+	ConversationsFunc func(ctx context.Context, last uint64, before *string) (nakama.Conversations, error)
+
 	// CreateCommentFunc mocks the CreateComment method.
 	CreateCommentFunc func(ctx context.Context, postID string, content string) (nakama.Comment, error)
 
+	// CreateConversationFunc mocks the CreateConversation method.
+	CreateConversationFunc func(ctx context.Context, usernames []string) (nakama.Conversation, error)
+
 	// CreateTimelineItemFunc mocks the CreateTimelineItem method.
-	CreateTimelineItemFunc func(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker) (nakama.TimelineItem, error)
+	CreateTimelineItemFunc func(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker, uploadIDs []string, mediaAltTexts []string) (nakama.TimelineItem, error)
+
+	// CreateUploadIntentFunc mocks the CreateUploadIntent method.
+	CreateUploadIntentFunc func(ctx context.Context, in nakama.CreateUploadIntent) (nakama.UploadIntent, error)
+
+	// CreateWebhookFunc mocks the CreateWebhook method.
+	CreateWebhookFunc func(ctx context.Context, in nakama.CreateWebhook) (nakama.Webhook, error)
+
+	// DMSettingsFunc mocks the DMSettings method.
+	DMSettingsFunc func(ctx context.Context) (nakama.DMSettings, error)
+
+	// DeleteAllNotificationsFunc mocks the DeleteAllNotifications method.
+	DeleteAllNotificationsFunc func(ctx context.Context) error
 
 	// DeleteCommentFunc mocks the DeleteComment method.
 	DeleteCommentFunc func(ctx context.Context, commentID string) error
 
+	// DeleteNotificationFunc mocks the DeleteNotification method.
+	DeleteNotificationFunc func(ctx context.Context, notificationID string) error
+
 	// DeletePostFunc mocks the DeletePost method.
 	DeletePostFunc func(ctx context.Context, postID string) error
 
 	// DeleteTimelineItemFunc mocks the DeleteTimelineItem method.
 	DeleteTimelineItemFunc func(ctx context.Context, timelineItemID string) error
 
+	// DeleteWebPushSubscriptionFunc mocks the DeleteWebPushSubscription method.
+	DeleteWebPushSubscriptionFunc func(ctx context.Context, subscriptionID string) error
+
+	// DeleteWebhookFunc mocks the DeleteWebhook method.
+	DeleteWebhookFunc func(ctx context.Context, webhookID string) error
+
 	// DevLoginFunc mocks the DevLogin method.
 	DevLoginFunc func(ctx context.Context, email string) (nakama.AuthOutput, error)
 
+	// EmailDigestSettingsFunc mocks the EmailDigestSettings method.
+	EmailDigestSettingsFunc func(ctx context.Context) (nakama.EmailDigestSettings, error)
+
+	// FinalizeUploadFunc mocks the FinalizeUpload method.
+	FinalizeUploadFunc func(ctx context.Context, uploadID string) (nakama.FinalizedUpload, error)
+
 	// FolloweesFunc mocks the Followees method.
 	FolloweesFunc func(ctx context.Context, username string, first uint64, after *string) (nakama.UserProfiles, error)
 
@@ -194,14 +325,20 @@ type ServiceMock struct {
 	// LoginFromProviderFunc mocks the LoginFromProvider method.
 	LoginFromProviderFunc func(ctx context.Context, name string, user nakama.ProvidedUser) (nakama.User, error)
 
+	// MarkConversationAsReadFunc mocks the MarkConversationAsRead method.
+	MarkConversationAsReadFunc func(ctx context.Context, conversationID string) error
+
 	// MarkNotificationAsReadFunc mocks the MarkNotificationAsRead method.
 	MarkNotificationAsReadFunc func(ctx context.Context, notificationID string) error
 
 	// MarkNotificationsAsReadFunc mocks the MarkNotificationsAsRead method.
 	MarkNotificationsAsReadFunc func(ctx context.Context) error
 
+	// MessagesFunc mocks the Messages method.
+	MessagesFunc func(ctx context.Context, conversationID string, last uint64, before *string) (nakama.Messages, error)
+
 	// NotificationStreamFunc mocks the NotificationStream method.
-	NotificationStreamFunc func(ctx context.Context) (<-chan nakama.Notification, error)
+	NotificationStreamFunc func(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.Notification], error)
 
 	// NotificationsFunc mocks the Notifications method.
 	NotificationsFunc func(ctx context.Context, last uint64, before *string) (nakama.Notifications, error)
@@ -218,14 +355,26 @@ type ServiceMock struct {
 	// PostsFunc mocks the Posts method.
 	PostsFunc func(ctx context.Context, last uint64, before *string, opts ...nakama.PostsOpt) (nakama.Posts, error)
 
+	// PutUploadFunc mocks the PutUpload method.
+	PutUploadFunc func(ctx context.Context, uploadID string, expires string, signature string, r io.Reader, size int64) error
+
+	// SendCommentTypingFunc mocks the SendCommentTyping method.
+	SendCommentTypingFunc func(ctx context.Context, postID string) error
+
 	// SendMagicLinkFunc mocks the SendMagicLink method.
 	SendMagicLinkFunc func(ctx context.Context, in nakama.SendMagicLink) error
 
+	// SendMessageFunc mocks the SendMessage method.
+	SendMessageFunc func(ctx context.Context, conversationID string, content string, media []io.ReadSeeker) (nakama.Message, error)
+
 	// TimelineFunc mocks the Timeline method.
 	TimelineFunc func(ctx context.Context, last uint64, before *string) (nakama.Timeline, error)
 
 	// TimelineItemStreamFunc mocks the TimelineItemStream method.
-	TimelineItemStreamFunc func(ctx context.Context) (<-chan nakama.TimelineItem, error)
+	TimelineItemStreamFunc func(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.TimelineItem], error)
+
+	// ToggleBlockFunc mocks the ToggleBlock method.
+	ToggleBlockFunc func(ctx context.Context, username string) (nakama.ToggleBlockOutput, error)
 
 	// ToggleCommentReactionFunc mocks the ToggleCommentReaction method.
 	ToggleCommentReactionFunc func(ctx context.Context, commentID string, in nakama.ReactionInput) ([]nakama.Reaction, error)
@@ -242,6 +391,15 @@ type ServiceMock struct {
 	// TokenFunc mocks the Token method.
 	TokenFunc func(ctx context.Context) (nakama.TokenOutput, error)
 
+	// UnreadMessagesCountFunc mocks the UnreadMessagesCount method.
+	UnreadMessagesCountFunc func(ctx context.Context) (uint64, error)
+
+	// UnreadNotificationsCountFunc mocks the UnreadNotificationsCount method.
+	UnreadNotificationsCountFunc func(ctx context.Context) (uint64, error)
+
+	// UnsubscribeEmailDigestFunc mocks the UnsubscribeEmailDigest method.
+	UnsubscribeEmailDigestFunc func(ctx context.Context, userID string, token string) error
+
 	// UpdateAvatarFunc mocks the UpdateAvatar method.
 	UpdateAvatarFunc func(ctx context.Context, r io.ReadSeeker) (string, error)
 
@@ -251,9 +409,18 @@ type ServiceMock struct {
 	// UpdateCoverFunc mocks the UpdateCover method.
 	UpdateCoverFunc func(ctx context.Context, r io.ReadSeeker) (string, error)
 
+	// UpdateDMSettingsFunc mocks the UpdateDMSettings method.
+	UpdateDMSettingsFunc func(ctx context.Context, in nakama.DMSettings) error
+
+	// UpdateEmailDigestSettingsFunc mocks the UpdateEmailDigestSettings method.
+	UpdateEmailDigestSettingsFunc func(ctx context.Context, in nakama.EmailDigestSettings) error
+
 	// UpdatePostFunc mocks the UpdatePost method.
 	UpdatePostFunc func(ctx context.Context, postID string, in nakama.UpdatePost) (nakama.UpdatedPost, error)
 
+	// UpdatePostMediaAltTextFunc mocks the UpdatePostMediaAltText method.
+	UpdatePostMediaAltTextFunc func(ctx context.Context, postID string, name string, altText *string) error
+
 	// UpdateUserFunc mocks the UpdateUser method.
 	UpdateUserFunc func(ctx context.Context, params nakama.UpdateUserParams) error
 
@@ -269,14 +436,26 @@ type ServiceMock struct {
 	// VerifyMagicLinkFunc mocks the VerifyMagicLink method.
 	VerifyMagicLinkFunc func(ctx context.Context, email string, code string, username *string) (nakama.AuthOutput, error)
 
+	// WebPushPublicKeyFunc mocks the WebPushPublicKey method.
+	WebPushPublicKeyFunc func(ctx context.Context) (string, error)
+
+	// WebPushSubscriptionsFunc mocks the WebPushSubscriptions method.
+	WebPushSubscriptionsFunc func(ctx context.Context) ([]nakama.WebPushSubscription, error)
+
+	// WebhookDeliveriesFunc mocks the WebhookDeliveries method.
+	WebhookDeliveriesFunc func(ctx context.Context, webhookID string, last uint64, before *string) (nakama.WebhookDeliveries, error)
+
+	// WebhooksFunc mocks the Webhooks method.
+	WebhooksFunc func(ctx context.Context) ([]nakama.Webhook, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// AddWebPushSubscription holds details about calls to the AddWebPushSubscription method.
 		AddWebPushSubscription []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
-			// Sub is the sub argument value.
-			Sub webpush.Subscription
+			// In is the in argument value.
+			In nakama.AddWebPushSubscription
 		}
 		// AuthUser holds details about calls to the AuthUser method.
 		AuthUser []struct {
@@ -288,12 +467,21 @@ type ServiceMock struct {
 			// Token is the token argument value.
 			Token string
 		}
+		// CommentPresenceStream holds details about calls to the CommentPresenceStream method.
+		CommentPresenceStream []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PostID is the postID argument value.
+			PostID string
+		}
 		// CommentStream holds details about calls to the CommentStream method.
 		CommentStream []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 			// PostID is the postID argument value.
 			PostID string
+			// LastEventID is the lastEventID argument value.
+			LastEventID string
 		}
 		// Comments holds details about calls to the Comments method.
 		Comments []struct {
@@ -306,6 +494,31 @@ type ServiceMock struct {
 			// Before is the before argument value.
 			Before *string
 		}
+		// Conversation holds details about calls to the Conversation method.
+		Conversation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ConversationID is the conversationID argument value.
+			ConversationID string
+		}
+		// ConversationStream holds details about calls to the ConversationStream method.
+		ConversationStream []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ConversationID is the conversationID argument value.
+			ConversationID string
+			// LastEventID is the lastEventID argument value.
+			LastEventID string
+		}
+		// Conversations holds details about calls to the Conversations method.
+		Conversations []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Last is the last argument value.
+			Last uint64
+			// Before is the before argument value.
+			Before *string
+		}
 		// CreateComment holds details about calls to the CreateComment method.
 		CreateComment []struct {
 			// Ctx is the ctx argument value.
@@ -315,6 +528,13 @@ type ServiceMock struct {
 			// Content is the content argument value.
 			Content string
 		}
+		// CreateConversation holds details about calls to the CreateConversation method.
+		CreateConversation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Usernames is the usernames argument value.
+			Usernames []string
+		}
 		// CreateTimelineItem holds details about calls to the CreateTimelineItem method.
 		CreateTimelineItem []struct {
 			// Ctx is the ctx argument value.
@@ -327,6 +547,34 @@ type ServiceMock struct {
 			Nsfw bool
 			// Media is the media argument value.
 			Media []io.ReadSeeker
+			// UploadIDs is the uploadIDs argument value.
+			UploadIDs []string
+			// MediaAltTexts is the mediaAltTexts argument value.
+			MediaAltTexts []string
+		}
+		// CreateUploadIntent holds details about calls to the CreateUploadIntent method.
+		CreateUploadIntent []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In nakama.CreateUploadIntent
+		}
+		// CreateWebhook holds details about calls to the CreateWebhook method.
+		CreateWebhook []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In nakama.CreateWebhook
+		}
+		// DMSettings holds details about calls to the DMSettings method.
+		DMSettings []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// DeleteAllNotifications holds details about calls to the DeleteAllNotifications method.
+		DeleteAllNotifications []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
 		}
 		// DeleteComment holds details about calls to the DeleteComment method.
 		DeleteComment []struct {
@@ -335,6 +583,13 @@ type ServiceMock struct {
 			// CommentID is the commentID argument value.
 			CommentID string
 		}
+		// DeleteNotification holds details about calls to the DeleteNotification method.
+		DeleteNotification []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// NotificationID is the notificationID argument value.
+			NotificationID string
+		}
 		// DeletePost holds details about calls to the DeletePost method.
 		DeletePost []struct {
 			// Ctx is the ctx argument value.
@@ -349,6 +604,20 @@ type ServiceMock struct {
 			// TimelineItemID is the timelineItemID argument value.
 			TimelineItemID string
 		}
+		// DeleteWebPushSubscription holds details about calls to the DeleteWebPushSubscription method.
+		DeleteWebPushSubscription []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// SubscriptionID is the subscriptionID argument value.
+			SubscriptionID string
+		}
+		// DeleteWebhook holds details about calls to the DeleteWebhook method.
+		DeleteWebhook []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// WebhookID is the webhookID argument value.
+			WebhookID string
+		}
 		// DevLogin holds details about calls to the DevLogin method.
 		DevLogin []struct {
 			// Ctx is the ctx argument value.
@@ -356,6 +625,18 @@ type ServiceMock struct {
 			// Email is the email argument value.
 			Email string
 		}
+		// EmailDigestSettings holds details about calls to the EmailDigestSettings method.
+		EmailDigestSettings []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// FinalizeUpload holds details about calls to the FinalizeUpload method.
+		FinalizeUpload []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UploadID is the uploadID argument value.
+			UploadID string
+		}
 		// Followees holds details about calls to the Followees method.
 		Followees []struct {
 			// Ctx is the ctx argument value.
@@ -392,6 +673,13 @@ type ServiceMock struct {
 			// User is the user argument value.
 			User nakama.ProvidedUser
 		}
+		// MarkConversationAsRead holds details about calls to the MarkConversationAsRead method.
+		MarkConversationAsRead []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ConversationID is the conversationID argument value.
+			ConversationID string
+		}
 		// MarkNotificationAsRead holds details about calls to the MarkNotificationAsRead method.
 		MarkNotificationAsRead []struct {
 			// Ctx is the ctx argument value.
@@ -404,10 +692,23 @@ type ServiceMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// Messages holds details about calls to the Messages method.
+		Messages []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ConversationID is the conversationID argument value.
+			ConversationID string
+			// Last is the last argument value.
+			Last uint64
+			// Before is the before argument value.
+			Before *string
+		}
 		// NotificationStream holds details about calls to the NotificationStream method.
 		NotificationStream []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
+			// LastEventID is the lastEventID argument value.
+			LastEventID string
 		}
 		// Notifications holds details about calls to the Notifications method.
 		Notifications []struct {
@@ -446,6 +747,28 @@ type ServiceMock struct {
 			// Opts is the opts argument value.
 			Opts []nakama.PostsOpt
 		}
+		// PutUpload holds details about calls to the PutUpload method.
+		PutUpload []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UploadID is the uploadID argument value.
+			UploadID string
+			// Expires is the expires argument value.
+			Expires string
+			// Signature is the signature argument value.
+			Signature string
+			// R is the r argument value.
+			R io.Reader
+			// Size is the size argument value.
+			Size int64
+		}
+		// SendCommentTyping holds details about calls to the SendCommentTyping method.
+		SendCommentTyping []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PostID is the postID argument value.
+			PostID string
+		}
 		// SendMagicLink holds details about calls to the SendMagicLink method.
 		SendMagicLink []struct {
 			// Ctx is the ctx argument value.
@@ -453,6 +776,17 @@ type ServiceMock struct {
 			// In is the in argument value.
 			In nakama.SendMagicLink
 		}
+		// SendMessage holds details about calls to the SendMessage method.
+		SendMessage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ConversationID is the conversationID argument value.
+			ConversationID string
+			// Content is the content argument value.
+			Content string
+			// Media is the media argument value.
+			Media []io.ReadSeeker
+		}
 		// Timeline holds details about calls to the Timeline method.
 		Timeline []struct {
 			// Ctx is the ctx argument value.
@@ -466,6 +800,15 @@ type ServiceMock struct {
 		TimelineItemStream []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
+			// LastEventID is the lastEventID argument value.
+			LastEventID string
+		}
+		// ToggleBlock holds details about calls to the ToggleBlock method.
+		ToggleBlock []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Username is the username argument value.
+			Username string
 		}
 		// ToggleCommentReaction holds details about calls to the ToggleCommentReaction method.
 		ToggleCommentReaction []struct {
@@ -504,6 +847,25 @@ type ServiceMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// UnreadMessagesCount holds details about calls to the UnreadMessagesCount method.
+		UnreadMessagesCount []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// UnreadNotificationsCount holds details about calls to the UnreadNotificationsCount method.
+		UnreadNotificationsCount []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// UnsubscribeEmailDigest holds details about calls to the UnsubscribeEmailDigest method.
+		UnsubscribeEmailDigest []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// UserID is the userID argument value.
+			UserID string
+			// Token is the token argument value.
+			Token string
+		}
 		// UpdateAvatar holds details about calls to the UpdateAvatar method.
 		UpdateAvatar []struct {
 			// Ctx is the ctx argument value.
@@ -525,6 +887,20 @@ type ServiceMock struct {
 			// R is the r argument value.
 			R io.ReadSeeker
 		}
+		// UpdateDMSettings holds details about calls to the UpdateDMSettings method.
+		UpdateDMSettings []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In nakama.DMSettings
+		}
+		// UpdateEmailDigestSettings holds details about calls to the UpdateEmailDigestSettings method.
+		UpdateEmailDigestSettings []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// In is the in argument value.
+			In nakama.EmailDigestSettings
+		}
 		// UpdatePost holds details about calls to the UpdatePost method.
 		UpdatePost []struct {
 			// Ctx is the ctx argument value.
@@ -534,6 +910,17 @@ type ServiceMock struct {
 			// In is the in argument value.
 			In nakama.UpdatePost
 		}
+		// UpdatePostMediaAltText holds details about calls to the UpdatePostMediaAltText method.
+		UpdatePostMediaAltText []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PostID is the postID argument value.
+			PostID string
+			// Name is the name argument value.
+			Name string
+			// AltText is the altText argument value.
+			AltText *string
+		}
 		// UpdateUser holds details about calls to the UpdateUser method.
 		UpdateUser []struct {
 			// Ctx is the ctx argument value.
@@ -581,57 +968,113 @@ type ServiceMock struct {
 			// Username is the username argument value.
 			Username *string
 		}
+		// WebPushPublicKey holds details about calls to the WebPushPublicKey method.
+		WebPushPublicKey []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// WebPushSubscriptions holds details about calls to the WebPushSubscriptions method.
+		WebPushSubscriptions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// WebhookDeliveries holds details about calls to the WebhookDeliveries method.
+		WebhookDeliveries []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// WebhookID is the webhookID argument value.
+			WebhookID string
+			// Last is the last argument value.
+			Last uint64
+			// Before is the before argument value.
+			Before *string
+		}
+		// Webhooks holds details about calls to the Webhooks method.
+		Webhooks []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
 	}
-	lockAddWebPushSubscription  sync.RWMutex
-	lockAuthUser                sync.RWMutex
-	lockAuthUserIDFromToken     sync.RWMutex
-	lockCommentStream           sync.RWMutex
-	lockComments                sync.RWMutex
-	lockCreateComment           sync.RWMutex
-	lockCreateTimelineItem      sync.RWMutex
-	lockDeleteComment           sync.RWMutex
-	lockDeletePost              sync.RWMutex
-	lockDeleteTimelineItem      sync.RWMutex
-	lockDevLogin                sync.RWMutex
-	lockFollowees               sync.RWMutex
-	lockFollowers               sync.RWMutex
-	lockHasUnreadNotifications  sync.RWMutex
-	lockLoginFromProvider       sync.RWMutex
-	lockMarkNotificationAsRead  sync.RWMutex
-	lockMarkNotificationsAsRead sync.RWMutex
-	lockNotificationStream      sync.RWMutex
-	lockNotifications           sync.RWMutex
-	lockParseRedirectURI        sync.RWMutex
-	lockPost                    sync.RWMutex
-	lockPostStream              sync.RWMutex
-	lockPosts                   sync.RWMutex
-	lockSendMagicLink           sync.RWMutex
-	lockTimeline                sync.RWMutex
-	lockTimelineItemStream      sync.RWMutex
-	lockToggleCommentReaction   sync.RWMutex
-	lockToggleFollow            sync.RWMutex
-	lockTogglePostReaction      sync.RWMutex
-	lockTogglePostSubscription  sync.RWMutex
-	lockToken                   sync.RWMutex
-	lockUpdateAvatar            sync.RWMutex
-	lockUpdateComment           sync.RWMutex
-	lockUpdateCover             sync.RWMutex
-	lockUpdatePost              sync.RWMutex
-	lockUpdateUser              sync.RWMutex
-	lockUser                    sync.RWMutex
-	lockUsernames               sync.RWMutex
-	lockUsers                   sync.RWMutex
-	lockVerifyMagicLink         sync.RWMutex
+	lockAddWebPushSubscription    sync.RWMutex
+	lockAuthUser                  sync.RWMutex
+	lockAuthUserIDFromToken       sync.RWMutex
+	lockCommentPresenceStream     sync.RWMutex
+	lockCommentStream             sync.RWMutex
+	lockComments                  sync.RWMutex
+	lockConversation              sync.RWMutex
+	lockConversationStream        sync.RWMutex
+	lockConversations             sync.RWMutex
+	lockCreateComment             sync.RWMutex
+	lockCreateConversation        sync.RWMutex
+	lockCreateTimelineItem        sync.RWMutex
+	lockCreateUploadIntent        sync.RWMutex
+	lockCreateWebhook             sync.RWMutex
+	lockDMSettings                sync.RWMutex
+	lockDeleteAllNotifications    sync.RWMutex
+	lockDeleteComment             sync.RWMutex
+	lockDeleteNotification        sync.RWMutex
+	lockDeletePost                sync.RWMutex
+	lockDeleteTimelineItem        sync.RWMutex
+	lockDeleteWebPushSubscription sync.RWMutex
+	lockDeleteWebhook             sync.RWMutex
+	lockDevLogin                  sync.RWMutex
+	lockEmailDigestSettings       sync.RWMutex
+	lockFinalizeUpload            sync.RWMutex
+	lockFollowees                 sync.RWMutex
+	lockFollowers                 sync.RWMutex
+	lockHasUnreadNotifications    sync.RWMutex
+	lockLoginFromProvider         sync.RWMutex
+	lockMarkConversationAsRead    sync.RWMutex
+	lockMarkNotificationAsRead    sync.RWMutex
+	lockMarkNotificationsAsRead   sync.RWMutex
+	lockMessages                  sync.RWMutex
+	lockNotificationStream        sync.RWMutex
+	lockNotifications             sync.RWMutex
+	lockParseRedirectURI          sync.RWMutex
+	lockPost                      sync.RWMutex
+	lockPostStream                sync.RWMutex
+	lockPosts                     sync.RWMutex
+	lockPutUpload                 sync.RWMutex
+	lockSendCommentTyping         sync.RWMutex
+	lockSendMagicLink             sync.RWMutex
+	lockSendMessage               sync.RWMutex
+	lockTimeline                  sync.RWMutex
+	lockTimelineItemStream        sync.RWMutex
+	lockToggleBlock               sync.RWMutex
+	lockToggleCommentReaction     sync.RWMutex
+	lockToggleFollow              sync.RWMutex
+	lockTogglePostReaction        sync.RWMutex
+	lockTogglePostSubscription    sync.RWMutex
+	lockToken                     sync.RWMutex
+	lockUnreadMessagesCount       sync.RWMutex
+	lockUnreadNotificationsCount  sync.RWMutex
+	lockUnsubscribeEmailDigest    sync.RWMutex
+	lockUpdateAvatar              sync.RWMutex
+	lockUpdateComment             sync.RWMutex
+	lockUpdateCover               sync.RWMutex
+	lockUpdateDMSettings          sync.RWMutex
+	lockUpdateEmailDigestSettings sync.RWMutex
+	lockUpdatePost                sync.RWMutex
+	lockUpdatePostMediaAltText    sync.RWMutex
+	lockUpdateUser                sync.RWMutex
+	lockUser                      sync.RWMutex
+	lockUsernames                 sync.RWMutex
+	lockUsers                     sync.RWMutex
+	lockVerifyMagicLink           sync.RWMutex
+	lockWebPushPublicKey          sync.RWMutex
+	lockWebPushSubscriptions      sync.RWMutex
+	lockWebhookDeliveries         sync.RWMutex
+	lockWebhooks                  sync.RWMutex
 }
 
 // AddWebPushSubscription calls AddWebPushSubscriptionFunc.
-func (mock *ServiceMock) AddWebPushSubscription(ctx context.Context, sub webpush.Subscription) error {
+func (mock *ServiceMock) AddWebPushSubscription(ctx context.Context, in nakama.AddWebPushSubscription) error {
 	callInfo := struct {
 		Ctx context.Context
-		Sub webpush.Subscription
+		In  nakama.AddWebPushSubscription
 	}{
 		Ctx: ctx,
-		Sub: sub,
+		In:  in,
 	}
 	mock.lockAddWebPushSubscription.Lock()
 	mock.calls.AddWebPushSubscription = append(mock.calls.AddWebPushSubscription, callInfo)
@@ -642,7 +1085,7 @@ func (mock *ServiceMock) AddWebPushSubscription(ctx context.Context, sub webpush
 		)
 		return errOut
 	}
-	return mock.AddWebPushSubscriptionFunc(ctx, sub)
+	return mock.AddWebPushSubscriptionFunc(ctx, in)
 }
 
 // AddWebPushSubscriptionCalls gets all the calls that were made to AddWebPushSubscription.
@@ -651,11 +1094,11 @@ func (mock *ServiceMock) AddWebPushSubscription(ctx context.Context, sub webpush
 //	len(mockedService.AddWebPushSubscriptionCalls())
 func (mock *ServiceMock) AddWebPushSubscriptionCalls() []struct {
 	Ctx context.Context
-	Sub webpush.Subscription
+	In  nakama.AddWebPushSubscription
 } {
 	var calls []struct {
 		Ctx context.Context
-		Sub webpush.Subscription
+		In  nakama.AddWebPushSubscription
 	}
 	mock.lockAddWebPushSubscription.RLock()
 	calls = mock.calls.AddWebPushSubscription
@@ -735,8 +1178,8 @@ func (mock *ServiceMock) AuthUserIDFromTokenCalls() []struct {
 	return calls
 }
 
-// CommentStream calls CommentStreamFunc.
-func (mock *ServiceMock) CommentStream(ctx context.Context, postID string) (<-chan nakama.Comment, error) {
+// CommentPresenceStream calls CommentPresenceStreamFunc.
+func (mock *ServiceMock) CommentPresenceStream(ctx context.Context, postID string) (<-chan nakama.CommentPresence, error) {
 	callInfo := struct {
 		Ctx    context.Context
 		PostID string
@@ -744,17 +1187,59 @@ func (mock *ServiceMock) CommentStream(ctx context.Context, postID string) (<-ch
 		Ctx:    ctx,
 		PostID: postID,
 	}
+	mock.lockCommentPresenceStream.Lock()
+	mock.calls.CommentPresenceStream = append(mock.calls.CommentPresenceStream, callInfo)
+	mock.lockCommentPresenceStream.Unlock()
+	if mock.CommentPresenceStreamFunc == nil {
+		var (
+			commentPresenceChOut <-chan nakama.CommentPresence
+			errOut               error
+		)
+		return commentPresenceChOut, errOut
+	}
+	return mock.CommentPresenceStreamFunc(ctx, postID)
+}
+
+// CommentPresenceStreamCalls gets all the calls that were made to CommentPresenceStream.
+// Check the length with:
+//
+//	len(mockedService.CommentPresenceStreamCalls())
+func (mock *ServiceMock) CommentPresenceStreamCalls() []struct {
+	Ctx    context.Context
+	PostID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		PostID string
+	}
+	mock.lockCommentPresenceStream.RLock()
+	calls = mock.calls.CommentPresenceStream
+	mock.lockCommentPresenceStream.RUnlock()
+	return calls
+}
+
+// CommentStream calls CommentStreamFunc.
+func (mock *ServiceMock) CommentStream(ctx context.Context, postID string, lastEventID string) (<-chan nakama.StreamEvent[nakama.Comment], error) {
+	callInfo := struct {
+		Ctx         context.Context
+		PostID      string
+		LastEventID string
+	}{
+		Ctx:         ctx,
+		PostID:      postID,
+		LastEventID: lastEventID,
+	}
 	mock.lockCommentStream.Lock()
 	mock.calls.CommentStream = append(mock.calls.CommentStream, callInfo)
 	mock.lockCommentStream.Unlock()
 	if mock.CommentStreamFunc == nil {
 		var (
-			commentChOut <-chan nakama.Comment
-			errOut       error
+			streamEventChOut <-chan nakama.StreamEvent[nakama.Comment]
+			errOut           error
 		)
-		return commentChOut, errOut
+		return streamEventChOut, errOut
 	}
-	return mock.CommentStreamFunc(ctx, postID)
+	return mock.CommentStreamFunc(ctx, postID, lastEventID)
 }
 
 // CommentStreamCalls gets all the calls that were made to CommentStream.
@@ -762,12 +1247,14 @@ func (mock *ServiceMock) CommentStream(ctx context.Context, postID string) (<-ch
 //
 //	len(mockedService.CommentStreamCalls())
 func (mock *ServiceMock) CommentStreamCalls() []struct {
-	Ctx    context.Context
-	PostID string
+	Ctx         context.Context
+	PostID      string
+	LastEventID string
 } {
 	var calls []struct {
-		Ctx    context.Context
-		PostID string
+		Ctx         context.Context
+		PostID      string
+		LastEventID string
 	}
 	mock.lockCommentStream.RLock()
 	calls = mock.calls.CommentStream
@@ -823,68 +1310,240 @@ func (mock *ServiceMock) CommentsCalls() []struct {
 	return calls
 }
 
-// CreateComment calls CreateCommentFunc.
-func (mock *ServiceMock) CreateComment(ctx context.Context, postID string, content string) (nakama.Comment, error) {
+// Conversation calls ConversationFunc.
+func (mock *ServiceMock) Conversation(ctx context.Context, conversationID string) (nakama.Conversation, error) {
 	callInfo := struct {
-		Ctx     context.Context
-		PostID  string
-		Content string
+		Ctx            context.Context
+		ConversationID string
 	}{
-		Ctx:     ctx,
-		PostID:  postID,
-		Content: content,
+		Ctx:            ctx,
+		ConversationID: conversationID,
 	}
-	mock.lockCreateComment.Lock()
-	mock.calls.CreateComment = append(mock.calls.CreateComment, callInfo)
-	mock.lockCreateComment.Unlock()
-	if mock.CreateCommentFunc == nil {
+	mock.lockConversation.Lock()
+	mock.calls.Conversation = append(mock.calls.Conversation, callInfo)
+	mock.lockConversation.Unlock()
+	if mock.ConversationFunc == nil {
 		var (
-			commentOut nakama.Comment
-			errOut     error
+			conversationOut nakama.Conversation
+			errOut          error
 		)
-		return commentOut, errOut
+		return conversationOut, errOut
 	}
-	return mock.CreateCommentFunc(ctx, postID, content)
+	return mock.ConversationFunc(ctx, conversationID)
 }
 
-// CreateCommentCalls gets all the calls that were made to CreateComment.
+// ConversationCalls gets all the calls that were made to Conversation.
 // Check the length with:
 //
-//	len(mockedService.CreateCommentCalls())
-func (mock *ServiceMock) CreateCommentCalls() []struct {
-	Ctx     context.Context
-	PostID  string
-	Content string
+//	len(mockedService.ConversationCalls())
+func (mock *ServiceMock) ConversationCalls() []struct {
+	Ctx            context.Context
+	ConversationID string
 } {
 	var calls []struct {
-		Ctx     context.Context
-		PostID  string
-		Content string
+		Ctx            context.Context
+		ConversationID string
 	}
-	mock.lockCreateComment.RLock()
-	calls = mock.calls.CreateComment
-	mock.lockCreateComment.RUnlock()
+	mock.lockConversation.RLock()
+	calls = mock.calls.Conversation
+	mock.lockConversation.RUnlock()
 	return calls
 }
 
-// CreateTimelineItem calls CreateTimelineItemFunc.
-func (mock *ServiceMock) CreateTimelineItem(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker) (nakama.TimelineItem, error) {
+// ConversationStream calls ConversationStreamFunc.
+func (mock *ServiceMock) ConversationStream(ctx context.Context, conversationID string, lastEventID string) (<-chan nakama.StreamEvent[nakama.ConversationEvent], error) {
 	callInfo := struct {
-		Ctx       context.Context
-		Content   string
-		SpoilerOf *string
-		Nsfw      bool
-		Media     []io.ReadSeeker
+		Ctx            context.Context
+		ConversationID string
+		LastEventID    string
 	}{
-		Ctx:       ctx,
-		Content:   content,
-		SpoilerOf: spoilerOf,
-		Nsfw:      nsfw,
-		Media:     media,
+		Ctx:            ctx,
+		ConversationID: conversationID,
+		LastEventID:    lastEventID,
 	}
-	mock.lockCreateTimelineItem.Lock()
-	mock.calls.CreateTimelineItem = append(mock.calls.CreateTimelineItem, callInfo)
-	mock.lockCreateTimelineItem.Unlock()
+	mock.lockConversationStream.Lock()
+	mock.calls.ConversationStream = append(mock.calls.ConversationStream, callInfo)
+	mock.lockConversationStream.Unlock()
+	if mock.ConversationStreamFunc == nil {
+		var (
+			streamEventChOut <-chan nakama.StreamEvent[nakama.ConversationEvent]
+			errOut           error
+		)
+		return streamEventChOut, errOut
+	}
+	return mock.ConversationStreamFunc(ctx, conversationID, lastEventID)
+}
+
+// ConversationStreamCalls gets all the calls that were made to ConversationStream.
+// Check the length with:
+//
+//	len(mockedService.ConversationStreamCalls())
+func (mock *ServiceMock) ConversationStreamCalls() []struct {
+	Ctx            context.Context
+	ConversationID string
+	LastEventID    string
+} {
+	var calls []struct {
+		Ctx            context.Context
+		ConversationID string
+		LastEventID    string
+	}
+	mock.lockConversationStream.RLock()
+	calls = mock.calls.ConversationStream
+	mock.lockConversationStream.RUnlock()
+	return calls
+}
+
+// Conversations calls ConversationsFunc.
+func (mock *ServiceMock) Conversations(ctx context.Context, last uint64, before *string) (nakama.Conversations, error) {
+	callInfo := struct {
+		Ctx    context.Context
+		Last   uint64
+		Before *string
+	}{
+		Ctx:    ctx,
+		Last:   last,
+		Before: before,
+	}
+	mock.lockConversations.Lock()
+	mock.calls.Conversations = append(mock.calls.Conversations, callInfo)
+	mock.lockConversations.Unlock()
+	if mock.ConversationsFunc == nil {
+		var (
+			conversationsOut nakama.Conversations
+			errOut           error
+		)
+		return conversationsOut, errOut
+	}
+	return mock.ConversationsFunc(ctx, last, before)
+}
+
+// ConversationsCalls gets all the calls that were made to Conversations.
+// Check the length with:
+//
+//	len(mockedService.ConversationsCalls())
+func (mock *ServiceMock) ConversationsCalls() []struct {
+	Ctx    context.Context
+	Last   uint64
+	Before *string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Last   uint64
+		Before *string
+	}
+	mock.lockConversations.RLock()
+	calls = mock.calls.Conversations
+	mock.lockConversations.RUnlock()
+	return calls
+}
+
+// CreateComment calls CreateCommentFunc.
+func (mock *ServiceMock) CreateComment(ctx context.Context, postID string, content string) (nakama.Comment, error) {
+	callInfo := struct {
+		Ctx     context.Context
+		PostID  string
+		Content string
+	}{
+		Ctx:     ctx,
+		PostID:  postID,
+		Content: content,
+	}
+	mock.lockCreateComment.Lock()
+	mock.calls.CreateComment = append(mock.calls.CreateComment, callInfo)
+	mock.lockCreateComment.Unlock()
+	if mock.CreateCommentFunc == nil {
+		var (
+			commentOut nakama.Comment
+			errOut     error
+		)
+		return commentOut, errOut
+	}
+	return mock.CreateCommentFunc(ctx, postID, content)
+}
+
+// CreateCommentCalls gets all the calls that were made to CreateComment.
+// Check the length with:
+//
+//	len(mockedService.CreateCommentCalls())
+func (mock *ServiceMock) CreateCommentCalls() []struct {
+	Ctx     context.Context
+	PostID  string
+	Content string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		PostID  string
+		Content string
+	}
+	mock.lockCreateComment.RLock()
+	calls = mock.calls.CreateComment
+	mock.lockCreateComment.RUnlock()
+	return calls
+}
+
+// CreateConversation calls CreateConversationFunc.
+func (mock *ServiceMock) CreateConversation(ctx context.Context, usernames []string) (nakama.Conversation, error) {
+	callInfo := struct {
+		Ctx       context.Context
+		Usernames []string
+	}{
+		Ctx:       ctx,
+		Usernames: usernames,
+	}
+	mock.lockCreateConversation.Lock()
+	mock.calls.CreateConversation = append(mock.calls.CreateConversation, callInfo)
+	mock.lockCreateConversation.Unlock()
+	if mock.CreateConversationFunc == nil {
+		var (
+			conversationOut nakama.Conversation
+			errOut          error
+		)
+		return conversationOut, errOut
+	}
+	return mock.CreateConversationFunc(ctx, usernames)
+}
+
+// CreateConversationCalls gets all the calls that were made to CreateConversation.
+// Check the length with:
+//
+//	len(mockedService.CreateConversationCalls())
+func (mock *ServiceMock) CreateConversationCalls() []struct {
+	Ctx       context.Context
+	Usernames []string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Usernames []string
+	}
+	mock.lockCreateConversation.RLock()
+	calls = mock.calls.CreateConversation
+	mock.lockCreateConversation.RUnlock()
+	return calls
+}
+
+// CreateTimelineItem calls CreateTimelineItemFunc.
+func (mock *ServiceMock) CreateTimelineItem(ctx context.Context, content string, spoilerOf *string, nsfw bool, media []io.ReadSeeker, uploadIDs []string, mediaAltTexts []string) (nakama.TimelineItem, error) {
+	callInfo := struct {
+		Ctx           context.Context
+		Content       string
+		SpoilerOf     *string
+		Nsfw          bool
+		Media         []io.ReadSeeker
+		UploadIDs     []string
+		MediaAltTexts []string
+	}{
+		Ctx:           ctx,
+		Content:       content,
+		SpoilerOf:     spoilerOf,
+		Nsfw:          nsfw,
+		Media:         media,
+		UploadIDs:     uploadIDs,
+		MediaAltTexts: mediaAltTexts,
+	}
+	mock.lockCreateTimelineItem.Lock()
+	mock.calls.CreateTimelineItem = append(mock.calls.CreateTimelineItem, callInfo)
+	mock.lockCreateTimelineItem.Unlock()
 	if mock.CreateTimelineItemFunc == nil {
 		var (
 			timelineItemOut nakama.TimelineItem
@@ -892,7 +1551,7 @@ func (mock *ServiceMock) CreateTimelineItem(ctx context.Context, content string,
 		)
 		return timelineItemOut, errOut
 	}
-	return mock.CreateTimelineItemFunc(ctx, content, spoilerOf, nsfw, media)
+	return mock.CreateTimelineItemFunc(ctx, content, spoilerOf, nsfw, media, uploadIDs, mediaAltTexts)
 }
 
 // CreateTimelineItemCalls gets all the calls that were made to CreateTimelineItem.
@@ -900,18 +1559,22 @@ func (mock *ServiceMock) CreateTimelineItem(ctx context.Context, content string,
 //
 //	len(mockedService.CreateTimelineItemCalls())
 func (mock *ServiceMock) CreateTimelineItemCalls() []struct {
-	Ctx       context.Context
-	Content   string
-	SpoilerOf *string
-	Nsfw      bool
-	Media     []io.ReadSeeker
+	Ctx           context.Context
+	Content       string
+	SpoilerOf     *string
+	Nsfw          bool
+	Media         []io.ReadSeeker
+	UploadIDs     []string
+	MediaAltTexts []string
 } {
 	var calls []struct {
-		Ctx       context.Context
-		Content   string
-		SpoilerOf *string
-		Nsfw      bool
-		Media     []io.ReadSeeker
+		Ctx           context.Context
+		Content       string
+		SpoilerOf     *string
+		Nsfw          bool
+		Media         []io.ReadSeeker
+		UploadIDs     []string
+		MediaAltTexts []string
 	}
 	mock.lockCreateTimelineItem.RLock()
 	calls = mock.calls.CreateTimelineItem
@@ -919,6 +1582,157 @@ func (mock *ServiceMock) CreateTimelineItemCalls() []struct {
 	return calls
 }
 
+// CreateUploadIntent calls CreateUploadIntentFunc.
+func (mock *ServiceMock) CreateUploadIntent(ctx context.Context, in nakama.CreateUploadIntent) (nakama.UploadIntent, error) {
+	callInfo := struct {
+		Ctx context.Context
+		In  nakama.CreateUploadIntent
+	}{
+		Ctx: ctx,
+		In:  in,
+	}
+	mock.lockCreateUploadIntent.Lock()
+	mock.calls.CreateUploadIntent = append(mock.calls.CreateUploadIntent, callInfo)
+	mock.lockCreateUploadIntent.Unlock()
+	if mock.CreateUploadIntentFunc == nil {
+		var (
+			uploadIntentOut nakama.UploadIntent
+			errOut          error
+		)
+		return uploadIntentOut, errOut
+	}
+	return mock.CreateUploadIntentFunc(ctx, in)
+}
+
+// CreateUploadIntentCalls gets all the calls that were made to CreateUploadIntent.
+// Check the length with:
+//
+//	len(mockedService.CreateUploadIntentCalls())
+func (mock *ServiceMock) CreateUploadIntentCalls() []struct {
+	Ctx context.Context
+	In  nakama.CreateUploadIntent
+} {
+	var calls []struct {
+		Ctx context.Context
+		In  nakama.CreateUploadIntent
+	}
+	mock.lockCreateUploadIntent.RLock()
+	calls = mock.calls.CreateUploadIntent
+	mock.lockCreateUploadIntent.RUnlock()
+	return calls
+}
+
+// CreateWebhook calls CreateWebhookFunc.
+func (mock *ServiceMock) CreateWebhook(ctx context.Context, in nakama.CreateWebhook) (nakama.Webhook, error) {
+	callInfo := struct {
+		Ctx context.Context
+		In  nakama.CreateWebhook
+	}{
+		Ctx: ctx,
+		In:  in,
+	}
+	mock.lockCreateWebhook.Lock()
+	mock.calls.CreateWebhook = append(mock.calls.CreateWebhook, callInfo)
+	mock.lockCreateWebhook.Unlock()
+	if mock.CreateWebhookFunc == nil {
+		var (
+			webhookOut nakama.Webhook
+			errOut     error
+		)
+		return webhookOut, errOut
+	}
+	return mock.CreateWebhookFunc(ctx, in)
+}
+
+// CreateWebhookCalls gets all the calls that were made to CreateWebhook.
+// Check the length with:
+//
+//	len(mockedService.CreateWebhookCalls())
+func (mock *ServiceMock) CreateWebhookCalls() []struct {
+	Ctx context.Context
+	In  nakama.CreateWebhook
+} {
+	var calls []struct {
+		Ctx context.Context
+		In  nakama.CreateWebhook
+	}
+	mock.lockCreateWebhook.RLock()
+	calls = mock.calls.CreateWebhook
+	mock.lockCreateWebhook.RUnlock()
+	return calls
+}
+
+// DMSettings calls DMSettingsFunc.
+func (mock *ServiceMock) DMSettings(ctx context.Context) (nakama.DMSettings, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockDMSettings.Lock()
+	mock.calls.DMSettings = append(mock.calls.DMSettings, callInfo)
+	mock.lockDMSettings.Unlock()
+	if mock.DMSettingsFunc == nil {
+		var (
+			dMSettingsOut nakama.DMSettings
+			errOut        error
+		)
+		return dMSettingsOut, errOut
+	}
+	return mock.DMSettingsFunc(ctx)
+}
+
+// DMSettingsCalls gets all the calls that were made to DMSettings.
+// Check the length with:
+//
+//	len(mockedService.DMSettingsCalls())
+func (mock *ServiceMock) DMSettingsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockDMSettings.RLock()
+	calls = mock.calls.DMSettings
+	mock.lockDMSettings.RUnlock()
+	return calls
+}
+
+// DeleteAllNotifications calls DeleteAllNotificationsFunc.
+func (mock *ServiceMock) DeleteAllNotifications(ctx context.Context) error {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockDeleteAllNotifications.Lock()
+	mock.calls.DeleteAllNotifications = append(mock.calls.DeleteAllNotifications, callInfo)
+	mock.lockDeleteAllNotifications.Unlock()
+	if mock.DeleteAllNotificationsFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.DeleteAllNotificationsFunc(ctx)
+}
+
+// DeleteAllNotificationsCalls gets all the calls that were made to DeleteAllNotifications.
+// Check the length with:
+//
+//	len(mockedService.DeleteAllNotificationsCalls())
+func (mock *ServiceMock) DeleteAllNotificationsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockDeleteAllNotifications.RLock()
+	calls = mock.calls.DeleteAllNotifications
+	mock.lockDeleteAllNotifications.RUnlock()
+	return calls
+}
+
 // DeleteComment calls DeleteCommentFunc.
 func (mock *ServiceMock) DeleteComment(ctx context.Context, commentID string) error {
 	callInfo := struct {
@@ -958,6 +1772,45 @@ func (mock *ServiceMock) DeleteCommentCalls() []struct {
 	return calls
 }
 
+// DeleteNotification calls DeleteNotificationFunc.
+func (mock *ServiceMock) DeleteNotification(ctx context.Context, notificationID string) error {
+	callInfo := struct {
+		Ctx            context.Context
+		NotificationID string
+	}{
+		Ctx:            ctx,
+		NotificationID: notificationID,
+	}
+	mock.lockDeleteNotification.Lock()
+	mock.calls.DeleteNotification = append(mock.calls.DeleteNotification, callInfo)
+	mock.lockDeleteNotification.Unlock()
+	if mock.DeleteNotificationFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.DeleteNotificationFunc(ctx, notificationID)
+}
+
+// DeleteNotificationCalls gets all the calls that were made to DeleteNotification.
+// Check the length with:
+//
+//	len(mockedService.DeleteNotificationCalls())
+func (mock *ServiceMock) DeleteNotificationCalls() []struct {
+	Ctx            context.Context
+	NotificationID string
+} {
+	var calls []struct {
+		Ctx            context.Context
+		NotificationID string
+	}
+	mock.lockDeleteNotification.RLock()
+	calls = mock.calls.DeleteNotification
+	mock.lockDeleteNotification.RUnlock()
+	return calls
+}
+
 // DeletePost calls DeletePostFunc.
 func (mock *ServiceMock) DeletePost(ctx context.Context, postID string) error {
 	callInfo := struct {
@@ -1036,6 +1889,84 @@ func (mock *ServiceMock) DeleteTimelineItemCalls() []struct {
 	return calls
 }
 
+// DeleteWebPushSubscription calls DeleteWebPushSubscriptionFunc.
+func (mock *ServiceMock) DeleteWebPushSubscription(ctx context.Context, subscriptionID string) error {
+	callInfo := struct {
+		Ctx            context.Context
+		SubscriptionID string
+	}{
+		Ctx:            ctx,
+		SubscriptionID: subscriptionID,
+	}
+	mock.lockDeleteWebPushSubscription.Lock()
+	mock.calls.DeleteWebPushSubscription = append(mock.calls.DeleteWebPushSubscription, callInfo)
+	mock.lockDeleteWebPushSubscription.Unlock()
+	if mock.DeleteWebPushSubscriptionFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.DeleteWebPushSubscriptionFunc(ctx, subscriptionID)
+}
+
+// DeleteWebPushSubscriptionCalls gets all the calls that were made to DeleteWebPushSubscription.
+// Check the length with:
+//
+//	len(mockedService.DeleteWebPushSubscriptionCalls())
+func (mock *ServiceMock) DeleteWebPushSubscriptionCalls() []struct {
+	Ctx            context.Context
+	SubscriptionID string
+} {
+	var calls []struct {
+		Ctx            context.Context
+		SubscriptionID string
+	}
+	mock.lockDeleteWebPushSubscription.RLock()
+	calls = mock.calls.DeleteWebPushSubscription
+	mock.lockDeleteWebPushSubscription.RUnlock()
+	return calls
+}
+
+// DeleteWebhook calls DeleteWebhookFunc.
+func (mock *ServiceMock) DeleteWebhook(ctx context.Context, webhookID string) error {
+	callInfo := struct {
+		Ctx       context.Context
+		WebhookID string
+	}{
+		Ctx:       ctx,
+		WebhookID: webhookID,
+	}
+	mock.lockDeleteWebhook.Lock()
+	mock.calls.DeleteWebhook = append(mock.calls.DeleteWebhook, callInfo)
+	mock.lockDeleteWebhook.Unlock()
+	if mock.DeleteWebhookFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.DeleteWebhookFunc(ctx, webhookID)
+}
+
+// DeleteWebhookCalls gets all the calls that were made to DeleteWebhook.
+// Check the length with:
+//
+//	len(mockedService.DeleteWebhookCalls())
+func (mock *ServiceMock) DeleteWebhookCalls() []struct {
+	Ctx       context.Context
+	WebhookID string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		WebhookID string
+	}
+	mock.lockDeleteWebhook.RLock()
+	calls = mock.calls.DeleteWebhook
+	mock.lockDeleteWebhook.RUnlock()
+	return calls
+}
+
 // DevLogin calls DevLoginFunc.
 func (mock *ServiceMock) DevLogin(ctx context.Context, email string) (nakama.AuthOutput, error) {
 	callInfo := struct {
@@ -1076,6 +2007,82 @@ func (mock *ServiceMock) DevLoginCalls() []struct {
 	return calls
 }
 
+// EmailDigestSettings calls EmailDigestSettingsFunc.
+func (mock *ServiceMock) EmailDigestSettings(ctx context.Context) (nakama.EmailDigestSettings, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockEmailDigestSettings.Lock()
+	mock.calls.EmailDigestSettings = append(mock.calls.EmailDigestSettings, callInfo)
+	mock.lockEmailDigestSettings.Unlock()
+	if mock.EmailDigestSettingsFunc == nil {
+		var (
+			emailDigestSettingsOut nakama.EmailDigestSettings
+			errOut                 error
+		)
+		return emailDigestSettingsOut, errOut
+	}
+	return mock.EmailDigestSettingsFunc(ctx)
+}
+
+// EmailDigestSettingsCalls gets all the calls that were made to EmailDigestSettings.
+// Check the length with:
+//
+//	len(mockedService.EmailDigestSettingsCalls())
+func (mock *ServiceMock) EmailDigestSettingsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockEmailDigestSettings.RLock()
+	calls = mock.calls.EmailDigestSettings
+	mock.lockEmailDigestSettings.RUnlock()
+	return calls
+}
+
+// FinalizeUpload calls FinalizeUploadFunc.
+func (mock *ServiceMock) FinalizeUpload(ctx context.Context, uploadID string) (nakama.FinalizedUpload, error) {
+	callInfo := struct {
+		Ctx      context.Context
+		UploadID string
+	}{
+		Ctx:      ctx,
+		UploadID: uploadID,
+	}
+	mock.lockFinalizeUpload.Lock()
+	mock.calls.FinalizeUpload = append(mock.calls.FinalizeUpload, callInfo)
+	mock.lockFinalizeUpload.Unlock()
+	if mock.FinalizeUploadFunc == nil {
+		var (
+			finalizedUploadOut nakama.FinalizedUpload
+			errOut             error
+		)
+		return finalizedUploadOut, errOut
+	}
+	return mock.FinalizeUploadFunc(ctx, uploadID)
+}
+
+// FinalizeUploadCalls gets all the calls that were made to FinalizeUpload.
+// Check the length with:
+//
+//	len(mockedService.FinalizeUploadCalls())
+func (mock *ServiceMock) FinalizeUploadCalls() []struct {
+	Ctx      context.Context
+	UploadID string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		UploadID string
+	}
+	mock.lockFinalizeUpload.RLock()
+	calls = mock.calls.FinalizeUpload
+	mock.lockFinalizeUpload.RUnlock()
+	return calls
+}
+
 // Followees calls FolloweesFunc.
 func (mock *ServiceMock) Followees(ctx context.Context, username string, first uint64, after *string) (nakama.UserProfiles, error) {
 	callInfo := struct {
@@ -1252,6 +2259,45 @@ func (mock *ServiceMock) LoginFromProviderCalls() []struct {
 	return calls
 }
 
+// MarkConversationAsRead calls MarkConversationAsReadFunc.
+func (mock *ServiceMock) MarkConversationAsRead(ctx context.Context, conversationID string) error {
+	callInfo := struct {
+		Ctx            context.Context
+		ConversationID string
+	}{
+		Ctx:            ctx,
+		ConversationID: conversationID,
+	}
+	mock.lockMarkConversationAsRead.Lock()
+	mock.calls.MarkConversationAsRead = append(mock.calls.MarkConversationAsRead, callInfo)
+	mock.lockMarkConversationAsRead.Unlock()
+	if mock.MarkConversationAsReadFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.MarkConversationAsReadFunc(ctx, conversationID)
+}
+
+// MarkConversationAsReadCalls gets all the calls that were made to MarkConversationAsRead.
+// Check the length with:
+//
+//	len(mockedService.MarkConversationAsReadCalls())
+func (mock *ServiceMock) MarkConversationAsReadCalls() []struct {
+	Ctx            context.Context
+	ConversationID string
+} {
+	var calls []struct {
+		Ctx            context.Context
+		ConversationID string
+	}
+	mock.lockMarkConversationAsRead.RLock()
+	calls = mock.calls.MarkConversationAsRead
+	mock.lockMarkConversationAsRead.RUnlock()
+	return calls
+}
+
 // MarkNotificationAsRead calls MarkNotificationAsReadFunc.
 func (mock *ServiceMock) MarkNotificationAsRead(ctx context.Context, notificationID string) error {
 	callInfo := struct {
@@ -1326,24 +2372,74 @@ func (mock *ServiceMock) MarkNotificationsAsReadCalls() []struct {
 	return calls
 }
 
+// Messages calls MessagesFunc.
+func (mock *ServiceMock) Messages(ctx context.Context, conversationID string, last uint64, before *string) (nakama.Messages, error) {
+	callInfo := struct {
+		Ctx            context.Context
+		ConversationID string
+		Last           uint64
+		Before         *string
+	}{
+		Ctx:            ctx,
+		ConversationID: conversationID,
+		Last:           last,
+		Before:         before,
+	}
+	mock.lockMessages.Lock()
+	mock.calls.Messages = append(mock.calls.Messages, callInfo)
+	mock.lockMessages.Unlock()
+	if mock.MessagesFunc == nil {
+		var (
+			messagesOut nakama.Messages
+			errOut      error
+		)
+		return messagesOut, errOut
+	}
+	return mock.MessagesFunc(ctx, conversationID, last, before)
+}
+
+// MessagesCalls gets all the calls that were made to Messages.
+// Check the length with:
+//
+//	len(mockedService.MessagesCalls())
+func (mock *ServiceMock) MessagesCalls() []struct {
+	Ctx            context.Context
+	ConversationID string
+	Last           uint64
+	Before         *string
+} {
+	var calls []struct {
+		Ctx            context.Context
+		ConversationID string
+		Last           uint64
+		Before         *string
+	}
+	mock.lockMessages.RLock()
+	calls = mock.calls.Messages
+	mock.lockMessages.RUnlock()
+	return calls
+}
+
 // NotificationStream calls NotificationStreamFunc.
-func (mock *ServiceMock) NotificationStream(ctx context.Context) (<-chan nakama.Notification, error) {
+func (mock *ServiceMock) NotificationStream(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.Notification], error) {
 	callInfo := struct {
-		Ctx context.Context
+		Ctx         context.Context
+		LastEventID string
 	}{
-		Ctx: ctx,
+		Ctx:         ctx,
+		LastEventID: lastEventID,
 	}
 	mock.lockNotificationStream.Lock()
 	mock.calls.NotificationStream = append(mock.calls.NotificationStream, callInfo)
 	mock.lockNotificationStream.Unlock()
 	if mock.NotificationStreamFunc == nil {
 		var (
-			notificationChOut <-chan nakama.Notification
-			errOut            error
+			streamEventChOut <-chan nakama.StreamEvent[nakama.Notification]
+			errOut           error
 		)
-		return notificationChOut, errOut
+		return streamEventChOut, errOut
 	}
-	return mock.NotificationStreamFunc(ctx)
+	return mock.NotificationStreamFunc(ctx, lastEventID)
 }
 
 // NotificationStreamCalls gets all the calls that were made to NotificationStream.
@@ -1351,10 +2447,12 @@ func (mock *ServiceMock) NotificationStream(ctx context.Context) (<-chan nakama.
 //
 //	len(mockedService.NotificationStreamCalls())
 func (mock *ServiceMock) NotificationStreamCalls() []struct {
-	Ctx context.Context
+	Ctx         context.Context
+	LastEventID string
 } {
 	var calls []struct {
-		Ctx context.Context
+		Ctx         context.Context
+		LastEventID string
 	}
 	mock.lockNotificationStream.RLock()
 	calls = mock.calls.NotificationStream
@@ -1566,6 +2664,100 @@ func (mock *ServiceMock) PostsCalls() []struct {
 	return calls
 }
 
+// PutUpload calls PutUploadFunc.
+func (mock *ServiceMock) PutUpload(ctx context.Context, uploadID string, expires string, signature string, r io.Reader, size int64) error {
+	callInfo := struct {
+		Ctx       context.Context
+		UploadID  string
+		Expires   string
+		Signature string
+		R         io.Reader
+		Size      int64
+	}{
+		Ctx:       ctx,
+		UploadID:  uploadID,
+		Expires:   expires,
+		Signature: signature,
+		R:         r,
+		Size:      size,
+	}
+	mock.lockPutUpload.Lock()
+	mock.calls.PutUpload = append(mock.calls.PutUpload, callInfo)
+	mock.lockPutUpload.Unlock()
+	if mock.PutUploadFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.PutUploadFunc(ctx, uploadID, expires, signature, r, size)
+}
+
+// PutUploadCalls gets all the calls that were made to PutUpload.
+// Check the length with:
+//
+//	len(mockedService.PutUploadCalls())
+func (mock *ServiceMock) PutUploadCalls() []struct {
+	Ctx       context.Context
+	UploadID  string
+	Expires   string
+	Signature string
+	R         io.Reader
+	Size      int64
+} {
+	var calls []struct {
+		Ctx       context.Context
+		UploadID  string
+		Expires   string
+		Signature string
+		R         io.Reader
+		Size      int64
+	}
+	mock.lockPutUpload.RLock()
+	calls = mock.calls.PutUpload
+	mock.lockPutUpload.RUnlock()
+	return calls
+}
+
+// SendCommentTyping calls SendCommentTypingFunc.
+func (mock *ServiceMock) SendCommentTyping(ctx context.Context, postID string) error {
+	callInfo := struct {
+		Ctx    context.Context
+		PostID string
+	}{
+		Ctx:    ctx,
+		PostID: postID,
+	}
+	mock.lockSendCommentTyping.Lock()
+	mock.calls.SendCommentTyping = append(mock.calls.SendCommentTyping, callInfo)
+	mock.lockSendCommentTyping.Unlock()
+	if mock.SendCommentTypingFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.SendCommentTypingFunc(ctx, postID)
+}
+
+// SendCommentTypingCalls gets all the calls that were made to SendCommentTyping.
+// Check the length with:
+//
+//	len(mockedService.SendCommentTypingCalls())
+func (mock *ServiceMock) SendCommentTypingCalls() []struct {
+	Ctx    context.Context
+	PostID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		PostID string
+	}
+	mock.lockSendCommentTyping.RLock()
+	calls = mock.calls.SendCommentTyping
+	mock.lockSendCommentTyping.RUnlock()
+	return calls
+}
+
 // SendMagicLink calls SendMagicLinkFunc.
 func (mock *ServiceMock) SendMagicLink(ctx context.Context, in nakama.SendMagicLink) error {
 	callInfo := struct {
@@ -1596,12 +2788,60 @@ func (mock *ServiceMock) SendMagicLinkCalls() []struct {
 	In  nakama.SendMagicLink
 } {
 	var calls []struct {
-		Ctx context.Context
-		In  nakama.SendMagicLink
+		Ctx context.Context
+		In  nakama.SendMagicLink
+	}
+	mock.lockSendMagicLink.RLock()
+	calls = mock.calls.SendMagicLink
+	mock.lockSendMagicLink.RUnlock()
+	return calls
+}
+
+// SendMessage calls SendMessageFunc.
+func (mock *ServiceMock) SendMessage(ctx context.Context, conversationID string, content string, media []io.ReadSeeker) (nakama.Message, error) {
+	callInfo := struct {
+		Ctx            context.Context
+		ConversationID string
+		Content        string
+		Media          []io.ReadSeeker
+	}{
+		Ctx:            ctx,
+		ConversationID: conversationID,
+		Content:        content,
+		Media:          media,
+	}
+	mock.lockSendMessage.Lock()
+	mock.calls.SendMessage = append(mock.calls.SendMessage, callInfo)
+	mock.lockSendMessage.Unlock()
+	if mock.SendMessageFunc == nil {
+		var (
+			messageOut nakama.Message
+			errOut     error
+		)
+		return messageOut, errOut
+	}
+	return mock.SendMessageFunc(ctx, conversationID, content, media)
+}
+
+// SendMessageCalls gets all the calls that were made to SendMessage.
+// Check the length with:
+//
+//	len(mockedService.SendMessageCalls())
+func (mock *ServiceMock) SendMessageCalls() []struct {
+	Ctx            context.Context
+	ConversationID string
+	Content        string
+	Media          []io.ReadSeeker
+} {
+	var calls []struct {
+		Ctx            context.Context
+		ConversationID string
+		Content        string
+		Media          []io.ReadSeeker
 	}
-	mock.lockSendMagicLink.RLock()
-	calls = mock.calls.SendMagicLink
-	mock.lockSendMagicLink.RUnlock()
+	mock.lockSendMessage.RLock()
+	calls = mock.calls.SendMessage
+	mock.lockSendMessage.RUnlock()
 	return calls
 }
 
@@ -1650,23 +2890,25 @@ func (mock *ServiceMock) TimelineCalls() []struct {
 }
 
 // TimelineItemStream calls TimelineItemStreamFunc.
-func (mock *ServiceMock) TimelineItemStream(ctx context.Context) (<-chan nakama.TimelineItem, error) {
+func (mock *ServiceMock) TimelineItemStream(ctx context.Context, lastEventID string) (<-chan nakama.StreamEvent[nakama.TimelineItem], error) {
 	callInfo := struct {
-		Ctx context.Context
+		Ctx         context.Context
+		LastEventID string
 	}{
-		Ctx: ctx,
+		Ctx:         ctx,
+		LastEventID: lastEventID,
 	}
 	mock.lockTimelineItemStream.Lock()
 	mock.calls.TimelineItemStream = append(mock.calls.TimelineItemStream, callInfo)
 	mock.lockTimelineItemStream.Unlock()
 	if mock.TimelineItemStreamFunc == nil {
 		var (
-			timelineItemChOut <-chan nakama.TimelineItem
-			errOut            error
+			streamEventChOut <-chan nakama.StreamEvent[nakama.TimelineItem]
+			errOut           error
 		)
-		return timelineItemChOut, errOut
+		return streamEventChOut, errOut
 	}
-	return mock.TimelineItemStreamFunc(ctx)
+	return mock.TimelineItemStreamFunc(ctx, lastEventID)
 }
 
 // TimelineItemStreamCalls gets all the calls that were made to TimelineItemStream.
@@ -1674,10 +2916,12 @@ func (mock *ServiceMock) TimelineItemStream(ctx context.Context) (<-chan nakama.
 //
 //	len(mockedService.TimelineItemStreamCalls())
 func (mock *ServiceMock) TimelineItemStreamCalls() []struct {
-	Ctx context.Context
+	Ctx         context.Context
+	LastEventID string
 } {
 	var calls []struct {
-		Ctx context.Context
+		Ctx         context.Context
+		LastEventID string
 	}
 	mock.lockTimelineItemStream.RLock()
 	calls = mock.calls.TimelineItemStream
@@ -1685,6 +2929,46 @@ func (mock *ServiceMock) TimelineItemStreamCalls() []struct {
 	return calls
 }
 
+// ToggleBlock calls ToggleBlockFunc.
+func (mock *ServiceMock) ToggleBlock(ctx context.Context, username string) (nakama.ToggleBlockOutput, error) {
+	callInfo := struct {
+		Ctx      context.Context
+		Username string
+	}{
+		Ctx:      ctx,
+		Username: username,
+	}
+	mock.lockToggleBlock.Lock()
+	mock.calls.ToggleBlock = append(mock.calls.ToggleBlock, callInfo)
+	mock.lockToggleBlock.Unlock()
+	if mock.ToggleBlockFunc == nil {
+		var (
+			toggleBlockOutputOut nakama.ToggleBlockOutput
+			errOut               error
+		)
+		return toggleBlockOutputOut, errOut
+	}
+	return mock.ToggleBlockFunc(ctx, username)
+}
+
+// ToggleBlockCalls gets all the calls that were made to ToggleBlock.
+// Check the length with:
+//
+//	len(mockedService.ToggleBlockCalls())
+func (mock *ServiceMock) ToggleBlockCalls() []struct {
+	Ctx      context.Context
+	Username string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Username string
+	}
+	mock.lockToggleBlock.RLock()
+	calls = mock.calls.ToggleBlock
+	mock.lockToggleBlock.RUnlock()
+	return calls
+}
+
 // ToggleCommentReaction calls ToggleCommentReactionFunc.
 func (mock *ServiceMock) ToggleCommentReaction(ctx context.Context, commentID string, in nakama.ReactionInput) ([]nakama.Reaction, error) {
 	callInfo := struct {
@@ -1889,6 +3173,121 @@ func (mock *ServiceMock) TokenCalls() []struct {
 	return calls
 }
 
+// UnreadMessagesCount calls UnreadMessagesCountFunc.
+func (mock *ServiceMock) UnreadMessagesCount(ctx context.Context) (uint64, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockUnreadMessagesCount.Lock()
+	mock.calls.UnreadMessagesCount = append(mock.calls.UnreadMessagesCount, callInfo)
+	mock.lockUnreadMessagesCount.Unlock()
+	if mock.UnreadMessagesCountFunc == nil {
+		var (
+			vOut   uint64
+			errOut error
+		)
+		return vOut, errOut
+	}
+	return mock.UnreadMessagesCountFunc(ctx)
+}
+
+// UnreadMessagesCountCalls gets all the calls that were made to UnreadMessagesCount.
+// Check the length with:
+//
+//	len(mockedService.UnreadMessagesCountCalls())
+func (mock *ServiceMock) UnreadMessagesCountCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockUnreadMessagesCount.RLock()
+	calls = mock.calls.UnreadMessagesCount
+	mock.lockUnreadMessagesCount.RUnlock()
+	return calls
+}
+
+// UnreadNotificationsCount calls UnreadNotificationsCountFunc.
+func (mock *ServiceMock) UnreadNotificationsCount(ctx context.Context) (uint64, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockUnreadNotificationsCount.Lock()
+	mock.calls.UnreadNotificationsCount = append(mock.calls.UnreadNotificationsCount, callInfo)
+	mock.lockUnreadNotificationsCount.Unlock()
+	if mock.UnreadNotificationsCountFunc == nil {
+		var (
+			vOut   uint64
+			errOut error
+		)
+		return vOut, errOut
+	}
+	return mock.UnreadNotificationsCountFunc(ctx)
+}
+
+// UnreadNotificationsCountCalls gets all the calls that were made to UnreadNotificationsCount.
+// Check the length with:
+//
+//	len(mockedService.UnreadNotificationsCountCalls())
+func (mock *ServiceMock) UnreadNotificationsCountCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockUnreadNotificationsCount.RLock()
+	calls = mock.calls.UnreadNotificationsCount
+	mock.lockUnreadNotificationsCount.RUnlock()
+	return calls
+}
+
+// UnsubscribeEmailDigest calls UnsubscribeEmailDigestFunc.
+func (mock *ServiceMock) UnsubscribeEmailDigest(ctx context.Context, userID string, token string) error {
+	callInfo := struct {
+		Ctx    context.Context
+		UserID string
+		Token  string
+	}{
+		Ctx:    ctx,
+		UserID: userID,
+		Token:  token,
+	}
+	mock.lockUnsubscribeEmailDigest.Lock()
+	mock.calls.UnsubscribeEmailDigest = append(mock.calls.UnsubscribeEmailDigest, callInfo)
+	mock.lockUnsubscribeEmailDigest.Unlock()
+	if mock.UnsubscribeEmailDigestFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.UnsubscribeEmailDigestFunc(ctx, userID, token)
+}
+
+// UnsubscribeEmailDigestCalls gets all the calls that were made to UnsubscribeEmailDigest.
+// Check the length with:
+//
+//	len(mockedService.UnsubscribeEmailDigestCalls())
+func (mock *ServiceMock) UnsubscribeEmailDigestCalls() []struct {
+	Ctx    context.Context
+	UserID string
+	Token  string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		UserID string
+		Token  string
+	}
+	mock.lockUnsubscribeEmailDigest.RLock()
+	calls = mock.calls.UnsubscribeEmailDigest
+	mock.lockUnsubscribeEmailDigest.RUnlock()
+	return calls
+}
+
 // UpdateAvatar calls UpdateAvatarFunc.
 func (mock *ServiceMock) UpdateAvatar(ctx context.Context, r io.ReadSeeker) (string, error) {
 	callInfo := struct {
@@ -2009,6 +3408,84 @@ func (mock *ServiceMock) UpdateCoverCalls() []struct {
 	return calls
 }
 
+// UpdateDMSettings calls UpdateDMSettingsFunc.
+func (mock *ServiceMock) UpdateDMSettings(ctx context.Context, in nakama.DMSettings) error {
+	callInfo := struct {
+		Ctx context.Context
+		In  nakama.DMSettings
+	}{
+		Ctx: ctx,
+		In:  in,
+	}
+	mock.lockUpdateDMSettings.Lock()
+	mock.calls.UpdateDMSettings = append(mock.calls.UpdateDMSettings, callInfo)
+	mock.lockUpdateDMSettings.Unlock()
+	if mock.UpdateDMSettingsFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.UpdateDMSettingsFunc(ctx, in)
+}
+
+// UpdateDMSettingsCalls gets all the calls that were made to UpdateDMSettings.
+// Check the length with:
+//
+//	len(mockedService.UpdateDMSettingsCalls())
+func (mock *ServiceMock) UpdateDMSettingsCalls() []struct {
+	Ctx context.Context
+	In  nakama.DMSettings
+} {
+	var calls []struct {
+		Ctx context.Context
+		In  nakama.DMSettings
+	}
+	mock.lockUpdateDMSettings.RLock()
+	calls = mock.calls.UpdateDMSettings
+	mock.lockUpdateDMSettings.RUnlock()
+	return calls
+}
+
+// UpdateEmailDigestSettings calls UpdateEmailDigestSettingsFunc.
+func (mock *ServiceMock) UpdateEmailDigestSettings(ctx context.Context, in nakama.EmailDigestSettings) error {
+	callInfo := struct {
+		Ctx context.Context
+		In  nakama.EmailDigestSettings
+	}{
+		Ctx: ctx,
+		In:  in,
+	}
+	mock.lockUpdateEmailDigestSettings.Lock()
+	mock.calls.UpdateEmailDigestSettings = append(mock.calls.UpdateEmailDigestSettings, callInfo)
+	mock.lockUpdateEmailDigestSettings.Unlock()
+	if mock.UpdateEmailDigestSettingsFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.UpdateEmailDigestSettingsFunc(ctx, in)
+}
+
+// UpdateEmailDigestSettingsCalls gets all the calls that were made to UpdateEmailDigestSettings.
+// Check the length with:
+//
+//	len(mockedService.UpdateEmailDigestSettingsCalls())
+func (mock *ServiceMock) UpdateEmailDigestSettingsCalls() []struct {
+	Ctx context.Context
+	In  nakama.EmailDigestSettings
+} {
+	var calls []struct {
+		Ctx context.Context
+		In  nakama.EmailDigestSettings
+	}
+	mock.lockUpdateEmailDigestSettings.RLock()
+	calls = mock.calls.UpdateEmailDigestSettings
+	mock.lockUpdateEmailDigestSettings.RUnlock()
+	return calls
+}
+
 // UpdatePost calls UpdatePostFunc.
 func (mock *ServiceMock) UpdatePost(ctx context.Context, postID string, in nakama.UpdatePost) (nakama.UpdatedPost, error) {
 	callInfo := struct {
@@ -2053,6 +3530,53 @@ func (mock *ServiceMock) UpdatePostCalls() []struct {
 	return calls
 }
 
+// UpdatePostMediaAltText calls UpdatePostMediaAltTextFunc.
+func (mock *ServiceMock) UpdatePostMediaAltText(ctx context.Context, postID string, name string, altText *string) error {
+	callInfo := struct {
+		Ctx     context.Context
+		PostID  string
+		Name    string
+		AltText *string
+	}{
+		Ctx:     ctx,
+		PostID:  postID,
+		Name:    name,
+		AltText: altText,
+	}
+	mock.lockUpdatePostMediaAltText.Lock()
+	mock.calls.UpdatePostMediaAltText = append(mock.calls.UpdatePostMediaAltText, callInfo)
+	mock.lockUpdatePostMediaAltText.Unlock()
+	if mock.UpdatePostMediaAltTextFunc == nil {
+		var (
+			errOut error
+		)
+		return errOut
+	}
+	return mock.UpdatePostMediaAltTextFunc(ctx, postID, name, altText)
+}
+
+// UpdatePostMediaAltTextCalls gets all the calls that were made to UpdatePostMediaAltText.
+// Check the length with:
+//
+//	len(mockedService.UpdatePostMediaAltTextCalls())
+func (mock *ServiceMock) UpdatePostMediaAltTextCalls() []struct {
+	Ctx     context.Context
+	PostID  string
+	Name    string
+	AltText *string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		PostID  string
+		Name    string
+		AltText *string
+	}
+	mock.lockUpdatePostMediaAltText.RLock()
+	calls = mock.calls.UpdatePostMediaAltText
+	mock.lockUpdatePostMediaAltText.RUnlock()
+	return calls
+}
+
 // UpdateUser calls UpdateUserFunc.
 func (mock *ServiceMock) UpdateUser(ctx context.Context, params nakama.UpdateUserParams) error {
 	callInfo := struct {
@@ -2275,3 +3799,159 @@ func (mock *ServiceMock) VerifyMagicLinkCalls() []struct {
 	mock.lockVerifyMagicLink.RUnlock()
 	return calls
 }
+
+// WebPushPublicKey calls WebPushPublicKeyFunc.
+func (mock *ServiceMock) WebPushPublicKey(ctx context.Context) (string, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockWebPushPublicKey.Lock()
+	mock.calls.WebPushPublicKey = append(mock.calls.WebPushPublicKey, callInfo)
+	mock.lockWebPushPublicKey.Unlock()
+	if mock.WebPushPublicKeyFunc == nil {
+		var (
+			sOut   string
+			errOut error
+		)
+		return sOut, errOut
+	}
+	return mock.WebPushPublicKeyFunc(ctx)
+}
+
+// WebPushPublicKeyCalls gets all the calls that were made to WebPushPublicKey.
+// Check the length with:
+//
+//	len(mockedService.WebPushPublicKeyCalls())
+func (mock *ServiceMock) WebPushPublicKeyCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockWebPushPublicKey.RLock()
+	calls = mock.calls.WebPushPublicKey
+	mock.lockWebPushPublicKey.RUnlock()
+	return calls
+}
+
+// WebPushSubscriptions calls WebPushSubscriptionsFunc.
+func (mock *ServiceMock) WebPushSubscriptions(ctx context.Context) ([]nakama.WebPushSubscription, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockWebPushSubscriptions.Lock()
+	mock.calls.WebPushSubscriptions = append(mock.calls.WebPushSubscriptions, callInfo)
+	mock.lockWebPushSubscriptions.Unlock()
+	if mock.WebPushSubscriptionsFunc == nil {
+		var (
+			webPushSubscriptionsOut []nakama.WebPushSubscription
+			errOut                  error
+		)
+		return webPushSubscriptionsOut, errOut
+	}
+	return mock.WebPushSubscriptionsFunc(ctx)
+}
+
+// WebPushSubscriptionsCalls gets all the calls that were made to WebPushSubscriptions.
+// Check the length with:
+//
+//	len(mockedService.WebPushSubscriptionsCalls())
+func (mock *ServiceMock) WebPushSubscriptionsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockWebPushSubscriptions.RLock()
+	calls = mock.calls.WebPushSubscriptions
+	mock.lockWebPushSubscriptions.RUnlock()
+	return calls
+}
+
+// WebhookDeliveries calls WebhookDeliveriesFunc.
+func (mock *ServiceMock) WebhookDeliveries(ctx context.Context, webhookID string, last uint64, before *string) (nakama.WebhookDeliveries, error) {
+	callInfo := struct {
+		Ctx       context.Context
+		WebhookID string
+		Last      uint64
+		Before    *string
+	}{
+		Ctx:       ctx,
+		WebhookID: webhookID,
+		Last:      last,
+		Before:    before,
+	}
+	mock.lockWebhookDeliveries.Lock()
+	mock.calls.WebhookDeliveries = append(mock.calls.WebhookDeliveries, callInfo)
+	mock.lockWebhookDeliveries.Unlock()
+	if mock.WebhookDeliveriesFunc == nil {
+		var (
+			webhookDeliveriesOut nakama.WebhookDeliveries
+			errOut               error
+		)
+		return webhookDeliveriesOut, errOut
+	}
+	return mock.WebhookDeliveriesFunc(ctx, webhookID, last, before)
+}
+
+// WebhookDeliveriesCalls gets all the calls that were made to WebhookDeliveries.
+// Check the length with:
+//
+//	len(mockedService.WebhookDeliveriesCalls())
+func (mock *ServiceMock) WebhookDeliveriesCalls() []struct {
+	Ctx       context.Context
+	WebhookID string
+	Last      uint64
+	Before    *string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		WebhookID string
+		Last      uint64
+		Before    *string
+	}
+	mock.lockWebhookDeliveries.RLock()
+	calls = mock.calls.WebhookDeliveries
+	mock.lockWebhookDeliveries.RUnlock()
+	return calls
+}
+
+// Webhooks calls WebhooksFunc.
+func (mock *ServiceMock) Webhooks(ctx context.Context) ([]nakama.Webhook, error) {
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockWebhooks.Lock()
+	mock.calls.Webhooks = append(mock.calls.Webhooks, callInfo)
+	mock.lockWebhooks.Unlock()
+	if mock.WebhooksFunc == nil {
+		var (
+			webhooksOut []nakama.Webhook
+			errOut      error
+		)
+		return webhooksOut, errOut
+	}
+	return mock.WebhooksFunc(ctx)
+}
+
+// WebhooksCalls gets all the calls that were made to Webhooks.
+// Check the length with:
+//
+//	len(mockedService.WebhooksCalls())
+func (mock *ServiceMock) WebhooksCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockWebhooks.RLock()
+	calls = mock.calls.Webhooks
+	mock.lockWebhooks.RUnlock()
+	return calls
+}
@@ -0,0 +1,481 @@
+package nakama
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/v2/crdb"
+
+	"github.com/nakamauwu/nakama/storage"
+)
+
+// UploadsBucket holds the objects uploaded directly by clients
+// until they get finalized.
+const UploadsBucket = "uploads"
+
+const (
+	// MaxUploadedMediaItemBytes is the size limit of post media items
+	// uploaded directly to storage.
+	MaxUploadedMediaItemBytes = 25 << 20 // 25MB
+
+	// uploadIntentTTL is how long the upload URL is valid.
+	// Uploads must be finalized within twice this time.
+	uploadIntentTTL = time.Minute * 15
+	// uploadsRetention is how long finalized post uploads
+	// wait to be attached to a post.
+	uploadsRetention     = time.Hour * 24
+	uploadsPruneInterval = time.Hour
+)
+
+// Upload purposes.
+const (
+	UploadPurposePost   = "post"
+	UploadPurposeAvatar = "avatar"
+	UploadPurposeCover  = "cover"
+)
+
+var (
+	// ErrInvalidUploadID denotes an invalid upload ID; that is not uuid.
+	ErrInvalidUploadID = InvalidArgumentError("invalid upload ID")
+	// ErrInvalidUploadPurpose denotes an unknown upload purpose.
+	ErrInvalidUploadPurpose = InvalidArgumentError("invalid upload purpose")
+	// ErrInvalidUploadSize denotes an empty or too large upload,
+	// or one not matching the declared size.
+	ErrInvalidUploadSize = InvalidArgumentError("invalid upload size")
+	// ErrUnsupportedUploadContentType denotes an unsupported upload content type.
+	ErrUnsupportedUploadContentType = InvalidArgumentError("unsupported upload content type")
+	// ErrUploadIncomplete denotes finalizing an upload that has not been uploaded yet.
+	ErrUploadIncomplete = InvalidArgumentError("upload incomplete")
+	// ErrInvalidUploadSignature denotes an invalid local upload URL signature.
+	ErrInvalidUploadSignature = PermissionDeniedError("invalid upload signature")
+	// ErrUploadNotFound denotes a not found upload,
+	// or one already finalized or attached.
+	ErrUploadNotFound = NotFoundError("upload not found")
+	// ErrUploadExpired denotes an upload past its deadline.
+	ErrUploadExpired = GoneError("upload expired")
+)
+
+type CreateUploadIntent struct {
+	Purpose     string `json:"purpose"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+// UploadIntent tells clients where to upload an object directly.
+// Send a request with the given method to UploadURL including the headers,
+// then finalize it.
+type UploadIntent struct {
+	ID        string            `json:"id"`
+	Purpose   string            `json:"purpose"`
+	Method    string            `json:"method"`
+	UploadURL string            `json:"uploadURL"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// FinalizedUpload is a processed upload.
+// Post uploads are not attached until passed to CreateTimelineItem.
+type FinalizedUpload struct {
+	ID      string `json:"id"`
+	Purpose string `json:"purpose"`
+	URL     string `json:"url"`
+}
+
+// CreateUploadIntent so the authenticated user can upload an object
+// directly to storage without passing through the server.
+// Stores without presigned URLs get a signed local upload URL instead.
+func (s *Service) CreateUploadIntent(ctx context.Context, in CreateUploadIntent) (UploadIntent, error) {
+	var out UploadIntent
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	maxBytes, ok := uploadMaxBytes(in.Purpose)
+	if !ok {
+		return out, ErrInvalidUploadPurpose
+	}
+
+	if !supportedImageType(in.ContentType) {
+		return out, ErrUnsupportedUploadContentType
+	}
+
+	if in.Size <= 0 || in.Size > maxBytes {
+		return out, ErrInvalidUploadSize
+	}
+
+	if err := s.checkStorageQuota(ctx, uid, in.Size); err != nil {
+		return out, err
+	}
+
+	// recorded once finalized along with the media.
+	if err := s.checkUploadLimits(ctx, s.DB, uid, in.Size); err != nil {
+		return out, err
+	}
+
+	out.ExpiresAt = time.Now().Add(uploadIntentTTL).Truncate(time.Second)
+
+	query := `
+		INSERT INTO uploads (user_id, purpose, content_type, size, expires_at) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	row := s.DB.QueryRowContext(ctx, query, uid, in.Purpose, in.ContentType, in.Size, out.ExpiresAt)
+	if err := row.Scan(&out.ID); err != nil {
+		if isForeignKeyViolation(err) {
+			return out, ErrUserGone
+		}
+
+		return out, fmt.Errorf("could not sql insert upload: %w", err)
+	}
+
+	out.Purpose = in.Purpose
+	out.Method = http.MethodPut
+
+	if p, ok := s.Store.(storage.Presigner); ok {
+		u, header, err := p.PresignPut(ctx, UploadsBucket, out.ID, in.Size, in.ContentType, uploadIntentTTL)
+		if err != nil {
+			return out, err
+		}
+
+		out.UploadURL = u
+		out.Headers = map[string]string{}
+		for k := range header {
+			out.Headers[k] = header.Get(k)
+		}
+
+		return out, nil
+	}
+
+	out.UploadURL = s.localUploadURL(out.ID, out.ExpiresAt)
+	out.Headers = map[string]string{"Content-Type": in.ContentType}
+
+	return out, nil
+}
+
+// PutUpload stores the object of a local upload URL.
+// The URL signature authorizes the request; no authentication needed.
+func (s *Service) PutUpload(ctx context.Context, uploadID, expires, signature string, r io.Reader, size int64) error {
+	if !reUUID.MatchString(uploadID) {
+		return ErrInvalidUploadID
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.uploadSignature(uploadID, expires))) {
+		return ErrInvalidUploadSignature
+	}
+
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidUploadSignature
+	}
+
+	if time.Now().After(time.Unix(exp, 0)) {
+		return ErrUploadExpired
+	}
+
+	var contentType string
+	var declaredSize int64
+	query := "SELECT content_type, size FROM uploads WHERE id = $1 AND finalized_at IS NULL"
+	err = s.DB.QueryRowContext(ctx, query, uploadID).Scan(&contentType, &declaredSize)
+	if err == sql.ErrNoRows {
+		return ErrUploadNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not sql query select upload: %w", err)
+	}
+
+	if size != declaredSize {
+		return ErrInvalidUploadSize
+	}
+
+	err = s.Store.Put(ctx, UploadsBucket, uploadID, r, size, storage.StoreWithContentType(contentType))
+	if err != nil {
+		return fmt.Errorf("could not store upload: %w", err)
+	}
+
+	return nil
+}
+
+// FinalizeUpload validates and processes an uploaded object.
+// Avatar and cover uploads update the authenticated user profile right away.
+func (s *Service) FinalizeUpload(ctx context.Context, uploadID string) (FinalizedUpload, error) {
+	var out FinalizedUpload
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(uploadID) {
+		return out, ErrInvalidUploadID
+	}
+
+	// claim it first so concurrent calls cannot finalize it twice.
+	var size int64
+	var expiresAt time.Time
+	query := `
+		UPDATE uploads SET finalized_at = now()
+		WHERE id = $1 AND user_id = $2 AND finalized_at IS NULL
+		RETURNING purpose, size, expires_at`
+	err := s.DB.QueryRowContext(ctx, query, uploadID, uid).Scan(&out.Purpose, &size, &expiresAt)
+	if err == sql.ErrNoRows {
+		return out, ErrUploadNotFound
+	}
+
+	if err != nil {
+		return out, fmt.Errorf("could not sql update upload as finalized: %w", err)
+	}
+
+	out.ID = uploadID
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		// release the claim so it can be retried.
+		query := "UPDATE uploads SET finalized_at = NULL WHERE id = $1"
+		if _, err := s.DB.ExecContext(context.Background(), query, uploadID); err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not sql reset upload finalization: %w", err))
+		}
+	}()
+
+	if time.Now().After(expiresAt.Add(uploadIntentTTL)) {
+		err = ErrUploadExpired
+		return out, err
+	}
+
+	f, err := s.Store.Open(ctx, UploadsBucket, uploadID)
+	if errors.Is(err, storage.ErrNotFound) {
+		err = ErrUploadIncomplete
+		return out, err
+	}
+
+	if err != nil {
+		err = fmt.Errorf("could not open upload: %w", err)
+		return out, err
+	}
+
+	defer f.Close()
+
+	if f.Size != size {
+		err = ErrInvalidUploadSize
+		return out, err
+	}
+
+	switch out.Purpose {
+	case UploadPurposeAvatar:
+		out.URL, err = s.UpdateAvatar(ctx, f)
+	case UploadPurposeCover:
+		out.URL, err = s.UpdateCover(ctx, f)
+	case UploadPurposePost:
+		out.URL, err = s.finalizePostUpload(ctx, uploadID, f)
+	default:
+		err = ErrInvalidUploadPurpose
+	}
+	if err != nil {
+		return out, err
+	}
+
+	go s.deleteUploadObject(uploadID)
+
+	if out.Purpose != UploadPurposePost {
+		query := "DELETE FROM uploads WHERE id = $1"
+		if _, err := s.DB.ExecContext(ctx, query, uploadID); err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not sql delete finalized upload: %w", err))
+		}
+	}
+
+	return out, nil
+}
+
+func (s *Service) finalizePostUpload(ctx context.Context, uploadID string, r io.ReadSeeker) (string, error) {
+	file, err := processMediaItem(r, MaxUploadedMediaItemBytes)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.storeMediaFiles(ctx, []mediaFile{file}); err != nil {
+		return "", fmt.Errorf("could not store uploaded media: %w", err)
+	}
+
+	err = crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
+		query := "UPDATE uploads SET media = $1 WHERE id = $2 RETURNING user_id"
+		var uid string
+		if err := tx.QueryRowContext(ctx, query, file.Name, uploadID).Scan(&uid); err != nil {
+			return fmt.Errorf("could not sql update upload media: %w", err)
+		}
+
+		if err := s.recordUploads(ctx, tx, uid, mediaFileSizes([]mediaFile{file})...); err != nil {
+			return err
+		}
+
+		if err := insertMediaItems(ctx, tx, uid, []mediaFile{file}); err != nil {
+			return err
+		}
+
+		return s.acquireMedia(ctx, tx, uid, []mediaFile{file})
+	})
+	// stored media is left to the storage garbage collector on error
+	// since identical media might be getting referenced concurrently.
+	if err != nil {
+		return "", err
+	}
+
+	return s.mediaURL(file.Name), nil
+}
+
+// attachUploads claims the given finalized post uploads
+// returning their media in the same order.
+func attachUploads(ctx context.Context, tx *sql.Tx, uid string, uploadIDs []string) ([]string, error) {
+	media := make([]string, len(uploadIDs))
+	for i, uploadID := range uploadIDs {
+		query := `
+			DELETE FROM uploads
+			WHERE id = $1 AND user_id = $2 AND purpose = $3 AND media IS NOT NULL
+			RETURNING media`
+		err := tx.QueryRowContext(ctx, query, uploadID, uid, UploadPurposePost).Scan(&media[i])
+		if err == sql.ErrNoRows {
+			return nil, ErrUploadNotFound
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not sql delete attached upload: %w", err)
+		}
+	}
+
+	return media, nil
+}
+
+func (s *Service) deleteUploadObject(uploadID string) {
+	err := s.Store.Delete(context.Background(), UploadsBucket, uploadID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		_ = s.Logger.Log("error", fmt.Errorf("could not delete upload object: %w", err))
+	}
+}
+
+func (s *Service) pruneUploadsJob(ctx context.Context) {
+	ticker := time.NewTicker(uploadsPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.pruneUploads(ctx); err != nil {
+				_ = s.Logger.Log("error", fmt.Errorf("could not prune uploads: %w", err))
+			}
+
+			if err := s.pruneUploadEvents(ctx); err != nil {
+				_ = s.Logger.Log("error", fmt.Errorf("could not prune upload events: %w", err))
+			}
+		}
+	}
+}
+
+// pruneUploads deletes the uploads never finalized along with their objects,
+// and the finalized post uploads never attached releasing their media.
+func (s *Service) pruneUploads(ctx context.Context) error {
+	now := time.Now()
+	var pending, unreferenced []string
+	err := crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			DELETE FROM uploads
+			WHERE (finalized_at IS NULL AND expires_at < $1)
+				OR (finalized_at IS NOT NULL AND finalized_at < $2)
+			RETURNING id, user_id, finalized_at IS NULL, media`, now.Add(-uploadIntentTTL), now.Add(-uploadsRetention))
+		if err != nil {
+			return fmt.Errorf("could not sql delete expired uploads: %w", err)
+		}
+
+		defer rows.Close()
+
+		pending = nil
+		media := map[string][]string{}
+		for rows.Next() {
+			var id, uid string
+			var unfinalized bool
+			var mediaItem sql.NullString
+			if err := rows.Scan(&id, &uid, &unfinalized, &mediaItem); err != nil {
+				return fmt.Errorf("could not sql scan expired upload: %w", err)
+			}
+
+			if unfinalized {
+				pending = append(pending, id)
+			}
+
+			if mediaItem.Valid {
+				media[uid] = append(media[uid], mediaItem.String)
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("could not iterate over expired upload rows: %w", err)
+		}
+
+		rows.Close()
+
+		unreferenced = nil
+		for uid, names := range media {
+			released, err := s.releaseMedia(ctx, tx, uid, names)
+			if err != nil {
+				return err
+			}
+
+			unreferenced = append(unreferenced, released...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range pending {
+		s.deleteUploadObject(id)
+	}
+
+	if err := s.deleteUnreferencedMedia(ctx, unreferenced); err != nil {
+		return fmt.Errorf("could not delete released upload media: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) localUploadURL(uploadID string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	u := cloneURL(s.Origin)
+	u.Path = "/api/uploads/" + uploadID
+	u.RawQuery = url.Values{
+		"expires":   []string{expires},
+		"signature": []string{s.uploadSignature(uploadID, expires)},
+	}.Encode()
+	return u.String()
+}
+
+func (s *Service) uploadSignature(uploadID, expires string) string {
+	h := hmac.New(sha256.New, []byte(s.TokenKey))
+	h.Write([]byte("upload:" + uploadID + ":" + expires))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func uploadMaxBytes(purpose string) (int64, bool) {
+	switch purpose {
+	case UploadPurposePost:
+		return MaxUploadedMediaItemBytes, true
+	case UploadPurposeAvatar:
+		return MaxAvatarBytes, true
+	case UploadPurposeCover:
+		return MaxCoverBytes, true
+	}
+	return 0, false
+}
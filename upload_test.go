@@ -0,0 +1,90 @@
+package nakama
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestService_CreateUploadIntent(t *testing.T) {
+	svc := &Service{Logger: log.NewNopLogger()}
+	ctx := context.WithValue(context.Background(), KeyAuthUserID, "00000001-0000-0000-0000-000000000000")
+
+	tests := []struct {
+		name string
+		in   CreateUploadIntent
+		want error
+	}{
+		{
+			name: "invalid_purpose",
+			in:   CreateUploadIntent{Purpose: "nope", ContentType: "image/png", Size: 1},
+			want: ErrInvalidUploadPurpose,
+		},
+		{
+			name: "unsupported_content_type",
+			in:   CreateUploadIntent{Purpose: UploadPurposePost, ContentType: "image/svg+xml", Size: 1},
+			want: ErrUnsupportedUploadContentType,
+		},
+		{
+			name: "empty",
+			in:   CreateUploadIntent{Purpose: UploadPurposePost, ContentType: "image/png"},
+			want: ErrInvalidUploadSize,
+		},
+		{
+			name: "too_large",
+			in:   CreateUploadIntent{Purpose: UploadPurposeAvatar, ContentType: "image/jpeg", Size: MaxAvatarBytes + 1},
+			want: ErrInvalidUploadSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateUploadIntent(ctx, tt.in)
+			testutil.WantEq(t, tt.want, err, "error")
+		})
+	}
+}
+
+func TestService_PutUpload(t *testing.T) {
+	origin, err := url.Parse("https://example.org")
+	testutil.WantEq(t, nil, err, "url parse")
+
+	svc := &Service{Logger: log.NewNopLogger(), Origin: origin, TokenKey: "test"}
+	ctx := context.Background()
+	uploadID := "00000001-0000-0000-0000-000000000000"
+
+	t.Run("local_url", func(t *testing.T) {
+		u, err := url.Parse(svc.localUploadURL(uploadID, time.Now()))
+		testutil.WantEq(t, nil, err, "url parse")
+		testutil.WantEq(t, "/api/uploads/"+uploadID, u.Path, "path")
+
+		q := u.Query()
+		testutil.WantEq(t, svc.uploadSignature(uploadID, q.Get("expires")), q.Get("signature"), "signature")
+	})
+
+	t.Run("invalid_signature", func(t *testing.T) {
+		expires := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+		err := svc.PutUpload(ctx, uploadID, expires, "nope", strings.NewReader("x"), 1)
+		testutil.WantEq(t, ErrInvalidUploadSignature, err, "error")
+	})
+
+	t.Run("tampered_expiry", func(t *testing.T) {
+		expires := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+		signature := svc.uploadSignature(uploadID, expires)
+		later := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+		err := svc.PutUpload(ctx, uploadID, later, signature, strings.NewReader("x"), 1)
+		testutil.WantEq(t, ErrInvalidUploadSignature, err, "error")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expires := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+		err := svc.PutUpload(ctx, uploadID, expires, svc.uploadSignature(uploadID, expires), strings.NewReader("x"), 1)
+		testutil.WantEq(t, ErrUploadExpired, err, "error")
+	})
+}
@@ -4,10 +4,8 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
 	"regexp"
 	"strings"
@@ -16,8 +14,6 @@ import (
 	"github.com/cockroachdb/cockroach-go/v2/crdb"
 	"github.com/disintegration/imaging"
 	gonanoid "github.com/matoous/go-nanoid/v2"
-
-	"github.com/nakamauwu/nakama/storage"
 )
 
 const (
@@ -25,6 +21,8 @@ const (
 	MaxAvatarBytes = 5 << 20 // 5MB
 	// MaxCoverBytes to read.
 	MaxCoverBytes = 20 << 20 // 20MB
+	// avatarSize is the width and height avatars get cropped to.
+	avatarSize = 400
 
 	AvatarsBucket = "avatars"
 	CoversBucket  = "covers"
@@ -70,14 +68,16 @@ var (
 type User struct {
 	ID        string  `json:"id,omitempty"`
 	Username  string  `json:"username"`
-	AvatarURL *string `json:"avatarURL"`
+	AvatarURL *string `json:"avatarURL"` // Deprecated: use Avatar.
+	Avatar    *Image  `json:"avatar"`
 }
 
 // UserProfile model.
 type UserProfile struct {
 	User
 	Email          string  `json:"email,omitempty"`
-	CoverURL       *string `json:"coverURL"`
+	CoverURL       *string `json:"coverURL"` // Deprecated: use Cover.
+	Cover          *Image  `json:"cover"`
 	Bio            *string `json:"bio"`
 	Waifu          *string `json:"waifu"`
 	Husbando       *string `json:"husbando"`
@@ -86,6 +86,9 @@ type UserProfile struct {
 	Me             bool    `json:"me"`
 	Following      bool    `json:"following"`
 	Followeed      bool    `json:"followeed"`
+	Blocked        bool    `json:"blocked"`
+	// StorageUsage is only set for the authenticated user.
+	StorageUsage *StorageUsage `json:"storageUsage,omitempty"`
 }
 
 // ToggleFollowOutput response.
@@ -121,7 +124,7 @@ func (s *Service) Users(ctx context.Context, search string, first uint64, after
 
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	query, args, err := buildQuery(`
-		SELECT id, email, username, avatar, cover, bio, waifu, husbando, followers_count, followees_count
+		SELECT id, email, username, avatar, avatar_blurhash, cover, cover_blurhash, cover_width, cover_height, bio, waifu, husbando, followers_count, followees_count
 		{{ if .auth }}
 		, followers.follower_id IS NOT NULL AS following
 		, followees.followee_id IS NOT NULL AS followeed
@@ -159,12 +162,17 @@ func (s *Service) Users(ctx context.Context, search string, first uint64, after
 	var uu UserProfiles
 	for rows.Next() {
 		var u UserProfile
-		var avatar, cover sql.NullString
+		var avatar, avatarBlurHash, cover sql.NullString
+		var coverMeta imageMeta
 		dest := []interface{}{
 			&u.ID, &u.Email,
 			&u.Username,
 			&avatar,
+			&avatarBlurHash,
 			&cover,
+			&coverMeta.BlurHash,
+			&coverMeta.Width,
+			&coverMeta.Height,
 			&u.Bio,
 			&u.Waifu,
 			&u.Husbando,
@@ -184,7 +192,9 @@ func (s *Service) Users(ctx context.Context, search string, first uint64, after
 			u.Email = ""
 		}
 		u.AvatarURL = s.avatarURL(avatar)
+		u.Avatar = s.avatarImage(avatar, avatarBlurHash)
 		u.CoverURL = s.coverURL(cover)
+		u.Cover = s.coverImage(cover, coverMeta)
 		uu = append(uu, u)
 	}
 
@@ -267,9 +277,9 @@ func (s *Service) Usernames(ctx context.Context, startingWith string, first uint
 
 func (s *Service) userByID(ctx context.Context, id string) (User, error) {
 	var u User
-	var avatar sql.NullString
-	query := "SELECT username, avatar FROM users WHERE id = $1"
-	err := s.DB.QueryRowContext(ctx, query, id).Scan(&u.Username, &avatar)
+	var avatar, avatarBlurHash sql.NullString
+	query := "SELECT username, avatar, avatar_blurhash FROM users WHERE id = $1"
+	err := s.DB.QueryRowContext(ctx, query, id).Scan(&u.Username, &avatar, &avatarBlurHash)
 	if err == sql.ErrNoRows {
 		return u, ErrUserNotFound
 	}
@@ -280,6 +290,7 @@ func (s *Service) userByID(ctx context.Context, id string) (User, error) {
 
 	u.ID = id
 	u.AvatarURL = s.avatarURL(avatar)
+	u.Avatar = s.avatarImage(avatar, avatarBlurHash)
 
 	return u, nil
 }
@@ -295,10 +306,11 @@ func (s *Service) User(ctx context.Context, username string) (UserProfile, error
 
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	query, args, err := buildQuery(`
-		SELECT id, email, avatar, cover, bio, waifu, husbando, followers_count, followees_count
+		SELECT id, email, avatar, avatar_blurhash, cover, cover_blurhash, cover_width, cover_height, bio, waifu, husbando, followers_count, followees_count, storage_bytes
 		{{if .auth}}
 		, followers.follower_id IS NOT NULL AS following
 		, followees.followee_id IS NOT NULL AS followeed
+		, user_blocks.blocked_id IS NOT NULL AS blocked
 		{{end}}
 		FROM users
 		{{if .auth}}
@@ -306,6 +318,8 @@ func (s *Service) User(ctx context.Context, username string) (UserProfile, error
 			ON followers.follower_id = @uid AND followers.followee_id = users.id
 		LEFT JOIN follows AS followees
 			ON followees.follower_id = users.id AND followees.followee_id = @uid
+		LEFT JOIN user_blocks
+			ON user_blocks.blocker_id = @uid AND user_blocks.blocked_id = users.id
 		{{end}}
 		WHERE username = @username`, map[string]interface{}{
 		"auth":     auth,
@@ -316,10 +330,12 @@ func (s *Service) User(ctx context.Context, username string) (UserProfile, error
 		return u, fmt.Errorf("could not build user sql query: %w", err)
 	}
 
-	var avatar, cover sql.NullString
-	dest := []interface{}{&u.ID, &u.Email, &avatar, &cover, &u.Bio, &u.Waifu, &u.Husbando, &u.FollowersCount, &u.FolloweesCount}
+	var avatar, avatarBlurHash, cover sql.NullString
+	var coverMeta imageMeta
+	var storageBytes int64
+	dest := []interface{}{&u.ID, &u.Email, &avatar, &avatarBlurHash, &cover, &coverMeta.BlurHash, &coverMeta.Width, &coverMeta.Height, &u.Bio, &u.Waifu, &u.Husbando, &u.FollowersCount, &u.FolloweesCount, &storageBytes}
 	if auth {
-		dest = append(dest, &u.Following, &u.Followeed)
+		dest = append(dest, &u.Following, &u.Followeed, &u.Blocked)
 	}
 	err = s.DB.QueryRowContext(ctx, query, args...).Scan(dest...)
 	if err == sql.ErrNoRows {
@@ -332,12 +348,21 @@ func (s *Service) User(ctx context.Context, username string) (UserProfile, error
 
 	u.Username = username
 	u.Me = auth && uid == u.ID
-	if !u.Me {
+	if u.Me {
+		usage, err := s.storageUsage(ctx, uid, storageBytes)
+		if err != nil {
+			return u, err
+		}
+
+		u.StorageUsage = &usage
+	} else {
 		u.ID = ""
 		u.Email = ""
 	}
 	u.AvatarURL = s.avatarURL(avatar)
+	u.Avatar = s.avatarImage(avatar, avatarBlurHash)
 	u.CoverURL = s.coverURL(cover)
+	u.Cover = s.coverImage(cover, coverMeta)
 	return u, nil
 }
 
@@ -414,12 +439,12 @@ func (s *Service) UpdateAvatar(ctx context.Context, r io.ReadSeeker) (string, er
 		return "", fmt.Errorf("update avatar: detect content type: %w", err)
 	}
 
-	if ct != "image/png" && ct != "image/jpeg" {
+	if !supportedImageType(ct) {
 		return "", ErrUnsupportedAvatarFormat
 	}
 
-	img, err := imaging.Decode(io.LimitReader(r, MaxAvatarBytes), imaging.AutoOrientation(true))
-	if err == image.ErrFormat {
+	img, err := decodeImage(r, ct, MaxAvatarBytes, false)
+	if errors.Is(err, ErrUnsupportedMediaItemFormat) {
 		return "", ErrUnsupportedAvatarFormat
 	}
 
@@ -428,13 +453,8 @@ func (s *Service) UpdateAvatar(ctx context.Context, r io.ReadSeeker) (string, er
 	}
 
 	buf := &bytes.Buffer{}
-	img = imaging.Fill(img, 400, 400, imaging.Center, imaging.CatmullRom)
-	if ct == "image/png" {
-		err = png.Encode(buf, img)
-	} else {
-		err = jpeg.Encode(buf, img, nil)
-	}
-	if err != nil {
+	img.Image = imaging.Fill(img.Image, avatarSize, avatarSize, imaging.Center, imaging.CatmullRom)
+	if err := encodeImage(buf, img.Image, nil, img.ContentType); err != nil {
 		return "", fmt.Errorf("could not resize avatar: %w", err)
 	}
 
@@ -443,38 +463,63 @@ func (s *Service) UpdateAvatar(ctx context.Context, r io.ReadSeeker) (string, er
 		return "", fmt.Errorf("could not generate avatar filename: %w", err)
 	}
 
-	if ct == "image/png" {
-		avatarFileName += ".png"
-	} else {
-		avatarFileName += ".jpg"
+	avatarFileName += imageExt(img.ContentType)
+
+	variants, err := imageVariantFiles(img, avatarFileName, avatarVariants)
+	if err != nil {
+		return "", err
 	}
 
-	err = s.Store.Store(ctx, AvatarsBucket, avatarFileName, buf.Bytes(), storage.StoreWithContentType(ct))
+	if err := s.checkUploadLimits(ctx, s.DB, uid, int64(buf.Len())); err != nil {
+		return "", err
+	}
+
+	files := []mediaFile{{
+		Name:        avatarFileName,
+		ContentType: img.ContentType,
+		Content:     buf.Bytes(),
+		Variants:    variants,
+	}}
+	err = s.storeImageFiles(ctx, AvatarsBucket, files)
 	if err != nil {
 		return "", fmt.Errorf("could not store avatar file: %w", err)
 	}
 
 	var oldAvatar sql.NullString
-	query := `
-		UPDATE users SET avatar = $1 WHERE id = $2
-		RETURNING (SELECT avatar FROM users WHERE id = $2) AS old_avatar
-	`
-	row := s.DB.QueryRowContext(ctx, query, avatarFileName, uid)
-	err = row.Scan(&oldAvatar)
+	size := imageFilesSize(files)
+	err = crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
+		var oldSize sql.NullInt64
+		query := `
+			UPDATE users SET avatar = $1, avatar_blurhash = $2, avatar_bytes = $3 WHERE id = $4
+			RETURNING
+				(SELECT avatar FROM users WHERE id = $4) AS old_avatar
+				, (SELECT avatar_bytes FROM users WHERE id = $4) AS old_avatar_bytes
+		`
+		row := tx.QueryRowContext(ctx, query, avatarFileName, blurHash(img.Image), size, uid)
+		if err := row.Scan(&oldAvatar, &oldSize); err != nil {
+			return fmt.Errorf("could not update avatar: %w", err)
+		}
+
+		if err := s.recordUploads(ctx, tx, uid, int64(buf.Len())); err != nil {
+			return err
+		}
+
+		return s.chargeStorage(ctx, tx, uid, size-oldSize.Int64)
+	})
 	if err != nil {
 		defer func() {
-			err := s.Store.Delete(context.Background(), AvatarsBucket, avatarFileName)
+			err := s.deleteImageFiles(context.Background(), AvatarsBucket, []string{avatarFileName}, avatarVariants)
 			if err != nil {
 				_ = s.Logger.Log("error", fmt.Errorf("could not delete avatar file after user update fail: %w", err))
 			}
 		}()
 
-		return "", fmt.Errorf("could not update avatar: %w", err)
+		return "", err
 	}
 
 	if oldAvatar.Valid {
 		defer func() {
-			err := s.Store.Delete(context.Background(), AvatarsBucket, oldAvatar.String)
+			err := s.deleteImageFiles(context.Background(), AvatarsBucket, []string{oldAvatar.String}, avatarVariants)
 			if err != nil {
 				_ = s.Logger.Log("error", fmt.Errorf("could not delete old avatar: %w", err))
 			}
@@ -497,12 +542,12 @@ func (s *Service) UpdateCover(ctx context.Context, r io.ReadSeeker) (string, err
 		return "", fmt.Errorf("update cover: detect content type: %w", err)
 	}
 
-	if ct != "image/png" && ct != "image/jpeg" {
-		return "", ErrUnsupportedAvatarFormat
+	if !supportedImageType(ct) {
+		return "", ErrUnsupportedCoverFormat
 	}
 
-	img, err := imaging.Decode(io.LimitReader(r, MaxCoverBytes), imaging.AutoOrientation(true))
-	if err == image.ErrFormat {
+	img, err := decodeImage(r, ct, MaxCoverBytes, false)
+	if errors.Is(err, ErrUnsupportedMediaItemFormat) {
 		return "", ErrUnsupportedCoverFormat
 	}
 
@@ -511,13 +556,8 @@ func (s *Service) UpdateCover(ctx context.Context, r io.ReadSeeker) (string, err
 	}
 
 	buf := &bytes.Buffer{}
-	img = imaging.CropCenter(img, 2560, 423)
-	if ct == "image/png" {
-		err = png.Encode(buf, img)
-	} else {
-		err = jpeg.Encode(buf, img, nil)
-	}
-	if err != nil {
+	img.Image = imaging.CropCenter(img.Image, 2560, 423)
+	if err := encodeImage(buf, img.Image, nil, img.ContentType); err != nil {
 		return "", fmt.Errorf("could not resize cover: %w", err)
 	}
 
@@ -526,38 +566,64 @@ func (s *Service) UpdateCover(ctx context.Context, r io.ReadSeeker) (string, err
 		return "", fmt.Errorf("could not generate cover filename: %w", err)
 	}
 
-	if ct == "image/png" {
-		coverFileName += ".png"
-	} else {
-		coverFileName += ".jpg"
+	coverFileName += imageExt(img.ContentType)
+
+	variants, err := imageVariantFiles(img, coverFileName, coverVariants)
+	if err != nil {
+		return "", err
 	}
 
-	err = s.Store.Store(ctx, CoversBucket, coverFileName, buf.Bytes(), storage.StoreWithContentType(ct))
+	if err := s.checkUploadLimits(ctx, s.DB, uid, int64(buf.Len())); err != nil {
+		return "", err
+	}
+
+	files := []mediaFile{{
+		Name:        coverFileName,
+		ContentType: img.ContentType,
+		Content:     buf.Bytes(),
+		Variants:    variants,
+	}}
+	err = s.storeImageFiles(ctx, CoversBucket, files)
 	if err != nil {
 		return "", fmt.Errorf("could not store cover file: %w", err)
 	}
 
 	var oldCover sql.NullString
-	query := `
-		UPDATE users SET cover = $1 WHERE id = $2
-		RETURNING (SELECT cover FROM users WHERE id = $2) AS old_cover
-	`
-	row := s.DB.QueryRowContext(ctx, query, coverFileName, uid)
-	err = row.Scan(&oldCover)
+	size := imageFilesSize(files)
+	bounds := img.Image.Bounds()
+	err = crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
+		var oldSize sql.NullInt64
+		query := `
+			UPDATE users SET cover = $1, cover_blurhash = $2, cover_width = $3, cover_height = $4, cover_bytes = $5 WHERE id = $6
+			RETURNING
+				(SELECT cover FROM users WHERE id = $6) AS old_cover
+				, (SELECT cover_bytes FROM users WHERE id = $6) AS old_cover_bytes
+		`
+		row := tx.QueryRowContext(ctx, query, coverFileName, blurHash(img.Image), bounds.Dx(), bounds.Dy(), size, uid)
+		if err := row.Scan(&oldCover, &oldSize); err != nil {
+			return fmt.Errorf("could not update cover: %w", err)
+		}
+
+		if err := s.recordUploads(ctx, tx, uid, int64(buf.Len())); err != nil {
+			return err
+		}
+
+		return s.chargeStorage(ctx, tx, uid, size-oldSize.Int64)
+	})
 	if err != nil {
 		defer func() {
-			err := s.Store.Delete(context.Background(), CoversBucket, coverFileName)
+			err := s.deleteImageFiles(context.Background(), CoversBucket, []string{coverFileName}, coverVariants)
 			if err != nil {
 				_ = s.Logger.Log("error", fmt.Errorf("could not delete cover file after user update fail: %w", err))
 			}
 		}()
 
-		return "", fmt.Errorf("could not update cover: %w", err)
+		return "", err
 	}
 
 	if oldCover.Valid {
 		defer func() {
-			err := s.Store.Delete(context.Background(), CoversBucket, oldCover.String)
+			err := s.deleteImageFiles(context.Background(), CoversBucket, []string{oldCover.String}, coverVariants)
 			if err != nil {
 				_ = s.Logger.Log("error", fmt.Errorf("could not delete old cover: %w", err))
 			}
@@ -657,6 +723,7 @@ func (s *Service) ToggleFollow(ctx context.Context, username string) (ToggleFoll
 
 	if out.Following {
 		go s.notifyFollow(followerID, followeeID)
+		go s.followWebhook(followerID, followeeID)
 	}
 
 	return out, nil
@@ -685,7 +752,11 @@ func (s *Service) Followers(ctx context.Context, username string, first uint64,
 		, users.email
 		, users.username
 		, users.avatar
+		, users.avatar_blurhash
 		, users.cover
+		, users.cover_blurhash
+		, users.cover_width
+		, users.cover_height
 		, users.followers_count
 		, users.followees_count
 		{{ if .auth }}
@@ -724,13 +795,18 @@ func (s *Service) Followers(ctx context.Context, username string, first uint64,
 	var uu UserProfiles
 	for rows.Next() {
 		var u UserProfile
-		var avatar, cover sql.NullString
+		var avatar, avatarBlurHash, cover sql.NullString
+		var coverMeta imageMeta
 		dest := []interface{}{
 			&u.ID,
 			&u.Email,
 			&u.Username,
 			&avatar,
+			&avatarBlurHash,
 			&cover,
+			&coverMeta.BlurHash,
+			&coverMeta.Width,
+			&coverMeta.Height,
 			&u.FollowersCount,
 			&u.FolloweesCount,
 		}
@@ -747,7 +823,9 @@ func (s *Service) Followers(ctx context.Context, username string, first uint64,
 			u.Email = ""
 		}
 		u.AvatarURL = s.avatarURL(avatar)
+		u.Avatar = s.avatarImage(avatar, avatarBlurHash)
 		u.CoverURL = s.coverURL(cover)
+		u.Cover = s.coverImage(cover, coverMeta)
 		uu = append(uu, u)
 	}
 
@@ -781,7 +859,11 @@ func (s *Service) Followees(ctx context.Context, username string, first uint64,
 		, users.email
 		, users.username
 		, users.avatar
+		, users.avatar_blurhash
 		, users.cover
+		, users.cover_blurhash
+		, users.cover_width
+		, users.cover_height
 		, users.followers_count
 		, users.followees_count
 		{{ if .auth }}
@@ -820,13 +902,18 @@ func (s *Service) Followees(ctx context.Context, username string, first uint64,
 	var uu UserProfiles
 	for rows.Next() {
 		var u UserProfile
-		var avatar, cover sql.NullString
+		var avatar, avatarBlurHash, cover sql.NullString
+		var coverMeta imageMeta
 		dest := []interface{}{
 			&u.ID,
 			&u.Email,
 			&u.Username,
 			&avatar,
+			&avatarBlurHash,
 			&cover,
+			&coverMeta.BlurHash,
+			&coverMeta.Width,
+			&coverMeta.Height,
 			&u.FollowersCount,
 			&u.FolloweesCount,
 		}
@@ -843,7 +930,9 @@ func (s *Service) Followees(ctx context.Context, username string, first uint64,
 			u.Email = ""
 		}
 		u.AvatarURL = s.avatarURL(avatar)
+		u.Avatar = s.avatarImage(avatar, avatarBlurHash)
 		u.CoverURL = s.coverURL(cover)
+		u.Cover = s.coverImage(cover, coverMeta)
 		uu = append(uu, u)
 	}
 
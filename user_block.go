@@ -0,0 +1,83 @@
+package nakama
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach-go/v2/crdb"
+)
+
+// ErrForbiddenBlock denotes a forbidden block. Like blocking yourself.
+var ErrForbiddenBlock = PermissionDeniedError("forbidden block")
+
+// ToggleBlockOutput response.
+type ToggleBlockOutput struct {
+	Blocked bool `json:"blocked"`
+}
+
+// ToggleBlock of the given user by the authenticated user.
+// Blocked users cannot send direct messages to the blocker, and vice versa.
+func (s *Service) ToggleBlock(ctx context.Context, username string) (ToggleBlockOutput, error) {
+	var out ToggleBlockOutput
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	username = strings.TrimSpace(username)
+	if !ValidUsername(username) {
+		return out, ErrInvalidUsername
+	}
+
+	err := crdb.ExecuteTx(ctx, s.DB, nil, func(tx *sql.Tx) error {
+		var blockedID string
+		query := "SELECT id FROM users WHERE username = $1"
+		err := tx.QueryRowContext(ctx, query, username).Scan(&blockedID)
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select user id from username: %w", err)
+		}
+
+		if blockedID == uid {
+			return ErrForbiddenBlock
+		}
+
+		query = "DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2"
+		result, err := tx.ExecContext(ctx, query, uid, blockedID)
+		if err != nil {
+			return fmt.Errorf("could not delete user block: %w", err)
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("could not get deleted user blocks count: %w", err)
+		}
+
+		if n != 0 {
+			out.Blocked = false
+			return nil
+		}
+
+		query = "INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2)"
+		if _, err := tx.ExecContext(ctx, query, uid, blockedID); err != nil {
+			return fmt.Errorf("could not insert user block: %w", err)
+		}
+
+		out.Blocked = true
+		return nil
+	})
+	if err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
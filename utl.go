@@ -200,6 +200,10 @@ func detectContentType(r io.ReadSeeker) (string, error) {
 	return mt, nil
 }
 
+func ptrInt(v int) *int {
+	return &v
+}
+
 func ptrString(v string) *string {
 	return &v
 }
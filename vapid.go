@@ -0,0 +1,128 @@
+package nakama
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidVAPIDPublicKey denotes a VAPID public key that is not
+	// an URL-safe base64 encoded uncompressed P-256 point.
+	ErrInvalidVAPIDPublicKey = InvalidArgumentError("invalid VAPID public key")
+	// ErrInvalidVAPIDPrivateKey denotes a VAPID private key that is not
+	// an URL-safe base64 encoded P-256 scalar.
+	ErrInvalidVAPIDPrivateKey = InvalidArgumentError("invalid VAPID private key")
+	// ErrVAPIDKeysMismatch denotes a VAPID private key that does not belong to the public key.
+	ErrVAPIDKeysMismatch = InvalidArgumentError("VAPID private key does not match public key")
+)
+
+// ValidateVAPIDKeys checks that the given keys are a valid P-256 key pair
+// as generated by webpush.GenerateVAPIDKeys.
+func ValidateVAPIDKeys(publicKey, privateKey string) error {
+	pub, err := decodeVAPIDKey(publicKey)
+	if err != nil {
+		return ErrInvalidVAPIDPublicKey
+	}
+
+	if _, err := ecdh.P256().NewPublicKey(pub); err != nil {
+		return ErrInvalidVAPIDPublicKey
+	}
+
+	b, err := decodeVAPIDKey(privateKey)
+	if err != nil {
+		return ErrInvalidVAPIDPrivateKey
+	}
+
+	priv, err := ecdh.P256().NewPrivateKey(b)
+	if err != nil {
+		return ErrInvalidVAPIDPrivateKey
+	}
+
+	if !bytes.Equal(priv.PublicKey().Bytes(), pub) {
+		return ErrVAPIDKeysMismatch
+	}
+
+	return nil
+}
+
+// decodeVAPIDKey accepts both padded and unpadded URL-safe base64
+// the same way webpush-go does.
+func decodeVAPIDKey(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+
+	return base64.URLEncoding.DecodeString(s)
+}
+
+// TestWebPushResult reports the outcome of SendTestWebPush.
+type TestWebPushResult struct {
+	Sent   int
+	Pruned int
+	Failed int
+}
+
+// SendTestWebPush sends a test notification synchronously to every
+// web push subscription of the given user.
+// Subscriptions the push service reports as gone are pruned.
+func (svc *Service) SendTestWebPush(ctx context.Context, userID string) (TestWebPushResult, error) {
+	var out TestWebPushResult
+
+	if svc.VAPIDPrivateKey == "" || svc.VAPIDPublicKey == "" {
+		return out, ErrWebPushUnavailable
+	}
+
+	if !reUUID.MatchString(userID) {
+		return out, ErrInvalidUserID
+	}
+
+	subs, err := svc.webPushSubscriptions(ctx, userID)
+	if err != nil {
+		return out, err
+	}
+
+	message, err := json.Marshal(Notification{
+		ID:       "test",
+		UserID:   userID,
+		Actors:   []string{},
+		Type:     "test",
+		IssuedAt: time.Now(),
+	})
+	if err != nil {
+		return out, fmt.Errorf("could not json marshal test web push notification message: %w", err)
+	}
+
+	var errs []error
+	for _, sub := range subs {
+		err := svc.sendWebPushNotification(ctx, webPushDelivery{
+			UserID:         userID,
+			SubscriptionID: sub.ID,
+			Sub:            sub.Sub,
+			Message:        message,
+			Urgency:        webPushUrgency("test"),
+		})
+		if errors.Is(err, errWebPushSubscriptionGone) {
+			err = svc.pruneWebPushSubscription(ctx, sub.ID)
+			if err == nil {
+				out.Pruned++
+				continue
+			}
+		}
+
+		if err != nil {
+			out.Failed++
+			errs = append(errs, fmt.Errorf("subscription %s: %w", sub.ID, err))
+			continue
+		}
+
+		out.Sent++
+	}
+
+	return out, errors.Join(errs...)
+}
@@ -0,0 +1,26 @@
+package nakama
+
+import (
+	"testing"
+
+	"github.com/SherClockHolmes/webpush-go"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestValidateVAPIDKeys(t *testing.T) {
+	privateKey, publicKey, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherPrivateKey, _, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.WantEq(t, nil, ValidateVAPIDKeys(publicKey, privateKey), "valid")
+	testutil.WantEq(t, ErrInvalidVAPIDPublicKey, ValidateVAPIDKeys("nope", privateKey), "invalid public key")
+	testutil.WantEq(t, ErrInvalidVAPIDPrivateKey, ValidateVAPIDKeys(publicKey, "nope"), "invalid private key")
+	testutil.WantEq(t, ErrVAPIDKeysMismatch, ValidateVAPIDKeys(publicKey, otherPrivateKey), "mismatch")
+}
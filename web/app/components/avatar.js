@@ -2,8 +2,13 @@ import { html } from "lit"
 
 export function Avatar(user) {
     return user.avatarURL !== null ? html`
-        <img class="avatar" src="${user.avatarURL}" alt="">
+        <img class="avatar" src="${avatarSrc(user)}" alt="">
     ` : html`
         <span class="avatar" data-initial="${user.username[0]}"></span>
     `
 }
+
+function avatarSrc(user) {
+    const thumb = user.avatar?.srcset.find(src => src.variant === "thumb")
+    return thumb !== undefined ? thumb.url : user.avatarURL
+}
@@ -155,10 +155,10 @@ function NotificationsPage() {
                     return sub
                 }
 
-                return reg.pushManager.subscribe({
-                    applicationServerKey: import.meta.env.VITE_VAPID_PUBLIC_KEY,
+                return fetchWebPushPublicKey().then(applicationServerKey => reg.pushManager.subscribe({
+                    applicationServerKey,
                     userVisibleOnly: true,
-                })
+                }))
             }).then(addWebPushSubscription).catch(err => {
                 addWebPushSubscriptionErrorHandler(err)
                 setNotificationsEnabled(false)
@@ -397,6 +397,14 @@ function fetchHasUnreadNotifications() {
         .then(v => Boolean(v))
 }
 
+/**
+ * @returns {Promise<string>}
+ */
+function fetchWebPushPublicKey() {
+    return request("GET", "/api/web_push_public_key")
+        .then(resp => resp.body)
+}
+
 /**
  * @param {PushSubscription} sub
  */
@@ -158,6 +158,11 @@ function PostItem({ post: initialPost, type }) {
         setMediaURLs(urls)
     }, [post["mediaURLs"], post.content])
 
+    const mediaItems = new Map()
+    for (const item of post.media ?? []) {
+        mediaItems.set(new URL(item.url, location.origin).toString(), item)
+    }
+
     useEffect(() => {
         setPost(initialPost)
     }, [initialPost])
@@ -256,7 +261,7 @@ function PostItem({ post: initialPost, type }) {
                             <button @click=${onDisplayNSFWBtnClick}>${translate("postItem.nsfw.show")}</button>
                         </div>
                     ` : html`
-                        <media-scroller .urls=${mediaURLs}></media-scroller>
+                        <media-scroller .urls=${mediaURLs} .mediaItems=${mediaItems}></media-scroller>
                     `}
                 `}
             </div>
@@ -440,10 +445,10 @@ const videoExts = ["mp4", "webm", "mov", "3gp", "ogg"].map(ext => "." + ext)
 
 /**
  *
- * @param {{urls:URL[]}} props
+ * @param {{urls:URL[], mediaItems?:Map<string, any>}} props
  * @returns
  */
-function MediaScroller({ urls }) {
+function MediaScroller({ urls, mediaItems = new Map() }) {
     const [items, setItems] = useState([])
 
     useEffect(() => {
@@ -516,7 +521,13 @@ function MediaScroller({ urls }) {
                 {
                     if (trustedOrigins.includes(url.origin) || (trustedOrigins.some(o => o.includes("localhost") && url.hostname === "localhost"))) {
                         if (imageExts.some(ext => url.pathname.endsWith(ext))) {
-                            items.push(html`<zoomable-img .src=${url.toString()}></zoomable-img>`)
+                            const mediaItem = mediaItems.get(url.toString())
+                            items.push(html`<zoomable-img
+                                .src=${url.toString()}
+                                .alt=${mediaItem?.altText ?? ""}
+                                .width=${mediaItem?.width ?? undefined}
+                                .height=${mediaItem?.height ?? undefined}
+                                style=${mediaItem?.blurHash ? "background-color: " + blurHashColor(mediaItem.blurHash) : ""}></zoomable-img>`)
                             continue
                         }
 
@@ -760,7 +771,7 @@ function findTikTokVideoID(url) {
 
 const zoom = mediumZoom()
 
-function ZoomableImg({ src, width = undefined, height = undefined }) {
+function ZoomableImg({ src, alt = "", width = undefined, height = undefined }) {
     const imgRef = /** @type {import("lit/directives/ref.js").Ref<HTMLImageElement>} */ (createRef())
 
     useEffect(() => {
@@ -775,7 +786,22 @@ function ZoomableImg({ src, width = undefined, height = undefined }) {
         }
     }, [imgRef.value])
 
-    return html`<img src="${src}" width="${width}" height="${height}" alt="" loading="lazy" ${ref(imgRef)}>`
+    return html`<img src="${src}" width="${width}" height="${height}" alt="${alt}" loading="lazy" ${ref(imgRef)}>`
+}
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+/**
+ * blurHashColor decodes the average color of a BlurHash
+ * to use as placeholder while the image loads.
+ * @param {string} hash
+ */
+function blurHashColor(hash) {
+    let value = 0
+    for (const c of hash.substring(2, 6)) {
+        value = value * 83 + base83Chars.indexOf(c)
+    }
+    return "#" + value.toString(16).padStart(6, "0")
 }
 
 // @ts-ignore
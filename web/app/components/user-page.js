@@ -600,7 +600,7 @@ function UserProfile({ user: initialUser }) {
                         <div @dblclick=${onAvatarDblClick}>
                             ${Avatar(user)}
                         </div>
-                        <input type="file" name="avatar" accept="image/png,image/jpeg" required hidden
+                        <input type="file" name="avatar" accept="image/png,image/jpeg,image/gif,image/webp" required hidden
                             .disabled=${updatingAvatar} ${ref(avatarInputRef)} @change=${onAvatarInputChange}>
                         <button .disabled=${updatingAvatar} @click=${onAvatarBtnClick}>Update</button>
                     </div>
@@ -611,7 +611,7 @@ function UserProfile({ user: initialUser }) {
                         ${user.coverURL !== null ? html`
                             <img src="${user.coverURL}" @dblclick=${onCoverDblClick}>
                         ` : null}
-                        <input type="file" name="cover" accept="image/png,image/jpeg" required hidden
+                        <input type="file" name="cover" accept="image/png,image/jpeg,image/gif,image/webp" required hidden
                             .disabled=${updatingCover} ${ref(coverInputRef)} @change=${onCoverInputChange}>
                         <button .disabled=${updatingCover} @click=${onCoverBtnClick}>Update</button>
                     </div>
@@ -1,7 +1,6 @@
 /// <reference types="vite/client" />
 
 interface ImportMetaEnv {
-    readonly VITE_VAPID_PUBLIC_KEY: string
 }
 
 interface ImportMeta {
@@ -103,6 +103,10 @@ async function openNotificationsPage(n) {
 }
 
 function notificationPathname(n) {
+    if (n.type === "message" && typeof n.conversationID === "string") {
+        return "/conversations/" + encodeURIComponent(n.conversationID)
+    }
+
     if (typeof n.postID === "string" && n.postID !== "") {
         return "/posts/" + encodeURIComponent(n.postID)
     }
@@ -140,11 +144,19 @@ function notificationTitle(n) {
             return "New post mention"
         case "comment_mention":
             return "New comment mention"
+        case "message":
+            return "New message"
+        case "test":
+            return "Test notification"
     }
     return "New notification"
 }
 
 function notificationBody(n) {
+    if (n.type === "test") {
+        return "Web push notifications are working"
+    }
+
     const getActors = () => {
         const aa = n.actors
         switch (aa.length) {
@@ -169,6 +181,8 @@ function notificationBody(n) {
                 return "mentioned you in a post"
             case "comment_mention":
                 return "mentioned you in a comment"
+            case "message":
+                return "sent you a message"
         }
         return "did something"
     }
@@ -0,0 +1,307 @@
+package nakama
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SherClockHolmes/webpush-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	webPushNoticationSendTimeout = time.Second * 30
+	webPushNoticationContact     = "contact@nakama.social"
+
+	webPushQueueSize   = 1024
+	webPushWorkers     = 8
+	webPushMaxAttempts = 5
+	webPushBaseBackoff = time.Second * 2
+	webPushMaxBackoff  = time.Minute * 5
+	// webPushMaxFailures is the number of consecutive failed deliveries
+	// after which a subscription is considered dead and gets pruned.
+	webPushMaxFailures = 10
+)
+
+var errWebPushSubscriptionGone = GoneError("web push subscription gone")
+
+var (
+	webPushSentTotal    = promauto.NewCounter(prometheus.CounterOpts{Name: "web_push_notifications_sent_total"})
+	webPushFailedTotal  = promauto.NewCounter(prometheus.CounterOpts{Name: "web_push_notifications_failed_total"})
+	webPushRetriedTotal = promauto.NewCounter(prometheus.CounterOpts{Name: "web_push_notifications_retried_total"})
+	webPushPrunedTotal  = promauto.NewCounter(prometheus.CounterOpts{Name: "web_push_subscriptions_pruned_total"})
+)
+
+// webPushStatusError is returned when the push service responds with an error status code.
+type webPushStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *webPushStatusError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("web push notification send failed with status code %d: %s", e.StatusCode, e.Body)
+	}
+
+	return fmt.Sprintf("web push notification send failed with status code %d", e.StatusCode)
+}
+
+// Retryable reports whether the push service asked us to try again later.
+func (e *webPushStatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Unauthorized reports whether the push service rejected our VAPID credentials.
+// That is a server misconfiguration, not a dead subscription.
+func (e *webPushStatusError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+type webPushDelivery struct {
+	UserID         string
+	SubscriptionID string
+	Sub            webpush.Subscription
+	Message        []byte
+	Topic          string
+	Urgency        webpush.Urgency
+	Attempt        int
+}
+
+func (svc *Service) webPushDeliveries() chan webPushDelivery {
+	svc.webPushQueueOncer.Do(func() {
+		svc.webPushQueue = make(chan webPushDelivery, webPushQueueSize)
+	})
+	return svc.webPushQueue
+}
+
+// webPushDeliveryJob runs the workers that drain the web push delivery queue.
+func (svc *Service) webPushDeliveryJob(ctx context.Context) {
+	queue := svc.webPushDeliveries()
+
+	var wg sync.WaitGroup
+	for i := 0; i < webPushWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case d := <-queue:
+					svc.deliverWebPush(ctx, d)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (svc *Service) enqueueWebPushDelivery(d webPushDelivery) {
+	select {
+	case svc.webPushDeliveries() <- d:
+	default:
+		webPushFailedTotal.Inc()
+		_ = svc.Logger.Log("error", "web push delivery queue full; dropping delivery", "subscription_id", d.SubscriptionID)
+	}
+}
+
+func (svc *Service) sendWebPushNotifications(n Notification) {
+	message, err := json.Marshal(n)
+	if err != nil {
+		_ = svc.Logger.Log("err", fmt.Errorf("could not json marshal web push notification message: %w", err))
+		return
+	}
+
+	var topic string
+	if n.PostID != nil {
+		topic = webPushTopic(*n.PostID)
+	}
+
+	svc.sendWebPush(n.UserID, message, topic, webPushUrgency(n.Type))
+}
+
+// sendWebPush enqueues a delivery of message to every web push subscription of the given user.
+func (svc *Service) sendWebPush(userID string, message []byte, topic string, urgency webpush.Urgency) {
+	if svc.VAPIDPrivateKey == "" || svc.VAPIDPublicKey == "" {
+		return
+	}
+
+	ctx := context.Background()
+	subs, err := svc.webPushSubscriptions(ctx, userID)
+	if err != nil {
+		_ = svc.Logger.Log("err", err)
+		return
+	}
+
+	for _, sub := range subs {
+		svc.enqueueWebPushDelivery(webPushDelivery{
+			UserID:         userID,
+			SubscriptionID: sub.ID,
+			Sub:            sub.Sub,
+			Message:        message,
+			Topic:          topic,
+			Urgency:        urgency,
+		})
+	}
+}
+
+// webPushTopic from an UUID.
+// Topic can have only 32 characters.
+// By removing the dashes from the UUID we can go from 36 to 32 characters.
+func webPushTopic(id string) string {
+	return strings.ReplaceAll(id, "-", "")
+}
+
+func (svc *Service) deliverWebPush(ctx context.Context, d webPushDelivery) {
+	err := svc.sendWebPushNotification(ctx, d)
+	if err == nil {
+		webPushSentTotal.Inc()
+		if err := svc.resetWebPushSubscriptionFailures(ctx, d.SubscriptionID); err != nil {
+			_ = svc.Logger.Log("err", err)
+		}
+		return
+	}
+
+	if errors.Is(err, errWebPushSubscriptionGone) {
+		if err := svc.pruneWebPushSubscription(ctx, d.SubscriptionID); err != nil {
+			_ = svc.Logger.Log("err", err)
+		}
+		return
+	}
+
+	var statusErr *webPushStatusError
+	retryable := !errors.As(err, &statusErr) || statusErr.Retryable()
+	if retryable && d.Attempt+1 < webPushMaxAttempts {
+		delay := webPushRetryDelay(d.Attempt, statusErr)
+		webPushRetriedTotal.Inc()
+		d.Attempt++
+		time.AfterFunc(delay, func() {
+			svc.enqueueWebPushDelivery(d)
+		})
+		return
+	}
+
+	webPushFailedTotal.Inc()
+	_ = svc.Logger.Log("err", err, "subscription_id", d.SubscriptionID, "attempts", d.Attempt+1)
+
+	// do not prune subscriptions because of unset or rotated VAPID keys.
+	if statusErr != nil && statusErr.Unauthorized() {
+		return
+	}
+
+	if err := svc.recordWebPushSubscriptionFailure(ctx, d.SubscriptionID); err != nil {
+		_ = svc.Logger.Log("err", err)
+	}
+}
+
+func (svc *Service) sendWebPushNotification(ctx context.Context, d webPushDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, webPushNoticationSendTimeout)
+	defer cancel()
+
+	resp, err := webpush.SendNotificationWithContext(ctx, d.Message, &d.Sub, &webpush.Options{
+		Subscriber:      webPushNoticationContact,
+		Topic:           d.Topic,
+		Urgency:         d.Urgency,
+		VAPIDPrivateKey: svc.VAPIDPrivateKey,
+		VAPIDPublicKey:  svc.VAPIDPublicKey,
+		TTL:             int(webPushNoticationSendTimeout.Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("could not send web push notification: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		// subscription has been removed.
+		if resp.StatusCode == http.StatusGone || resp.StatusCode == http.StatusNotFound {
+			return errWebPushSubscriptionGone
+		}
+
+		statusErr := &webPushStatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+		if b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<10)); err == nil {
+			statusErr.Body = string(b)
+		}
+
+		return statusErr
+	}
+
+	return nil
+}
+
+func (svc *Service) resetWebPushSubscriptionFailures(ctx context.Context, subscriptionID string) error {
+	query := "UPDATE user_web_push_subscriptions SET failures = 0 WHERE id = $1 AND failures != 0"
+	_, err := svc.DB.ExecContext(ctx, query, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("sql update reset web push subscription failures: %w", err)
+	}
+
+	return nil
+}
+
+// recordWebPushSubscriptionFailure increments the subscription failure counter
+// and prunes it once it reaches webPushMaxFailures.
+func (svc *Service) recordWebPushSubscriptionFailure(ctx context.Context, subscriptionID string) error {
+	var failures int
+	query := "UPDATE user_web_push_subscriptions SET failures = failures + 1 WHERE id = $1 RETURNING failures"
+	err := svc.DB.QueryRowContext(ctx, query, subscriptionID).Scan(&failures)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("sql update increment web push subscription failures: %w", err)
+	}
+
+	if failures < webPushMaxFailures {
+		return nil
+	}
+
+	return svc.pruneWebPushSubscription(ctx, subscriptionID)
+}
+
+func (svc *Service) pruneWebPushSubscription(ctx context.Context, subscriptionID string) error {
+	query := "DELETE FROM user_web_push_subscriptions WHERE id = $1"
+	_, err := svc.DB.ExecContext(ctx, query, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("sql delete user web push subscription: %w", err)
+	}
+
+	webPushPrunedTotal.Inc()
+	return nil
+}
+
+// webPushUrgency so devices on low battery only wake up for direct interactions.
+func webPushUrgency(notificationType string) webpush.Urgency {
+	switch notificationType {
+	case "post_mention", "comment_mention":
+		return webpush.UrgencyHigh
+	case "follow":
+		return webpush.UrgencyLow
+	}
+	return webpush.UrgencyNormal
+}
+
+// webPushRetryDelay is the delay before the given retry attempt
+// honoring the push service Retry-After up to webPushMaxBackoff.
+func webPushRetryDelay(attempt int, statusErr *webPushStatusError) time.Duration {
+	var retryAfter time.Duration
+	if statusErr != nil {
+		retryAfter = statusErr.RetryAfter
+	}
+	return retryDelay(webPushBaseBackoff, webPushMaxBackoff, attempt, retryAfter)
+}
@@ -0,0 +1,32 @@
+package nakama
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SherClockHolmes/webpush-go"
+	"github.com/go-kit/log"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func Test_webPushRetryDelay(t *testing.T) {
+	testutil.WantEq(t, webPushBaseBackoff, webPushRetryDelay(0, nil), "no status")
+	testutil.WantEq(t, time.Minute, webPushRetryDelay(0, &webPushStatusError{RetryAfter: time.Minute}), "retry after")
+	testutil.WantEq(t, webPushBaseBackoff*4, webPushRetryDelay(2, &webPushStatusError{RetryAfter: time.Second}), "backoff over retry after")
+	testutil.WantEq(t, webPushMaxBackoff, webPushRetryDelay(0, &webPushStatusError{RetryAfter: time.Hour * 24}), "capped retry after")
+}
+
+func Test_webPushStatusError_Unauthorized(t *testing.T) {
+	testutil.WantEq(t, true, (&webPushStatusError{StatusCode: http.StatusUnauthorized}).Unauthorized(), "401")
+	testutil.WantEq(t, true, (&webPushStatusError{StatusCode: http.StatusForbidden}).Unauthorized(), "403")
+	testutil.WantEq(t, false, (&webPushStatusError{StatusCode: http.StatusBadRequest}).Unauthorized(), "400")
+}
+
+func TestService_sendWebPush_withoutVAPIDKeys(t *testing.T) {
+	// no DB: it must return before loading the subscriptions.
+	svc := &Service{Logger: log.NewNopLogger()}
+	svc.sendWebPush("user_id", []byte("{}"), "", webpush.UrgencyNormal)
+	testutil.WantEq(t, 0, len(svc.webPushDeliveries()), "queued deliveries")
+}
@@ -2,36 +2,88 @@ package nakama
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
-	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/SherClockHolmes/webpush-go"
 )
 
 const (
-	webPushNoticationSendTimeout = time.Second * 30
-	webPushNoticationContact     = "contact@nakama.social"
+	webPushSubscriptionDeviceLabelMaxLength = 64
+	webPushSubscriptionUserAgentMaxLength   = 512
 )
 
 var (
-	errWebPushSubscriptionGone = GoneError("web push subscription gone")
+	// ErrInvalidWebPushSubscription denotes an invalid web push subscription; that is without endpoint or keys.
+	ErrInvalidWebPushSubscription = InvalidArgumentError("invalid web push subscription")
+	// ErrInvalidWebPushSubscriptionID denotes an invalid web push subscription ID; that is not uuid.
+	ErrInvalidWebPushSubscriptionID = InvalidArgumentError("invalid web push subscription ID")
+	// ErrInvalidDeviceLabel denotes an invalid device label. That is empty or it exceeds the max allowed characters (64).
+	ErrInvalidDeviceLabel = InvalidArgumentError("invalid device label")
+	// ErrWebPushUnavailable denotes that web push has not been configured on this server.
+	ErrWebPushUnavailable = UnimplementedError("web push unavailable")
 )
 
-func (svc *Service) AddWebPushSubscription(ctx context.Context, sub webpush.Subscription) error {
+// WebPushSubscription model.
+// It does not include the subscription keys.
+type WebPushSubscription struct {
+	ID          string    `json:"id"`
+	Endpoint    string    `json:"endpoint"`
+	DeviceLabel *string   `json:"deviceLabel"`
+	UserAgent   *string   `json:"userAgent"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type AddWebPushSubscription struct {
+	webpush.Subscription
+	DeviceLabel *string `json:"deviceLabel"`
+	UserAgent   string  `json:"-"`
+}
+
+// AddWebPushSubscription for the authenticated user.
+// Adding an already existing subscription updates its device label and user agent.
+func (svc *Service) AddWebPushSubscription(ctx context.Context, in AddWebPushSubscription) error {
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
 		return ErrUnauthenticated
 	}
 
-	query := "INSERT INTO user_web_push_subscriptions (user_id, sub) VALUES ($1, $2)"
-	_, err := svc.DB.ExecContext(ctx, query, uid, jsonValue{sub})
+	if in.Endpoint == "" || in.Keys.Auth == "" || in.Keys.P256dh == "" {
+		return ErrInvalidWebPushSubscription
+	}
+
+	if in.DeviceLabel != nil {
+		*in.DeviceLabel = strings.TrimSpace(*in.DeviceLabel)
+		if *in.DeviceLabel == "" || utf8.RuneCountInString(*in.DeviceLabel) > webPushSubscriptionDeviceLabelMaxLength {
+			return ErrInvalidDeviceLabel
+		}
+	}
+
+	var userAgent *string
+	if in.UserAgent = strings.TrimSpace(in.UserAgent); in.UserAgent != "" {
+		if utf8.RuneCountInString(in.UserAgent) > webPushSubscriptionUserAgentMaxLength {
+			in.UserAgent = string([]rune(in.UserAgent)[:webPushSubscriptionUserAgentMaxLength])
+		}
+		userAgent = &in.UserAgent
+	}
+
+	query := "INSERT INTO user_web_push_subscriptions (user_id, sub, device_label, user_agent) VALUES ($1, $2, $3, $4)"
+	_, err := svc.DB.ExecContext(ctx, query, uid, jsonValue{in.Subscription}, in.DeviceLabel, userAgent)
 	if isUniqueViolation(err) {
+		query := `
+			UPDATE user_web_push_subscriptions SET
+				sub = $1
+				, device_label = COALESCE($2, device_label)
+				, user_agent = COALESCE($3, user_agent)
+			WHERE user_id = $4 AND sub->>'endpoint' = $5`
+		// browsers might re-subscribe the same endpoint with rotated keys.
+		_, err = svc.DB.ExecContext(ctx, query, jsonValue{in.Subscription}, in.DeviceLabel, userAgent, uid, in.Endpoint)
+		if err != nil {
+			return fmt.Errorf("could not sql update user web push subscription: %w", err)
+		}
+
 		return nil
 	}
 
@@ -42,116 +94,100 @@ func (svc *Service) AddWebPushSubscription(ctx context.Context, sub webpush.Subs
 	return nil
 }
 
-func (svc *Service) webPushSubscriptions(ctx context.Context, userID string) ([]webpush.Subscription, error) {
-	query := "SELECT sub FROM user_web_push_subscriptions WHERE user_id = $1 ORDER BY created_at DESC"
-	rows, err := svc.DB.QueryContext(ctx, query, userID)
+// WebPushSubscriptions of the authenticated user. One for each subscribed device.
+func (svc *Service) WebPushSubscriptions(ctx context.Context) ([]WebPushSubscription, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	query := `
+		SELECT id, sub->>'endpoint', device_label, user_agent, created_at
+		FROM user_web_push_subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+	rows, err := svc.DB.QueryContext(ctx, query, uid)
 	if err != nil {
-		return nil, fmt.Errorf("could not sql query select user web push susbcriptions: %w", err)
+		return nil, fmt.Errorf("could not sql query select web push subscriptions: %w", err)
 	}
 
 	defer rows.Close()
 
-	var subs []webpush.Subscription
+	var out []WebPushSubscription
 	for rows.Next() {
-		var sub webpush.Subscription
-		err := rows.Scan(&jsonValue{&sub})
+		var sub WebPushSubscription
+		err := rows.Scan(&sub.ID, &sub.Endpoint, &sub.DeviceLabel, &sub.UserAgent, &sub.CreatedAt)
 		if err != nil {
-			return nil, fmt.Errorf("could not sql scan user web push subscription: %w", err)
+			return nil, fmt.Errorf("could not sql scan web push subscription: %w", err)
 		}
 
-		subs = append(subs, sub)
+		out = append(out, sub)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not sql query iterate over user web push subscriptions: %w", err)
+		return nil, fmt.Errorf("could not sql query iterate over web push subscriptions: %w", err)
 	}
 
-	return subs, nil
+	return out, nil
 }
 
-func (svc *Service) sendWebPushNotifications(n Notification) {
-	ctx := context.Background()
-	subs, err := svc.webPushSubscriptions(ctx, n.UserID)
-	if err != nil {
-		_ = svc.Logger.Log("err", err)
-		return
+// DeleteWebPushSubscription from the authenticated user.
+func (svc *Service) DeleteWebPushSubscription(ctx context.Context, subscriptionID string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
 	}
 
-	if len(subs) == 0 {
-		return
+	if !reUUID.MatchString(subscriptionID) {
+		return ErrInvalidWebPushSubscriptionID
 	}
 
-	message, err := json.Marshal(n)
+	query := "DELETE FROM user_web_push_subscriptions WHERE id = $1 AND user_id = $2"
+	_, err := svc.DB.ExecContext(ctx, query, subscriptionID, uid)
 	if err != nil {
-		_ = svc.Logger.Log("err", fmt.Errorf("could not json marshal web push notification message: %w", err))
-		return
-	}
-
-	var topic string
-	if n.PostID != nil {
-		// Topic can have only 32 characters.
-		// By removing the dashes from the UUID we can go from 36 to 32 characters.
-		topic = strings.ReplaceAll(*n.PostID, "-", "")
+		return fmt.Errorf("could not sql delete web push subscription: %w", err)
 	}
 
-	var wg sync.WaitGroup
-
-	for _, sub := range subs {
-		wg.Add(1)
-		sub := sub
-		go func() {
-			defer wg.Done()
-
-			err := svc.sendWebPushNotification(sub, message, topic)
-			if errors.Is(err, errWebPushSubscriptionGone) {
-				err = svc.deleteWebPushSubscription(ctx, n.UserID, sub)
-			}
+	return nil
+}
 
-			if err != nil {
-				_ = svc.Logger.Log("err", err)
-			}
-		}()
+// WebPushPublicKey is the VAPID public key clients need to subscribe to web push.
+func (svc *Service) WebPushPublicKey(ctx context.Context) (string, error) {
+	if svc.VAPIDPublicKey == "" {
+		return "", ErrWebPushUnavailable
 	}
 
-	wg.Wait()
+	return svc.VAPIDPublicKey, nil
+}
+
+type userWebPushSubscription struct {
+	ID  string
+	Sub webpush.Subscription
 }
 
-func (svc *Service) sendWebPushNotification(sub webpush.Subscription, message []byte, topic string) error {
-	resp, err := webpush.SendNotification(message, &sub, &webpush.Options{
-		Subscriber:      webPushNoticationContact,
-		Topic:           topic,
-		VAPIDPrivateKey: svc.VAPIDPrivateKey,
-		VAPIDPublicKey:  svc.VAPIDPublicKey,
-		TTL:             int(webPushNoticationSendTimeout.Seconds()),
-	})
+func (svc *Service) webPushSubscriptions(ctx context.Context, userID string) ([]userWebPushSubscription, error) {
+	query := "SELECT id, sub FROM user_web_push_subscriptions WHERE user_id = $1 ORDER BY created_at DESC"
+	rows, err := svc.DB.QueryContext(ctx, query, userID)
 	if err != nil {
-		return fmt.Errorf("could not send web push notification: %w", err)
+		return nil, fmt.Errorf("could not sql query select user web push susbcriptions: %w", err)
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		// subscription has been removed.
-		if resp.StatusCode == http.StatusGone {
-			return errWebPushSubscriptionGone
-		}
+	defer rows.Close()
 
-		if b, err := io.ReadAll(resp.Body); err == nil {
-			return fmt.Errorf("web push notification send failed with status code %d: %s", resp.StatusCode, string(b))
+	var subs []userWebPushSubscription
+	for rows.Next() {
+		var sub userWebPushSubscription
+		err := rows.Scan(&sub.ID, &jsonValue{&sub.Sub})
+		if err != nil {
+			return nil, fmt.Errorf("could not sql scan user web push subscription: %w", err)
 		}
 
-		return fmt.Errorf("web push notification send failed with status code %d", resp.StatusCode)
+		subs = append(subs, sub)
 	}
 
-	return nil
-}
-
-func (svc *Service) deleteWebPushSubscription(ctx context.Context, userID string, sub webpush.Subscription) error {
-	query := "DELETE FROM user_web_push_subscriptions WHERE user_id = $1 AND sub = $2"
-	_, err := svc.DB.ExecContext(ctx, query, userID, jsonValue{sub})
-	if err != nil {
-		return fmt.Errorf("sql delete user web push subscription: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not sql query iterate over user web push subscriptions: %w", err)
 	}
 
-	return nil
+	return subs, nil
 }
@@ -0,0 +1,400 @@
+package nakama
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	webhookURLMaxLength = 2048
+	webhooksMaxPerUser  = 10
+)
+
+// Webhook events.
+const (
+	// WebhookEventPost is a new post by the webhook owner.
+	WebhookEventPost = "post"
+	// WebhookEventFollow is a new follower of the webhook owner.
+	WebhookEventFollow = "follow"
+	// WebhookEventMention is a mention of the webhook owner on a post or comment.
+	WebhookEventMention = "mention"
+	// WebhookEventComment is a comment on a post of the webhook owner.
+	WebhookEventComment = "comment"
+)
+
+var webhookEvents = []string{
+	WebhookEventPost,
+	WebhookEventFollow,
+	WebhookEventMention,
+	WebhookEventComment,
+}
+
+var (
+	// ErrInvalidWebhookID denotes an invalid webhook ID; that is not uuid.
+	ErrInvalidWebhookID = InvalidArgumentError("invalid webhook ID")
+	// ErrInvalidWebhookURL denotes an invalid webhook URL; that is not an absolute http(s) URL.
+	ErrInvalidWebhookURL = InvalidArgumentError("invalid webhook URL")
+	// ErrInvalidWebhookEvents denotes an empty or unknown list of webhook events.
+	ErrInvalidWebhookEvents = InvalidArgumentError("invalid webhook events")
+	// ErrTooManyWebhooks denotes that the user reached the max allowed webhooks.
+	ErrTooManyWebhooks = InvalidArgumentError("too many webhooks")
+	// ErrWebhookNotFound denotes a not found webhook.
+	ErrWebhookNotFound = NotFoundError("webhook not found")
+	// ErrAdminRequired denotes an action that only instance admins can perform.
+	ErrAdminRequired = PermissionDeniedError("admin required")
+)
+
+// Webhook model.
+// Instance webhooks receive the events of every user.
+// The secret used to sign deliveries is only returned on creation.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Instance  bool      `json:"instance"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreateWebhook struct {
+	URL      string   `json:"url"`
+	Events   []string `json:"events"`
+	Instance bool     `json:"instance"`
+}
+
+// WebhookDelivery log entry.
+type WebhookDelivery struct {
+	ID         string          `json:"id"`
+	WebhookID  string          `json:"webhookID"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload"`
+	Status     string          `json:"status"`
+	Attempts   int             `json:"attempts"`
+	StatusCode *int            `json:"statusCode"`
+	Error      *string         `json:"error"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+}
+
+type WebhookDeliveries []WebhookDelivery
+
+func (dd WebhookDeliveries) EndCursor() *string {
+	if len(dd) == 0 {
+		return nil
+	}
+
+	last := dd[len(dd)-1]
+	return ptrString(encodeCursor(last.ID, last.CreatedAt))
+}
+
+// CreateWebhook for the authenticated user.
+// Only admins can create instance webhooks.
+func (s *Service) CreateWebhook(ctx context.Context, in CreateWebhook) (Webhook, error) {
+	var out Webhook
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	in.URL = strings.TrimSpace(in.URL)
+	if !validWebhookURL(in.URL) {
+		return out, ErrInvalidWebhookURL
+	}
+
+	events, ok := normalizeWebhookEvents(in.Events)
+	if !ok {
+		return out, ErrInvalidWebhookEvents
+	}
+
+	if in.Instance && !s.isAdmin(uid) {
+		return out, ErrAdminRequired
+	}
+
+	secret, err := genWebhookSecret()
+	if err != nil {
+		return out, err
+	}
+
+	var owner *string
+	if !in.Instance {
+		owner = &uid
+
+		var count int
+		query := "SELECT COUNT(*) FROM webhooks WHERE user_id = $1"
+		if err := s.DB.QueryRowContext(ctx, query, uid).Scan(&count); err != nil {
+			return out, fmt.Errorf("could not sql count webhooks: %w", err)
+		}
+
+		if count >= webhooksMaxPerUser {
+			return out, ErrTooManyWebhooks
+		}
+	}
+
+	query := `
+		INSERT INTO webhooks (user_id, url, events, secret) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	row := s.DB.QueryRowContext(ctx, query, owner, in.URL, pq.Array(events), secret)
+	if err := row.Scan(&out.ID, &out.CreatedAt); err != nil {
+		return out, fmt.Errorf("could not sql insert webhook: %w", err)
+	}
+
+	out.URL = in.URL
+	out.Events = events
+	out.Instance = in.Instance
+	out.Secret = secret
+
+	return out, nil
+}
+
+// Webhooks of the authenticated user.
+// Admins also get the instance webhooks.
+func (s *Service) Webhooks(ctx context.Context) ([]Webhook, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	query, args, err := buildQuery(`
+		SELECT id, url, events, user_id IS NULL, created_at
+		FROM webhooks
+		WHERE user_id = @uid
+		{{ if .admin }}
+			OR user_id IS NULL
+		{{ end }}
+		ORDER BY created_at DESC`, map[string]interface{}{
+		"uid":   uid,
+		"admin": s.isAdmin(uid),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build webhooks sql query: %w", err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select webhooks: %w", err)
+	}
+
+	defer rows.Close()
+
+	var ww []Webhook
+	for rows.Next() {
+		var w Webhook
+		if err := rows.Scan(&w.ID, &w.URL, pq.Array(&w.Events), &w.Instance, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not sql scan webhook: %w", err)
+		}
+
+		ww = append(ww, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate over webhook rows: %w", err)
+	}
+
+	return ww, nil
+}
+
+// DeleteWebhook owned by the authenticated user.
+// Admins can also delete instance webhooks.
+func (s *Service) DeleteWebhook(ctx context.Context, webhookID string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(webhookID) {
+		return ErrInvalidWebhookID
+	}
+
+	if err := s.canManageWebhook(ctx, uid, webhookID); err != nil {
+		return err
+	}
+
+	query := "DELETE FROM webhooks WHERE id = $1"
+	if _, err := s.DB.ExecContext(ctx, query, webhookID); err != nil {
+		return fmt.Errorf("could not sql delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// WebhookDeliveries log of the given webhook in descending order with backward pagination.
+func (s *Service) WebhookDeliveries(ctx context.Context, webhookID string, last uint64, before *string) (WebhookDeliveries, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(webhookID) {
+		return nil, ErrInvalidWebhookID
+	}
+
+	var beforeDeliveryID string
+	var beforeCreatedAt time.Time
+
+	if before != nil {
+		var err error
+		beforeDeliveryID, beforeCreatedAt, err = decodeCursor(*before)
+		if err != nil || !reUUID.MatchString(beforeDeliveryID) {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	if err := s.canManageWebhook(ctx, uid, webhookID); err != nil {
+		return nil, err
+	}
+
+	last = normalizePageSize(last)
+	query, args, err := buildQuery(`
+		SELECT id
+		, event
+		, payload
+		, status
+		, attempts
+		, status_code
+		, error
+		, created_at
+		, updated_at
+		FROM webhook_deliveries
+		WHERE webhook_id = @webhookID
+		{{ if and .beforeDeliveryID .beforeCreatedAt }}
+			AND created_at <= @beforeCreatedAt
+			AND (
+				id < @beforeDeliveryID
+					OR created_at < @beforeCreatedAt
+			)
+		{{ end }}
+		ORDER BY created_at DESC, id DESC
+		LIMIT @last`, map[string]interface{}{
+		"webhookID":        webhookID,
+		"last":             last,
+		"beforeDeliveryID": beforeDeliveryID,
+		"beforeCreatedAt":  beforeCreatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build webhook deliveries sql query: %w", err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not sql query select webhook deliveries: %w", err)
+	}
+
+	defer rows.Close()
+
+	var dd WebhookDeliveries
+	for rows.Next() {
+		var d WebhookDelivery
+		var payload []byte
+		err := rows.Scan(
+			&d.ID,
+			&d.Event,
+			&payload,
+			&d.Status,
+			&d.Attempts,
+			&d.StatusCode,
+			&d.Error,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not sql scan webhook delivery: %w", err)
+		}
+
+		d.WebhookID = webhookID
+		d.Payload = payload
+		dd = append(dd, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate over webhook delivery rows: %w", err)
+	}
+
+	return dd, nil
+}
+
+func (s *Service) canManageWebhook(ctx context.Context, uid, webhookID string) error {
+	var owner sql.NullString
+	query := "SELECT user_id FROM webhooks WHERE id = $1"
+	err := s.DB.QueryRowContext(ctx, query, webhookID).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return ErrWebhookNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not sql query select webhook owner: %w", err)
+	}
+
+	if owner.Valid && owner.String == uid {
+		return nil
+	}
+
+	if !owner.Valid && s.isAdmin(uid) {
+		return nil
+	}
+
+	// do not leak the existence of other users webhooks.
+	return ErrWebhookNotFound
+}
+
+func (s *Service) isAdmin(userID string) bool {
+	for _, id := range s.Admins {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func validWebhookURL(s string) bool {
+	if s == "" || len(s) > webhookURLMaxLength {
+		return false
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" && u.User == nil
+}
+
+// normalizeWebhookEvents deduplicates the given events in a stable order
+// and reports false if any of them is unknown.
+func normalizeWebhookEvents(events []string) ([]string, bool) {
+	if len(events) == 0 {
+		return nil, false
+	}
+
+	set := map[string]bool{}
+	for _, ev := range events {
+		set[ev] = true
+	}
+
+	var out []string
+	for _, ev := range webhookEvents {
+		if set[ev] {
+			out = append(out, ev)
+			delete(set, ev)
+		}
+	}
+
+	return out, len(set) == 0
+}
+
+func genWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate webhook secret: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,578 @@
+package nakama
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	webhookSendTimeout = time.Second * 10
+
+	webhookQueueSize   = 1024
+	webhookWorkers     = 4
+	webhookMaxAttempts = 6
+	webhookBaseBackoff = time.Second * 5
+	webhookMaxBackoff  = time.Minute * 30
+
+	// webhookDeliveryLease is how long a queued delivery stays claimed.
+	// Deliveries still pending after it, like the ones interrupted by a restart,
+	// get resumed by resumeWebhookDeliveriesJob.
+	webhookDeliveryLease         = time.Minute * 5
+	webhookDeliveriesResumeEvery = time.Second * 5
+	webhookDeliveriesResumeBatch = webhookQueueSize / 4
+
+	webhookDeliveriesRetention    = time.Hour * 24 * 30
+	webhookDeliveriesPruneEvery   = time.Hour
+	webhookDeliveriesPruneBatch   = 1000
+	webhookDeliveryErrorMaxLength = 512
+)
+
+var errWebhookPrivateAddress = errors.New("webhook address not allowed")
+
+var (
+	webhookDeliveredTotal = promauto.NewCounter(prometheus.CounterOpts{Name: "webhook_deliveries_succeeded_total"})
+	webhookFailedTotal    = promauto.NewCounter(prometheus.CounterOpts{Name: "webhook_deliveries_failed_total"})
+	webhookRetriedTotal   = promauto.NewCounter(prometheus.CounterOpts{Name: "webhook_deliveries_retried_total"})
+)
+
+// webhookPayload is the JSON body of every delivery.
+type webhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+type webhookPostData struct {
+	Post Post `json:"post"`
+}
+
+type webhookFollowData struct {
+	Follower User `json:"follower"`
+	Followee User `json:"followee"`
+}
+
+type webhookCommentData struct {
+	PostID  string  `json:"postID"`
+	Comment Comment `json:"comment"`
+}
+
+type webhookMentionData struct {
+	PostID  string   `json:"postID"`
+	Post    *Post    `json:"post,omitempty"`
+	Comment *Comment `json:"comment,omitempty"`
+}
+
+type webhookDelivery struct {
+	ID      string
+	URL     string
+	Secret  string
+	Event   string
+	Body    []byte
+	Attempt int
+}
+
+// webhookStatusError is returned when the webhook endpoint responds with an error status code.
+type webhookStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook responded with status code %d", e.StatusCode)
+}
+
+// Retryable reports whether the endpoint may succeed later.
+func (e *webhookStatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func (s *Service) webhookDeliveries() chan webhookDelivery {
+	s.webhookQueueOncer.Do(func() {
+		s.webhookQueue = make(chan webhookDelivery, webhookQueueSize)
+	})
+	return s.webhookQueue
+}
+
+// webhookDeliveryJob runs the workers that drain the webhook delivery queue
+// along with the jobs that resume and prune deliveries.
+func (s *Service) webhookDeliveryJob(ctx context.Context) {
+	queue := s.webhookDeliveries()
+	for i := 0; i < webhookWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case d := <-queue:
+					s.deliverWebhook(ctx, d)
+				}
+			}
+		}()
+	}
+
+	go s.resumeWebhookDeliveriesJob(ctx)
+	s.pruneWebhookDeliveriesJob(ctx)
+}
+
+// emitWebhookEvent logs and queues a delivery for every webhook subscribed to the event;
+// the ones owned by the given users plus the instance webhooks.
+func (s *Service) emitWebhookEvent(event string, userIDs []string, data interface{}) {
+	ctx := context.Background()
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, url, secret FROM webhooks
+		WHERE $1 = ANY(events)
+			AND (user_id IS NULL OR user_id = ANY($2))`, event, pq.Array(userIDs))
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not sql query select %s webhooks: %w", event, err))
+		return
+	}
+
+	type hook struct{ id, url, secret string }
+	var hooks []hook
+	for rows.Next() {
+		var h hook
+		if err := rows.Scan(&h.id, &h.url, &h.secret); err != nil {
+			_ = rows.Close()
+			_ = s.Logger.Log("error", fmt.Errorf("could not sql scan webhook: %w", err))
+			return
+		}
+
+		hooks = append(hooks, h)
+	}
+	_ = rows.Close()
+
+	if err := rows.Err(); err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not iterate over webhook rows: %w", err))
+		return
+	}
+
+	for _, h := range hooks {
+		d, err := s.createWebhookDelivery(ctx, h.id, event, data)
+		if err != nil {
+			_ = s.Logger.Log("error", err)
+			continue
+		}
+
+		d.URL = h.url
+		d.Secret = h.secret
+		s.enqueueWebhookDelivery(d)
+	}
+}
+
+// followWebhook emits the follow event to the followee webhooks.
+func (s *Service) followWebhook(followerID, followeeID string) {
+	ctx := context.Background()
+	follower, err := s.userByID(ctx, followerID)
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not fetch follow webhook follower: %w", err))
+		return
+	}
+
+	followee, err := s.userByID(ctx, followeeID)
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not fetch follow webhook followee: %w", err))
+		return
+	}
+
+	follower.ID = followerID
+	followee.ID = followeeID
+	s.emitWebhookEvent(WebhookEventFollow, []string{followeeID}, webhookFollowData{
+		Follower: follower,
+		Followee: followee,
+	})
+}
+
+// commentWebhook emits the comment event to the post author webhooks,
+// unless they commented on their own post.
+func (s *Service) commentWebhook(c Comment) {
+	var postUserID string
+	query := "SELECT user_id FROM posts WHERE id = $1"
+	err := s.DB.QueryRow(query, c.PostID).Scan(&postUserID)
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not sql query select comment webhook post author: %w", err))
+		return
+	}
+
+	var owners []string
+	if postUserID != c.UserID {
+		owners = append(owners, postUserID)
+	}
+
+	s.emitWebhookEvent(WebhookEventComment, owners, webhookCommentData{PostID: c.PostID, Comment: c})
+}
+
+// createWebhookDelivery logs a pending delivery so it shows up on the delivery log
+// before the first attempt.
+// It starts claimed by the caller for webhookDeliveryLease.
+func (s *Service) createWebhookDelivery(ctx context.Context, webhookID, event string, data interface{}) (webhookDelivery, error) {
+	d := webhookDelivery{Event: event}
+	p := webhookPayload{
+		ID:        uuid.NewString(),
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		return d, fmt.Errorf("could not json marshal webhook payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, event, payload, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := s.DB.ExecContext(ctx, query, p.ID, webhookID, event, body, p.CreatedAt.Add(webhookDeliveryLease), p.CreatedAt); err != nil {
+		return d, fmt.Errorf("could not sql insert webhook delivery: %w", err)
+	}
+
+	d.ID = p.ID
+	d.Body = body
+	return d, nil
+}
+
+// enqueueWebhookDelivery queues a claimed delivery.
+// When the queue is full, the delivery stays pending
+// and gets resumed once its lease expires.
+func (s *Service) enqueueWebhookDelivery(d webhookDelivery) {
+	select {
+	case s.webhookDeliveries() <- d:
+	default:
+		_ = s.Logger.Log("error", "webhook delivery queue full; delaying delivery", "delivery_id", d.ID)
+	}
+}
+
+func (s *Service) deliverWebhook(ctx context.Context, d webhookDelivery) {
+	statusCode, err := s.sendWebhook(ctx, d)
+	if err == nil {
+		webhookDeliveredTotal.Inc()
+		s.updateWebhookDelivery(ctx, d, "succeeded", statusCode, nil, nil)
+		return
+	}
+
+	var statusErr *webhookStatusError
+	retryable := !errors.As(err, &statusErr) || statusErr.Retryable()
+	if errors.Is(err, errWebhookPrivateAddress) {
+		retryable = false
+	}
+
+	// retries are persisted and resumed by resumeWebhookDeliveriesJob
+	// so they survive restarts.
+	if retryable && d.Attempt+1 < webhookMaxAttempts {
+		var retryAfter time.Duration
+		if statusErr != nil {
+			retryAfter = statusErr.RetryAfter
+		}
+
+		webhookRetriedTotal.Inc()
+		nextAttemptAt := time.Now().Add(retryDelay(webhookBaseBackoff, webhookMaxBackoff, d.Attempt, retryAfter))
+		s.updateWebhookDelivery(ctx, d, "pending", statusCode, err, &nextAttemptAt)
+		return
+	}
+
+	webhookFailedTotal.Inc()
+	s.updateWebhookDelivery(ctx, d, "failed", statusCode, err, nil)
+}
+
+// sendWebhook posts the payload signed with the webhook secret.
+// See webhookSignature.
+func (s *Service) sendWebhook(ctx context.Context, d webhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookSendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return 0, fmt.Errorf("could not create webhook request: %w", err)
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nakama-webhooks")
+	req.Header.Set("X-Nakama-Event", d.Event)
+	req.Header.Set("X-Nakama-Delivery", d.ID)
+	req.Header.Set("X-Nakama-Timestamp", ts)
+	req.Header.Set("X-Nakama-Signature", "sha256="+webhookSignature(d.Secret, ts, d.Body))
+
+	resp, err := s.webhookHTTPClient().Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("could not send webhook: %w", err)
+	}
+
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, &webhookStatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (s *Service) updateWebhookDelivery(ctx context.Context, d webhookDelivery, status string, statusCode int, deliveryErr error, nextAttemptAt *time.Time) {
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+
+	var errMsg *string
+	if deliveryErr != nil {
+		msg := deliveryErr.Error()
+		if len(msg) > webhookDeliveryErrorMaxLength {
+			msg = msg[:webhookDeliveryErrorMaxLength]
+		}
+		errMsg = &msg
+	}
+
+	query := `
+		UPDATE webhook_deliveries SET
+			status = $1
+			, attempts = $2
+			, status_code = $3
+			, error = $4
+			, next_attempt_at = COALESCE($5, next_attempt_at)
+			, updated_at = now()
+		WHERE id = $6`
+	_, err := s.DB.ExecContext(ctx, query, status, d.Attempt+1, code, errMsg, nextAttemptAt, d.ID)
+	if err != nil {
+		_ = s.Logger.Log("error", fmt.Errorf("could not sql update webhook delivery: %w", err))
+	}
+}
+
+// resumeWebhookDeliveriesJob queues the pending deliveries due for another attempt;
+// scheduled retries and the ones interrupted by a restart or a full queue.
+func (s *Service) resumeWebhookDeliveriesJob(ctx context.Context) {
+	ticker := time.NewTicker(webhookDeliveriesResumeEvery)
+	defer ticker.Stop()
+
+	for {
+		if err := s.resumeWebhookDeliveries(ctx); err != nil {
+			_ = s.Logger.Log("error", fmt.Errorf("could not resume webhook deliveries: %w", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resumeWebhookDeliveries claims a batch of due pending deliveries
+// for webhookDeliveryLease and queues them.
+// Claiming makes a single node resume each of them.
+func (s *Service) resumeWebhookDeliveries(ctx context.Context) error {
+	rows, err := s.DB.QueryContext(ctx, `
+		UPDATE webhook_deliveries SET next_attempt_at = $1
+		WHERE status = 'pending' AND next_attempt_at <= now()
+		ORDER BY next_attempt_at
+		LIMIT $2
+		RETURNING id, webhook_id, event, payload, attempts`, time.Now().Add(webhookDeliveryLease), webhookDeliveriesResumeBatch)
+	if err != nil {
+		return fmt.Errorf("could not sql update claim due webhook deliveries: %w", err)
+	}
+
+	var dd []webhookDelivery
+	var webhookIDs []string
+	for rows.Next() {
+		var d webhookDelivery
+		var webhookID string
+		if err := rows.Scan(&d.ID, &webhookID, &d.Event, &d.Body, &d.Attempt); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("could not sql scan due webhook delivery: %w", err)
+		}
+
+		dd = append(dd, d)
+		webhookIDs = append(webhookIDs, webhookID)
+	}
+	_ = rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate over due webhook delivery rows: %w", err)
+	}
+
+	if len(dd) == 0 {
+		return nil
+	}
+
+	type hook struct{ url, secret string }
+	hooks := map[string]hook{}
+	rows, err = s.DB.QueryContext(ctx, "SELECT id, url, secret FROM webhooks WHERE id = ANY($1)", pq.Array(webhookIDs))
+	if err != nil {
+		return fmt.Errorf("could not sql query select due delivery webhooks: %w", err)
+	}
+
+	for rows.Next() {
+		var id string
+		var h hook
+		if err := rows.Scan(&id, &h.url, &h.secret); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("could not sql scan due delivery webhook: %w", err)
+		}
+
+		hooks[id] = h
+	}
+	_ = rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate over due delivery webhook rows: %w", err)
+	}
+
+	for i, d := range dd {
+		// webhook deleted meanwhile; its deliveries cascade.
+		h, ok := hooks[webhookIDs[i]]
+		if !ok {
+			continue
+		}
+
+		d.URL = h.url
+		d.Secret = h.secret
+		s.enqueueWebhookDelivery(d)
+	}
+
+	return nil
+}
+
+// pruneWebhookDeliveriesJob deletes the delivery log entries older than a month.
+func (s *Service) pruneWebhookDeliveriesJob(ctx context.Context) {
+	ticker := time.NewTicker(webhookDeliveriesPruneEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.pruneWebhookDeliveries(ctx, time.Now().Add(-webhookDeliveriesRetention))
+			if err != nil {
+				_ = s.Logger.Log("error", fmt.Errorf("could not prune webhook deliveries: %w", err))
+				continue
+			}
+
+			if n != 0 {
+				_ = s.Logger.Log("message", "pruned webhook deliveries", "count", n)
+			}
+		}
+	}
+}
+
+func (s *Service) pruneWebhookDeliveries(ctx context.Context, olderThan time.Time) (int64, error) {
+	var total int64
+	for {
+		result, err := s.DB.ExecContext(ctx, `
+			DELETE FROM webhook_deliveries
+			WHERE created_at < $1
+			LIMIT $2`, olderThan, webhookDeliveriesPruneBatch)
+		if err != nil {
+			return total, fmt.Errorf("could not sql delete old webhook deliveries: %w", err)
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("could not get pruned webhook deliveries count: %w", err)
+		}
+
+		total += n
+		if n < webhookDeliveriesPruneBatch {
+			return total, nil
+		}
+	}
+}
+
+func (s *Service) webhookHTTPClient() *http.Client {
+	if s.webhookClient != nil {
+		return s.webhookClient
+	}
+
+	return defaultWebhookClient
+}
+
+// defaultWebhookClient refuses to connect to loopback, private and other non public addresses
+// so webhooks cannot be used to reach internal services.
+var defaultWebhookClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: webhookSendTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+
+				if blockedWebhookIP(net.ParseIP(host)) {
+					return errWebhookPrivateAddress
+				}
+
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: webhookSendTimeout,
+		MaxIdleConnsPerHost: 2,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// blockedWebhookNets are non public IPv4 ranges not covered by the net.IP methods:
+// "this network" and carrier-grade NAT shared address space.
+var blockedWebhookNets = []*net.IPNet{
+	{IP: net.IPv4(0, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(100, 64, 0, 0).To4(), Mask: net.CIDRMask(10, 32)},
+}
+
+// blockedWebhookIP reports whether webhooks must not connect to ip.
+// IPv4-mapped IPv6 addresses are checked as the IPv4 address they map to.
+func blockedWebhookIP(ip net.IP) bool {
+	if ip == nil {
+		return true
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+
+	for _, n := range blockedWebhookNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// webhookSignature is the hex encoded HMAC-SHA256 of "<timestamp>.<body>"
+// using the webhook secret as key.
+// Receivers should recompute it and reject old timestamps to prevent replays.
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,201 @@
+package nakama
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// wireVersion of the messages broadcasted over PubSub.
+// Only bump it on breaking changes; adding fields is fine
+// since decoders ignore unknown fields.
+const wireVersion = 1
+
+const (
+	wireTypePost         = "post"
+	wireTypeTimelineItem = "timeline_item"
+	wireTypeNotification = "notification"
+	wireTypeComment      = "comment"
+	wireTypeMessage      = "message"
+	wireTypeMessageRead  = "message_read"
+
+	wireTypeCommentPresence = "comment_presence"
+)
+
+var (
+	errUnexpectedWireType     = errors.New("unexpected wire message type")
+	errUnsupportedWireVersion = errors.New("unsupported wire message version")
+)
+
+// wireMessage is the JSON envelope of every message broadcasted over PubSub,
+// so nodes running different versions, and non Go consumers, can read them.
+// Ephemeral messages are delivered to current subscribers only
+// and never replayed.
+type wireMessage struct {
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	Ephemeral bool            `json:"ephemeral,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func marshalWire(typ string, v any) ([]byte, error) {
+	return encodeWire(typ, false, v)
+}
+
+func marshalEphemeralWire(typ string, v any) ([]byte, error) {
+	return encodeWire(typ, true, v)
+}
+
+func encodeWire(typ string, ephemeral bool, v any) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("could not json marshal %s wire payload: %w", typ, err)
+	}
+
+	return json.Marshal(wireMessage{
+		Type:      typ,
+		Version:   wireVersion,
+		Ephemeral: ephemeral,
+		Payload:   payload,
+	})
+}
+
+// isEphemeralWire reports whether data is an ephemeral wire message.
+func isEphemeralWire(data []byte) bool {
+	var msg struct {
+		Ephemeral bool `json:"ephemeral"`
+	}
+	return json.Unmarshal(data, &msg) == nil && msg.Ephemeral
+}
+
+func unmarshalWire(data []byte, typ string, v any) error {
+	var msg wireMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("could not json unmarshal wire message: %w", err)
+	}
+
+	if msg.Type != typ {
+		return fmt.Errorf("%w: want %q, got %q", errUnexpectedWireType, typ, msg.Type)
+	}
+
+	if msg.Version < 1 || msg.Version > wireVersion {
+		return fmt.Errorf("%w: %d", errUnsupportedWireVersion, msg.Version)
+	}
+
+	if err := json.Unmarshal(msg.Payload, v); err != nil {
+		return fmt.Errorf("could not json unmarshal %s wire payload: %w", typ, err)
+	}
+
+	return nil
+}
+
+// wire payloads add the fields hidden from the API responses.
+
+type wirePost struct {
+	Post
+	UserID string `json:"userID"`
+}
+
+type wireTimelineItem struct {
+	ID     string    `json:"id"`
+	UserID string    `json:"userID"`
+	PostID string    `json:"postID"`
+	Post   *wirePost `json:"post"`
+}
+
+type wireNotification struct {
+	Notification
+	UserID string `json:"userID"`
+}
+
+type wireComment struct {
+	Comment
+	UserID string `json:"userID"`
+	PostID string `json:"postID"`
+}
+
+type wireDirectMessage struct {
+	Message
+	UserID string `json:"userID"`
+}
+
+type wireReadReceipt struct {
+	ReadReceipt
+	UserID string `json:"userID"`
+}
+
+func marshalPost(p Post) ([]byte, error) {
+	return marshalWire(wireTypePost, wirePost{Post: p, UserID: p.UserID})
+}
+
+func unmarshalPost(data []byte) (Post, error) {
+	var w wirePost
+	err := unmarshalWire(data, wireTypePost, &w)
+	w.Post.UserID = w.UserID
+	return w.Post, err
+}
+
+func marshalTimelineItem(ti TimelineItem) ([]byte, error) {
+	w := wireTimelineItem{ID: ti.ID, UserID: ti.UserID, PostID: ti.PostID}
+	if ti.Post != nil {
+		w.Post = &wirePost{Post: *ti.Post, UserID: ti.Post.UserID}
+	}
+	return marshalWire(wireTypeTimelineItem, w)
+}
+
+func unmarshalTimelineItem(data []byte) (TimelineItem, error) {
+	var w wireTimelineItem
+	err := unmarshalWire(data, wireTypeTimelineItem, &w)
+	ti := TimelineItem{ID: w.ID, UserID: w.UserID, PostID: w.PostID}
+	if w.Post != nil {
+		p := w.Post.Post
+		p.UserID = w.Post.UserID
+		ti.Post = &p
+	}
+	return ti, err
+}
+
+func marshalNotification(n Notification) ([]byte, error) {
+	return marshalWire(wireTypeNotification, wireNotification{Notification: n, UserID: n.UserID})
+}
+
+func unmarshalNotification(data []byte) (Notification, error) {
+	var w wireNotification
+	err := unmarshalWire(data, wireTypeNotification, &w)
+	w.Notification.UserID = w.UserID
+	return w.Notification, err
+}
+
+func marshalComment(c Comment) ([]byte, error) {
+	return marshalWire(wireTypeComment, wireComment{Comment: c, UserID: c.UserID, PostID: c.PostID})
+}
+
+func unmarshalComment(data []byte) (Comment, error) {
+	var w wireComment
+	err := unmarshalWire(data, wireTypeComment, &w)
+	w.Comment.UserID = w.UserID
+	w.Comment.PostID = w.PostID
+	return w.Comment, err
+}
+
+func marshalMessage(m Message) ([]byte, error) {
+	return marshalWire(wireTypeMessage, wireDirectMessage{Message: m, UserID: m.UserID})
+}
+
+func unmarshalMessage(data []byte) (Message, error) {
+	var w wireDirectMessage
+	err := unmarshalWire(data, wireTypeMessage, &w)
+	w.Message.UserID = w.UserID
+	return w.Message, err
+}
+
+func marshalReadReceipt(r ReadReceipt) ([]byte, error) {
+	return marshalWire(wireTypeMessageRead, wireReadReceipt{ReadReceipt: r, UserID: r.UserID})
+}
+
+func unmarshalReadReceipt(data []byte) (ReadReceipt, error) {
+	var w wireReadReceipt
+	err := unmarshalWire(data, wireTypeMessageRead, &w)
+	w.ReadReceipt.UserID = w.UserID
+	return w.ReadReceipt, err
+}
@@ -0,0 +1,109 @@
+package nakama
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nakamauwu/nakama/testutil"
+)
+
+func TestWire(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("comment", func(t *testing.T) {
+		want := Comment{
+			ID:        "00000001-0000-0000-0000-000000000000",
+			UserID:    "00000002-0000-0000-0000-000000000000",
+			PostID:    "00000003-0000-0000-0000-000000000000",
+			Content:   "test",
+			CreatedAt: now,
+		}
+		b, err := marshalComment(want)
+		testutil.WantEq(t, nil, err, "marshal error")
+
+		got, err := unmarshalComment(b)
+		testutil.WantEq(t, nil, err, "unmarshal error")
+		testutil.WantEq(t, want, got, "comment")
+	})
+
+	t.Run("timeline_item", func(t *testing.T) {
+		want := TimelineItem{
+			ID:     "00000004-0000-0000-0000-000000000000",
+			UserID: "00000005-0000-0000-0000-000000000000",
+			PostID: "00000006-0000-0000-0000-000000000000",
+			Post: &Post{
+				ID:        "00000007-0000-0000-0000-000000000000",
+				UserID:    "00000008-0000-0000-0000-000000000000",
+				Content:   "test",
+				CreatedAt: now,
+				UpdatedAt: now,
+			},
+		}
+		b, err := marshalTimelineItem(want)
+		testutil.WantEq(t, nil, err, "marshal error")
+
+		got, err := unmarshalTimelineItem(b)
+		testutil.WantEq(t, nil, err, "unmarshal error")
+		testutil.WantEq(t, want, got, "timeline item")
+	})
+
+	t.Run("message", func(t *testing.T) {
+		want := Message{
+			ID:             "0000000a-0000-0000-0000-000000000000",
+			ConversationID: "0000000b-0000-0000-0000-000000000000",
+			UserID:         "0000000c-0000-0000-0000-000000000000",
+			Content:        "test",
+			MediaURLs:      []string{"https://example.org/img/media/a.jpg"},
+			CreatedAt:      now,
+			User:           &User{Username: "shinji"},
+		}
+		b, err := marshalMessage(want)
+		testutil.WantEq(t, nil, err, "marshal error")
+
+		got, err := unmarshalMessage(b)
+		testutil.WantEq(t, nil, err, "unmarshal error")
+		testutil.WantEq(t, want, got, "message")
+	})
+
+	t.Run("message_read", func(t *testing.T) {
+		want := ReadReceipt{
+			ConversationID: "0000000d-0000-0000-0000-000000000000",
+			UserID:         "0000000e-0000-0000-0000-000000000000",
+			User:           User{Username: "rei"},
+			ReadAt:         now,
+		}
+		b, err := marshalReadReceipt(want)
+		testutil.WantEq(t, nil, err, "marshal error")
+
+		got, err := unmarshalReadReceipt(b)
+		testutil.WantEq(t, nil, err, "unmarshal error")
+		testutil.WantEq(t, want, got, "read receipt")
+
+		_, err = unmarshalMessage(b)
+		testutil.WantEq(t, true, errors.Is(err, errUnexpectedWireType), "message error")
+	})
+
+	t.Run("unknown_fields", func(t *testing.T) {
+		data := []byte(`{"type":"notification","version":1,"trace":"x","payload":{"id":"a","userID":"b","type":"follow","extra":true}}`)
+		got, err := unmarshalNotification(data)
+		testutil.WantEq(t, nil, err, "error")
+		testutil.WantEq(t, "a", got.ID, "id")
+		testutil.WantEq(t, "b", got.UserID, "user id")
+		testutil.WantEq(t, "follow", got.Type, "type")
+	})
+
+	t.Run("unsupported_version", func(t *testing.T) {
+		data := []byte(`{"type":"post","version":2,"payload":{}}`)
+		_, err := unmarshalPost(data)
+		testutil.WantEq(t, true, errors.Is(err, errUnsupportedWireVersion), "error")
+	})
+
+	t.Run("unexpected_type", func(t *testing.T) {
+		b, err := marshalPost(Post{ID: "00000009-0000-0000-0000-000000000000"})
+		testutil.WantEq(t, nil, err, "marshal error")
+
+		_, err = unmarshalComment(b)
+		testutil.WantEq(t, true, errors.Is(err, errUnexpectedWireType), "error")
+	})
+}